@@ -0,0 +1,124 @@
+package stogo
+
+import "errors"
+
+// Awaitable operation running in the background, such as a Future.
+type Awaitable interface {
+	// Done returns a channel closed once the operation completed.
+	Done() <-chan struct{}
+	// Err waits for the operation to complete and returns its error.
+	Err() error
+}
+
+// Future result of a call started with one of the Async methods, available once it completed.
+// It is safe for concurrent use.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Done returns a channel closed once the call completed, to select on several futures.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the call to complete and returns its result.
+func (f *Future[T]) Wait() (T, error) {
+	<-f.done
+	return f.value, f.err
+}
+
+// Err waits for the call to complete and returns its error.
+func (f *Future[T]) Err() error {
+	<-f.done
+	return f.err
+}
+
+// WaitAll waits for every future to complete and returns their errors joined together, nil if
+// they all succeeded.
+//
+// Usage example:
+//
+//	futures := make([]stogo.Awaitable, 0, len(flags))
+//	for key, value := range flags {
+//		futures = append(futures, client.SetAsync("my-app", "prod", key, value))
+//	}
+//	if err := stogo.WaitAll(futures...); err != nil {
+//		log.Printf("Error setting flags %v", err)
+//	}
+func WaitAll(futures ...Awaitable) error {
+	var errs []error
+	for _, f := range futures {
+		if err := f.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// async runs call in the background once a slot of the async concurrency limit is free, see
+// config.StooConfig.WithAsyncConcurrency.
+func async[T any](c *StooClient, opts []CallOption, call func() (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		acquired, err := c.async.acquire(newCallOptions(opts).ctx, c.CurrentConfig().GetAsyncConcurrency())
+		if err != nil {
+			f.err = err
+			return
+		}
+		if acquired {
+			defer c.async.release()
+		}
+		f.value, f.err = call()
+	}()
+	return f
+}
+
+// GetAsync starts getting a value like Get in the background and returns its Future, so that
+// many reads can be fanned out without managing goroutines. At most
+// config.StooConfig.WithAsyncConcurrency calls started this way run at once.
+//
+// Usage example:
+//
+//	host := client.GetAsync("my-app", "prod", "database.host")
+//	port := client.GetAsync("my-app", "prod", "database.port")
+//	if err := stogo.WaitAll(host, port); err != nil {
+//		log.Fatalf("Error reading database settings %v", err)
+//	}
+//	h, _ := host.Wait()
+//	p, _ := port.Wait()
+func (c *StooClient) GetAsync(namespace, profile, key string, opts ...CallOption) *Future[string] {
+	return async(c, opts, func() (string, error) {
+		return c.Get(namespace, profile, key, opts...)
+	})
+}
+
+// GetAllAsync starts getting all keys of a profile like GetAll in the background, see GetAsync.
+func (c *StooClient) GetAllAsync(namespace, profile string, opts ...CallOption) *Future[map[string]string] {
+	return async(c, opts, func() (map[string]string, error) {
+		return c.GetAll(namespace, profile, opts...)
+	})
+}
+
+// SetAsync starts setting a key like Set in the background, see GetAsync.
+func (c *StooClient) SetAsync(namespace, profile, key, value string, opts ...CallOption) *Future[string] {
+	return async(c, opts, func() (string, error) {
+		return c.Set(namespace, profile, key, value, opts...)
+	})
+}
+
+// SetSecretAsync starts setting a secret like SetSecret in the background, see GetAsync.
+func (c *StooClient) SetSecretAsync(namespace, profile, key, value string, opts ...CallOption) *Future[string] {
+	return async(c, opts, func() (string, error) {
+		return c.SetSecret(namespace, profile, key, value, opts...)
+	})
+}
+
+// DeleteAsync starts deleting a key like Delete in the background, see GetAsync.
+func (c *StooClient) DeleteAsync(namespace, profile, key string, opts ...CallOption) *Future[string] {
+	return async(c, opts, func() (string, error) {
+		return c.Delete(namespace, profile, key, opts...)
+	})
+}
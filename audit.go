@@ -0,0 +1,60 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"path"
+)
+
+// identityKey context key of the caller identity set with WithIdentity.
+type identityKey struct{}
+
+// auditInterceptor reports every write and its outcome to the configured audit hook and
+// mutation log.
+func auditInterceptor(c *StooClient) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		cfg := c.CurrentConfig()
+		hook, log := cfg.GetAuditHook(), cfg.GetMutationLog()
+		if hook == nil && log == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		var event config.AuditEvent
+		switch r := req.(type) {
+		case *proto.SetKeyRequest:
+			event = config.AuditEvent{Op: config.AuditSet, Namespace: r.GetNamespace(), Profile: r.GetProfile(), Key: r.GetKey(), Value: r.GetValue()}
+			if path.Base(method) == "SetSecretKeyService" {
+				event.Op = config.AuditSetSecret
+			}
+			if event.Secret = event.Op == config.AuditSetSecret || cfg.IsSecretKey(r.GetKey()); event.Secret {
+				event.Value = MaskedValue
+			}
+		case *proto.DeleteKeyRequest:
+			event = config.AuditEvent{Op: config.AuditDelete, Namespace: r.GetNamespace(), Profile: r.GetProfile(), Key: r.GetKey()}
+		default:
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		event.Time = cfg.GetClock().Now()
+		event.Identity = cfg.GetAuditIdentity()
+		if identity, ok := ctx.Value(identityKey{}).(string); ok {
+			event.Identity = identity
+		}
+		event.DryRun = cfg.GetDryRun()
+		event.Err = err
+		if hook != nil {
+			hook(ctx, event)
+		}
+		if log != nil {
+			var result string
+			if r, ok := reply.(interface{ GetData() string }); ok && err == nil {
+				result = r.GetData()
+			}
+			if werr := c.journal.write(log, event, result); werr != nil {
+				cfg.GetLogger().Warnf("mutation log: %v", werr)
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,208 @@
+package stogo
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBatchWriterClosed thrown when a write is queued on a closed BatchWriter.
+var ErrBatchWriterClosed = errors.New("stogo: batch writer is closed")
+
+// batchWriteConcurrency max writes of a batch in flight at once.
+const batchWriteConcurrency = 8
+
+// BatchWriter queues writes to a namespace and profile and sends them in batches, every flush
+// interval or as soon as a batch is full. Writes queued to the same key before a flush are
+// coalesced, only the last one being sent, and the writes of a batch are sent concurrently, so
+// a producer writing many keys per second makes far fewer, parallel calls. Batches are sent in
+// the order they were queued. Writes failing are reported to the OnError callbacks, or logged if
+// there are none. It is safe for concurrent use.
+type BatchWriter struct {
+	client    *StooClient
+	namespace string
+	profile   string
+	maxBatch  int
+
+	mu        sync.Mutex
+	pending   map[string]mutation
+	callbacks []func(*BulkItemError)
+	closed    bool
+
+	// flushMu serializes flushes so that batches are sent in order.
+	flushMu sync.Mutex
+	full    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewBatchWriter creates a BatchWriter of a namespace and profile sending the queued writes every
+// flushInterval, or as soon as maxBatch distinct keys are queued, until Close is called. If
+// flushInterval is not positive the configured poll interval is used, and if maxBatch is not
+// positive batches are only bounded by the interval.
+//
+// Usage example:
+//
+//	w := client.NewBatchWriter("telemetry", "prod", time.Second, 500)
+//	w.OnError(func(err *stogo.BulkItemError) {
+//		log.Printf("Error writing %s: %v", err.Key, err.Err)
+//	})
+//	defer w.Close()
+//	for sample := range samples {
+//		w.Set(sample.Name, strconv.FormatFloat(sample.Value, 'f', -1, 64))
+//	}
+func (c *StooClient) NewBatchWriter(namespace, profile string, flushInterval time.Duration, maxBatch int) *BatchWriter {
+	if flushInterval <= 0 {
+		flushInterval = c.CurrentConfig().GetPollInterval()
+	}
+	w := &BatchWriter{
+		client:    c,
+		namespace: namespace,
+		profile:   profile,
+		maxBatch:  maxBatch,
+		pending:   make(map[string]mutation),
+		full:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go w.run(flushInterval)
+	return w
+}
+
+// NewBatchWriter creates a BatchWriter of the namespace and profile of the scope, see
+// StooClient.NewBatchWriter.
+func (s *ScopedClient) NewBatchWriter(flushInterval time.Duration, maxBatch int) *BatchWriter {
+	return s.client.NewBatchWriter(s.namespace, s.profile, flushInterval, maxBatch)
+}
+
+// OnError registers fn to be called with every write which failed. Callbacks run on the
+// goroutine sending the batch and should not block.
+func (w *BatchWriter) OnError(fn func(err *BulkItemError)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Set queues setting key to value.
+func (w *BatchWriter) Set(key, value string) error {
+	return w.queue(mutation{key: key, value: value})
+}
+
+// SetSecret queues setting key to value as a secret.
+func (w *BatchWriter) SetSecret(key, value string) error {
+	return w.queue(mutation{key: key, value: value, secret: true})
+}
+
+// Delete queues deleting key.
+func (w *BatchWriter) Delete(key string) error {
+	return w.queue(mutation{key: key, delete: true})
+}
+
+// Pending returns the number of keys queued and not sent yet.
+func (w *BatchWriter) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Flush sends the queued writes now and waits for them, returning the errors of the failed ones
+// joined together. They are reported to the OnError callbacks too.
+func (w *BatchWriter) Flush() error {
+	return w.flush()
+}
+
+// Close stops the background flushes and sends the queued writes, returning the errors of the
+// failed ones joined together. Writes queued afterwards fail with ErrBatchWriterClosed.
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	close(w.stop)
+	<-w.done
+	return w.flush()
+}
+
+// queue adds m to the pending writes, replacing any previous one of the same key, and wakes up
+// the flush loop when the batch is full.
+func (w *BatchWriter) queue(m mutation) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrBatchWriterClosed
+	}
+	w.pending[m.key] = m
+	if w.maxBatch > 0 && len(w.pending) >= w.maxBatch {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// run flushes every interval or when a batch is full, until Close is called.
+func (w *BatchWriter) run(interval time.Duration) {
+	defer close(w.done)
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.full:
+		case <-w.client.CurrentConfig().GetClock().After(interval):
+		}
+		w.flush()
+	}
+}
+
+// flush sends the pending writes, batchWriteConcurrency at a time.
+func (w *BatchWriter) flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	batch := w.pending
+	w.pending = make(map[string]mutation)
+	callbacks := w.callbacks
+	w.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []error
+		slots  = make(chan struct{}, batchWriteConcurrency)
+	)
+	for _, m := range batch {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(m mutation) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			err := w.client.mutate(w.namespace, w.profile, m)
+			if err == nil {
+				return
+			}
+			op := "set"
+			if m.delete {
+				op = "delete"
+			}
+			itemErr := &BulkItemError{Op: op, Key: m.key, Err: err}
+			mu.Lock()
+			failed = append(failed, itemErr)
+			mu.Unlock()
+			if len(callbacks) == 0 {
+				w.client.CurrentConfig().GetLogger().Warnf("batch writer %s/%s: %v", w.namespace, w.profile, itemErr)
+			}
+			for _, fn := range callbacks {
+				fn(itemErr)
+			}
+		}(m)
+	}
+	wg.Wait()
+	return errors.Join(failed...)
+}
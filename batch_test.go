@@ -0,0 +1,216 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeCounter counts the writes of every key.
+type writeCounter struct {
+	mu     sync.Mutex
+	writes map[string]int
+}
+
+func (r *writeCounter) interceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var key string
+	switch req := req.(type) {
+	case *proto.SetKeyRequest:
+		key = req.GetKey()
+	case *proto.DeleteKeyRequest:
+		key = req.GetKey()
+	default:
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	r.mu.Lock()
+	r.writes[key]++
+	r.mu.Unlock()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func (r *writeCounter) count(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writes[key]
+}
+
+// newBatchClient returns a client of srv whose clock never advances on its own, so that batches
+// are only sent when full or flushed, and whose writes are counted by counter.
+func newBatchClient(srv *stogotest.Server, counter *writeCounter) *stogo.StooClient {
+	return stogo.NewStoreClient(srv.Config().
+		WithClock(stogotest.NewFakeClock(time.Now())).
+		WithMaxValueSize(16).
+		WithUnaryInterceptors(counter.interceptor))
+}
+
+func TestBatchWriterCoalesces(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	counter := &writeCounter{writes: make(map[string]int)}
+	w := newBatchClient(srv, counter).NewBatchWriter("app", "prod", time.Minute, 0)
+	defer w.Close()
+
+	for _, value := range []string{"1", "2", "3"} {
+		if err := w.Set("counter", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Set("gauge", "0.5"); err != nil {
+		t.Fatal(err)
+	}
+	if pending := w.Pending(); pending != 2 {
+		t.Fatalf("got %d pending writes, want 2", pending)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if writes := counter.count("counter"); writes != 1 {
+		t.Errorf("got %d writes of counter, want the 3 queued ones coalesced", writes)
+	}
+	if data := srv.Data("app", "prod"); data["counter"] != "3" || data["gauge"] != "0.5" {
+		t.Errorf("got %v, want the last value of every key", data)
+	}
+	if pending := w.Pending(); pending != 0 {
+		t.Errorf("got %d pending writes after Flush, want 0", pending)
+	}
+}
+
+func TestBatchWriterFullBatch(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	counter := &writeCounter{writes: make(map[string]int)}
+	w := newBatchClient(srv, counter).NewBatchWriter("app", "prod", time.Minute, 3)
+	defer w.Close()
+
+	for _, key := range []string{"a", "b"} {
+		if err := w.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if data := srv.Data("app", "prod"); len(data) != 0 {
+		t.Fatalf("got %v sent before the batch is full", data)
+	}
+	if err := w.Set("c", "c"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.Data("app", "prod")) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %v, want the full batch sent without a flush", srv.Data("app", "prod"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBatchWriterOrder(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	// The first write of key is held until release is closed.
+	started, release := make(chan struct{}), make(chan struct{})
+	var once, released sync.Once
+	unblock := func() { released.Do(func() { close(release) }) }
+	hold := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if set, ok := req.(*proto.SetKeyRequest); ok && set.GetKey() == "key" {
+			once.Do(func() {
+				close(started)
+				<-release
+			})
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	client := stogo.NewStoreClient(srv.Config().
+		WithClock(stogotest.NewFakeClock(time.Now())).
+		WithUnaryInterceptors(hold))
+	w := client.NewBatchWriter("app", "prod", time.Minute, 2)
+	defer w.Close()
+	defer unblock()
+
+	if err := w.Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Set("other", "value"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := w.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	flushed := make(chan error, 1)
+	go func() {
+		flushed <- w.Flush()
+	}()
+	select {
+	case err := <-flushed:
+		t.Fatalf("got Flush returning %v while the previous batch is being sent", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	unblock()
+	if err := <-flushed; err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := srv.Data("app", "prod")["key"]; ok {
+		t.Errorf("got key %q, want it deleted by the later batch", value)
+	}
+}
+
+func TestBatchWriterClose(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	counter := &writeCounter{writes: make(map[string]int)}
+	w := newBatchClient(srv, counter).NewBatchWriter("app", "prod", time.Minute, 0)
+
+	var reported []string
+	w.OnError(func(err *stogo.BulkItemError) {
+		reported = append(reported, err.Key)
+	})
+	if err := w.Set("host", "db.internal"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetSecret("password", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Set("motd", "a value far too large"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Close()
+	var itemErr *stogo.BulkItemError
+	if !errors.As(err, &itemErr) || itemErr.Key != "motd" || !errors.Is(err, stogo.ErrValueTooLarge) {
+		t.Fatalf("got error %v from Close, want the failed write of motd", err)
+	}
+	if len(reported) != 1 || reported[0] != "motd" {
+		t.Errorf("got %v reported to OnError, want [motd]", reported)
+	}
+	if data := srv.Data("app", "prod"); data["host"] != "db.internal" || data["password"] != "s3cr3t" {
+		t.Errorf("got %v after Close, want the queued writes sent", data)
+	}
+
+	if err := w.Set("host", "db2.internal"); !errors.Is(err, stogo.ErrBatchWriterClosed) {
+		t.Errorf("got error %v writing after Close, want %v", err, stogo.ErrBatchWriterClosed)
+	}
+	if err := w.Delete("host"); !errors.Is(err, stogo.ErrBatchWriterClosed) {
+		t.Errorf("got error %v deleting after Close, want %v", err, stogo.ErrBatchWriterClosed)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("got error %v closing twice, want nil", err)
+	}
+}
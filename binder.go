@@ -0,0 +1,173 @@
+package stogo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyBound thrown by Binder.Bind when the binder is already bound.
+var ErrAlreadyBound = errors.New("stogo: binder is already bound")
+
+// Binder keeps a struct in sync with a namespace and profile. Changes are picked up by Watch,
+// decoded with Unmarshal and announced to the registered OnChange callbacks.
+type Binder struct {
+	client    *StooClient
+	namespace string
+	profile   string
+	interval  time.Duration
+	strict    bool
+
+	mu        sync.Mutex
+	bound     bool
+	value     reflect.Value
+	initial   reflect.Value
+	callbacks []func(old, new any)
+	cancel    context.CancelFunc
+}
+
+// NewBinder creates a Binder for the given namespace and profile. Poll interval defaults to
+// the configured poll interval.
+//
+// Usage example:
+//
+//	var cfg Config
+//	b := stogo.NewBinder(client, "my-app", "prod").WithInterval(15 * time.Second)
+//	b.OnChange(func(old, new any) {
+//		log.Printf("config changed from %+v to %+v", old.(Config), new.(Config))
+//	})
+//	if err := b.Bind(&cfg); err != nil {
+//		log.Fatalf("Error binding config %v", err)
+//	}
+//	defer b.Close()
+func NewBinder(client *StooClient, namespace, profile string) *Binder {
+	return &Binder{
+		client:    client,
+		namespace: namespace,
+		profile:   profile,
+	}
+}
+
+// WithInterval sets the poll interval.
+func (b *Binder) WithInterval(interval time.Duration) *Binder {
+	b.interval = interval
+	return b
+}
+
+// WithStrict makes the binder decode with UnmarshalStrict, rejecting updates holding keys
+// not mapped to the bound struct.
+func (b *Binder) WithStrict(strict bool) *Binder {
+	b.strict = strict
+	return b
+}
+
+// OnChange registers fn to be called with copies of the previous and the new struct value
+// every time the bound struct changes.
+func (b *Binder) OnChange(fn func(old, new any)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.callbacks = append(b.callbacks, fn)
+}
+
+// Bind loads the current values into target, which must be a non-nil pointer to a struct,
+// and keeps a private copy of it updated in the background until Close is called. Values
+// present in target before Bind are kept as defaults for keys missing from the store. target
+// is only written by Bind, later updates are read with Value. A binder can be bound once,
+// further calls returning ErrAlreadyBound.
+func (b *Binder) Bind(target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidUnmarshalTarget
+	}
+	b.mu.Lock()
+	if b.bound {
+		b.mu.Unlock()
+		return ErrAlreadyBound
+	}
+	b.bound = true
+	b.mu.Unlock()
+
+	data, err := b.client.GetAll(b.namespace, b.profile)
+	if err != nil {
+		b.unbind()
+		return err
+	}
+	b.mu.Lock()
+	b.initial = reflect.New(rv.Elem().Type()).Elem()
+	b.initial.Set(rv.Elem())
+	b.value = reflect.New(rv.Elem().Type()).Elem()
+	b.value.Set(rv.Elem())
+	b.mu.Unlock()
+	if err := b.apply(data); err != nil {
+		b.unbind()
+		return err
+	}
+	b.mu.Lock()
+	rv.Elem().Set(b.value)
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.mu.Lock()
+	b.cancel = cancel
+	b.mu.Unlock()
+	go b.client.Watch(ctx, b.namespace, b.profile, b.interval, func(all map[string]string) {
+		if err := b.apply(all); err != nil {
+			b.client.CurrentConfig().GetLogger().Warnf("binder %s/%s: %v", b.namespace, b.profile, err)
+		}
+	})
+	return nil
+}
+
+// unbind allows Bind to be called again after it failed.
+func (b *Binder) unbind() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bound = false
+	b.value = reflect.Value{}
+}
+
+// Value returns a copy of the bound struct, nil before Bind.
+func (b *Binder) Value() any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.value.IsValid() {
+		return nil
+	}
+	return b.value.Interface()
+}
+
+// Close stops watching for changes.
+func (b *Binder) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		b.cancel()
+	}
+}
+
+// apply decodes data on top of the initial struct value and replaces the bound copy if it differs.
+func (b *Binder) apply(data map[string]string) error {
+	b.mu.Lock()
+	next := reflect.New(b.initial.Type())
+	next.Elem().Set(b.initial)
+	if err := decodeInto(data, next.Elem(), b.strict); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	old := b.value.Interface()
+	current := next.Elem().Interface()
+	if reflect.DeepEqual(old, current) {
+		b.mu.Unlock()
+		return nil
+	}
+	b.value.Set(next.Elem())
+	callbacks := append([]func(old, new any){}, b.callbacks...)
+	b.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(old, current)
+	}
+	return nil
+}
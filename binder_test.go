@@ -0,0 +1,94 @@
+package stogo_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/stogotest"
+	"testing"
+	"time"
+)
+
+type boundConfig struct {
+	Host string `stoo:"db.host"`
+	Port int    `stoo:"db.port"`
+}
+
+func TestBinderBind(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	client := stogo.NewStoreClient(srv.Config())
+	if _, err := client.Set("app", "prod", "db.port", "5432"); err != nil {
+		t.Fatal(err)
+	}
+	bound := stogo.NewBinder(client, "app", "prod")
+	if err := bound.Bind(&boundConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	defer bound.Close()
+
+	tests := []struct {
+		name    string
+		binder  *stogo.Binder
+		target  any
+		wantErr error
+	}{
+		{"nil target", stogo.NewBinder(client, "app", "prod"), (*boundConfig)(nil), stogo.ErrInvalidUnmarshalTarget},
+		{"not a struct", stogo.NewBinder(client, "app", "prod"), new(string), stogo.ErrInvalidUnmarshalTarget},
+		{"bound twice", bound, &boundConfig{}, stogo.ErrAlreadyBound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.binder.Bind(tt.target)
+			defer tt.binder.Close()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBinderUpdates(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	clock := stogotest.NewFakeClock(time.Now())
+	client := stogo.NewStoreClient(srv.Config().WithClock(clock))
+	if _, err := client.Set("app", "prod", "db.port", "5432"); err != nil {
+		t.Fatal(err)
+	}
+
+	target := boundConfig{Host: "localhost"}
+	b := stogo.NewBinder(client, "app", "prod").WithInterval(time.Minute)
+	changed := make(chan any, 1)
+	b.OnChange(func(_, new any) { changed <- new })
+	if err := b.Bind(&target); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	<-changed
+	want := boundConfig{Host: "localhost", Port: 5432}
+	if target != want {
+		t.Fatalf("got %+v after Bind, want %+v", target, want)
+	}
+
+	if _, err := client.Set("app", "prod", "db.port", "6000"); err != nil {
+		t.Fatal(err)
+	}
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	<-changed
+	// target is read concurrently with the watcher, which must not write it anymore.
+	if target != want {
+		t.Errorf("got target %+v updated in the background, want %+v", target, want)
+	}
+	if got := b.Value(); got != (boundConfig{Host: "localhost", Port: 6000}) {
+		t.Errorf("got value %+v, want port 6000", got)
+	}
+}
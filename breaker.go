@@ -0,0 +1,134 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen thrown when a call fails fast because the circuit breaker is open, see
+// config.StooConfig.WithCircuitBreaker. It carries the Unavailable gRPC code, so reads are
+// served from the fallback file, if any.
+var ErrCircuitOpen error = circuitOpenError{}
+
+// circuitOpenError type of ErrCircuitOpen.
+type circuitOpenError struct{}
+
+// Error implements error.
+func (circuitOpenError) Error() string {
+	return "stogo: circuit breaker is open"
+}
+
+// GRPCStatus returns the Unavailable status.
+func (circuitOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, "circuit breaker is open")
+}
+
+// circuitState state of the circuit breaker.
+type circuitState int
+
+const (
+	// circuitClosed calls go through.
+	circuitClosed circuitState = iota
+	// circuitOpen calls fail fast.
+	circuitOpen
+	// circuitHalfOpen a few probe calls go through to tell if StooKV recovered.
+	circuitHalfOpen
+)
+
+// breaker circuit breaker shared by every call of a client. Settings are read from the current
+// configuration on every call. The zero value is ready to use.
+type breaker struct {
+	mu          sync.Mutex
+	state       circuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	succeeded   int
+}
+
+// interceptor fails calls fast while the circuit is open and records the outcome of the others.
+func (b *breaker) interceptor(c *StooClient) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		cfg := c.CurrentConfig()
+		settings := cfg.GetCircuitBreaker()
+		if settings == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		clock := cfg.GetClock()
+		probe, ok := b.allow(settings.OpenDuration, settings.HalfOpenProbes, clock.Now())
+		if !ok {
+			return ErrCircuitOpen
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(settings, probe, isEndpointError(ctx, err), clock.Now())
+		return err
+	}
+}
+
+// allow tells if a call can go through at now and whether it is a half open probe.
+func (b *breaker) allow(openDuration time.Duration, probes int, now time.Time) (probe bool, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == circuitOpen {
+		if now.Sub(b.openedAt) < openDuration {
+			return false, false
+		}
+		b.state = circuitHalfOpen
+		b.probes, b.succeeded = 0, 0
+	}
+	if b.state == circuitHalfOpen {
+		if b.probes >= probes {
+			return false, false
+		}
+		b.probes++
+		return true, true
+	}
+	return false, true
+}
+
+// record updates the breaker with the outcome of a call.
+func (b *breaker) record(settings *config.Breaker, probe, failed bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		if b.state != circuitHalfOpen {
+			return
+		}
+		if failed {
+			b.open(now)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= settings.HalfOpenProbes {
+			b.state = circuitClosed
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		return
+	}
+	if b.state != circuitClosed {
+		return
+	}
+	if now.Sub(b.windowStart) >= settings.Window {
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= settings.MinRequests && float64(b.failures)/float64(b.requests) >= settings.ErrorRate {
+		b.open(now)
+	}
+}
+
+// open opens the circuit at now.
+func (b *breaker) open(now time.Time) {
+	b.state = circuitOpen
+	b.openedAt = now
+}
@@ -0,0 +1,93 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// switchServer answers reads with value, or fails them with code unless it is codes.OK.
+type switchServer struct {
+	proto.UnimplementedKVServiceServer
+	mu   sync.Mutex
+	code codes.Code
+}
+
+func (s *switchServer) GetService(context.Context, *proto.GetRequest) (*proto.GetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.code != codes.OK {
+		return nil, status.Error(s.code, "switched off")
+	}
+	return &proto.GetResponse{Data: "value"}, nil
+}
+
+func (s *switchServer) set(code codes.Code) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.code = code
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := &switchServer{}
+	server := grpc.NewServer()
+	proto.RegisterKVServiceServer(server, kv)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	clock := stogotest.NewFakeClock(time.Now())
+	client := stogo.NewStoreClient(config.NewStooConfig(lis.Addr().String(), time.Second).
+		WithClock(clock).
+		WithCircuitBreaker(config.Breaker{ErrorRate: 0.5, MinRequests: 4, Window: time.Minute, OpenDuration: 10 * time.Second}))
+
+	// Steps run in order, each making calls calls while the server answers with code.
+	steps := []struct {
+		name    string
+		advance time.Duration
+		code    codes.Code
+		calls   int
+		wantErr error
+	}{
+		{"request errors are not counted", 0, codes.NotFound, 4, stogo.ErrKeyNotFound},
+		{"failures below min requests", 0, codes.Unavailable, 3, nil},
+		{"failure rate reached", 0, codes.Unavailable, 1, nil},
+		{"open circuit fails fast", 0, codes.OK, 1, stogo.ErrCircuitOpen},
+		{"failed probe", 10 * time.Second, codes.Unavailable, 1, nil},
+		{"reopened circuit fails fast", 0, codes.OK, 1, stogo.ErrCircuitOpen},
+		{"successful probe", 10 * time.Second, codes.OK, 1, nil},
+		{"closed circuit", 0, codes.OK, 4, nil},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		kv.set(step.code)
+		for i := 0; i < step.calls; i++ {
+			_, err := client.Get("app", "prod", "key")
+			switch {
+			case step.wantErr != nil:
+				if !errors.Is(err, step.wantErr) {
+					t.Fatalf("%s: got error %v, want %v", step.name, err, step.wantErr)
+				}
+			case step.code == codes.OK:
+				if err != nil {
+					t.Fatalf("%s: got error %v", step.name, err)
+				}
+			case status.Code(err) != step.code || errors.Is(err, stogo.ErrCircuitOpen):
+				t.Fatalf("%s: got error %v, want %v from the server", step.name, err, step.code)
+			}
+		}
+	}
+}
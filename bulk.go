@@ -0,0 +1,127 @@
+package stogo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// BulkItemError error of a single failed item of a bulk operation.
+type BulkItemError struct {
+	// Op operation which failed, set or delete.
+	Op string
+	// Key key the operation failed on.
+	Key string
+	// Err underlying error.
+	Err error
+}
+
+// Error implements error.
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("stogo: bulk %s %s: %v", e.Op, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BulkItemError) Unwrap() error {
+	return e.Err
+}
+
+// BulkResult outcome of a bulk operation, listing every item that succeeded and every item that failed.
+type BulkResult struct {
+	// Succeeded keys which were applied.
+	Succeeded []string
+	// Failed errors of the keys which were not applied.
+	Failed []*BulkItemError
+	// Skipped keys left untouched on purpose, e.g. because of a conflict policy.
+	Skipped []string
+}
+
+// Err returns all item errors joined together or nil if every item succeeded.
+func (r *BulkResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	errs := make([]error, len(r.Failed))
+	for i, failed := range r.Failed {
+		errs[i] = failed
+	}
+	return errors.Join(errs...)
+}
+
+// FailedKeys returns the keys which failed, so that they can be retried.
+func (r *BulkResult) FailedKeys() []string {
+	keys := make([]string, len(r.Failed))
+	for i, failed := range r.Failed {
+		keys[i] = failed.Key
+	}
+	return keys
+}
+
+// add records the outcome of an item.
+func (r *BulkResult) add(op, key string, err error) {
+	if err != nil {
+		r.Failed = append(r.Failed, &BulkItemError{Op: op, Key: key, Err: err})
+		return
+	}
+	r.Succeeded = append(r.Succeeded, key)
+}
+
+// SetMany sets all given key value pairs to a namespace and profile, in key order. Every pair is
+// attempted, failures are reported per key in the result.
+//
+// Usage example:
+//
+//	res := client.SetMany("my-app", "prod", map[string]string{
+//		"database.host": "db.example.com",
+//		"database.port": "5432",
+//	})
+//	if err := res.Err(); err != nil {
+//		log.Printf("Failed keys %v: %v", res.FailedKeys(), err)
+//	}
+func (c *StooClient) SetMany(namespace, profile string, values map[string]string) *BulkResult {
+	result := &BulkResult{}
+	for _, key := range sortedKeys(values) {
+		_, err := c.Set(namespace, profile, key, values[key])
+		result.add("set", key, err)
+	}
+	return result
+}
+
+// DeleteMany removes all given keys from a namespace and profile. Every key is attempted,
+// failures are reported per key in the result.
+func (c *StooClient) DeleteMany(namespace, profile string, keys []string) *BulkResult {
+	result := &BulkResult{}
+	for _, key := range keys {
+		_, err := c.Delete(namespace, profile, key)
+		result.add("delete", key, err)
+	}
+	return result
+}
+
+// Import reads a JSON object of key value pairs from r and sets them to a namespace and profile
+// using SetMany. The returned error only reports a failure to decode the input.
+//
+// Usage example:
+//
+//	f, _ := os.Open("prod.json")
+//	defer f.Close()
+//	res, err := client.Import("my-app", "prod", f)
+func (c *StooClient) Import(namespace, profile string, r io.Reader) (*BulkResult, error) {
+	var values map[string]string
+	if err := json.NewDecoder(r).Decode(&values); err != nil {
+		return nil, err
+	}
+	return c.SetMany(namespace, profile, values), nil
+}
+
+// sortedKeys returns the keys of values in ascending order.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"sync"
+)
+
+// eventBuffer events buffered per subscriber, further events are dropped until it catches up.
+const eventBuffer = 64
+
+// eventBus topics of the profiles subscribed to with Subscribe. The zero value is ready to use.
+type eventBus struct {
+	mu     sync.Mutex
+	topics map[config.NamespaceProfile]*topic
+}
+
+// topic subscribers of a profile, sharing a single watch.
+type topic struct {
+	subs   map[chan Event]struct{}
+	cancel context.CancelFunc
+}
+
+// Subscribe returns a channel receiving an Event for every key changed in a namespace and
+// profile, along with the function ending the subscription and closing the channel.
+// Subscribers of a profile share a single watch polling at the configured poll interval, see
+// Watch, and keys present when the watch starts are not reported. Each subscriber has its
+// own buffer of 64 events, when it is full further events for that subscriber are dropped and
+// logged, so a slow subscriber never holds up the others; handlers doing heavy work should
+// hand events over to a Dispatcher.
+//
+// Usage example:
+//
+//	events, unsubscribe := client.Subscribe("my-app", "prod")
+//	defer unsubscribe()
+//	for e := range events {
+//		cache.Invalidate(e.Key)
+//	}
+func (c *StooClient) Subscribe(namespace, profile string) (<-chan Event, func()) {
+	p := config.NamespaceProfile{Namespace: namespace, Profile: profile}
+	ch := make(chan Event, eventBuffer)
+	b := &c.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.topics == nil {
+		b.topics = make(map[config.NamespaceProfile]*topic)
+	}
+	t, ok := b.topics[p]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		t = &topic{subs: make(map[chan Event]struct{}), cancel: cancel}
+		b.topics[p] = t
+		go c.publish(ctx, p, t)
+	}
+	t.subs[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(t.subs, ch)
+			close(ch)
+			if len(t.subs) == 0 {
+				t.cancel()
+				if b.topics[p] == t {
+					delete(b.topics, p)
+				}
+			}
+		})
+	}
+}
+
+// publish watches a profile and sends its changes to the subscribers of t until ctx is done.
+func (c *StooClient) publish(ctx context.Context, p config.NamespaceProfile, t *topic) {
+	var last map[string]string
+	c.Watch(ctx, p.Namespace, p.Profile, 0, func(all map[string]string) {
+		if last != nil {
+			events := diffEvents(p.Namespace, p.Profile, last, all, c.CurrentConfig().GetClock().Now())
+			c.events.mu.Lock()
+			for ch := range t.subs {
+				for _, e := range events {
+					select {
+					case ch <- e:
+					default:
+						c.CurrentConfig().GetLogger().Warnf("subscriber of %s/%s is falling behind, dropped %s event of %s", p.Namespace, p.Profile, e.Type, e.Key)
+					}
+				}
+			}
+			c.events.mu.Unlock()
+		}
+		last = all
+		if last == nil {
+			last = map[string]string{}
+		}
+	})
+}
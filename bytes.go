@@ -0,0 +1,27 @@
+package stogo
+
+import "github.com/mwangox/stogo/codec"
+
+// SetBytes sets binary data like Set, stored base64 encoded with codec.Binary so that it
+// survives the string based API. WithCodec can still be given to e.g. compress data instead.
+//
+// Usage example:
+//
+//	der, _ := os.ReadFile("ca.der")
+//	if _, err := client.SetBytes("my-app", "prod", "tls.ca", der); err != nil {
+//		log.Fatalf("Error in setting value %v", err)
+//	}
+func (c *StooClient) SetBytes(namespace, profile, key string, data []byte, opts ...CallOption) (string, error) {
+	opts = append([]CallOption{WithCodec(codec.Binary)}, opts...)
+	return c.Set(namespace, profile, key, string(data), opts...)
+}
+
+// GetBytes gets binary data written by SetBytes. Values written as plain strings are returned
+// as they are.
+func (c *StooClient) GetBytes(namespace, profile, key string, opts ...CallOption) ([]byte, error) {
+	value, err := c.Get(namespace, profile, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
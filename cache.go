@@ -0,0 +1,86 @@
+package stogo
+
+import (
+	"github.com/mwangox/stogo/config"
+	"sync"
+	"time"
+)
+
+// cacheEntry cached values of a profile and the time they were fetched at.
+type cacheEntry struct {
+	values    map[string]string
+	fetchedAt time.Time
+}
+
+// profileKey identifies the values of a profile as seen by a tenant, empty for calls made
+// without WithTenant.
+type profileKey struct {
+	tenant string
+	config.NamespaceProfile
+}
+
+// newProfileKey returns the profileKey of a namespace and profile seen by tenant.
+func newProfileKey(tenant, namespace, profile string) profileKey {
+	return profileKey{tenant: tenant, NamespaceProfile: config.NamespaceProfile{Namespace: namespace, Profile: profile}}
+}
+
+// profileCache values of prefetched profiles, kept apart by tenant. The zero value is ready to use.
+type profileCache struct {
+	mu      sync.Mutex
+	entries map[profileKey]cacheEntry
+}
+
+// prefetch fetches the configured profiles concurrently, filling the cache.
+func (c *StooClient) prefetch() {
+	var wg sync.WaitGroup
+	for _, p := range c.CurrentConfig().GetPrefetch() {
+		wg.Add(1)
+		go func(p config.NamespaceProfile) {
+			defer wg.Done()
+			if _, err := c.GetAll(p.Namespace, p.Profile); err != nil {
+				c.CurrentConfig().GetLogger().Warnf("prefetch %s/%s: %v", p.Namespace, p.Profile, err)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+// cached returns a copy of the values of a profile cached for tenant if they are younger than
+// the cache TTL.
+func (c *StooClient) cached(tenant, namespace, profile string) (map[string]string, bool) {
+	cfg := c.CurrentConfig()
+	if !cfg.IsPrefetched(namespace, profile) {
+		return nil, false
+	}
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	entry, ok := c.cache.entries[newProfileKey(tenant, namespace, profile)]
+	if !ok || cfg.GetClock().Now().Sub(entry.fetchedAt) >= cfg.GetCacheTTL() {
+		return nil, false
+	}
+	return copyValues(entry.values), true
+}
+
+// storeCache caches values of a profile for tenant if it is prefetched.
+func (c *StooClient) storeCache(tenant, namespace, profile string, values map[string]string) {
+	cfg := c.CurrentConfig()
+	if !cfg.IsPrefetched(namespace, profile) {
+		return
+	}
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if c.cache.entries == nil {
+		c.cache.entries = make(map[profileKey]cacheEntry)
+	}
+	c.cache.entries[newProfileKey(tenant, namespace, profile)] = cacheEntry{
+		values:    copyValues(values),
+		fetchedAt: cfg.GetClock().Now(),
+	}
+}
+
+// invalidateCache drops the values of a profile cached for tenant, after a write.
+func (c *StooClient) invalidateCache(tenant, namespace, profile string) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	delete(c.cache.entries, newProfileKey(tenant, namespace, profile))
+}
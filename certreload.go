@@ -0,0 +1,127 @@
+package stogo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// certReloader serves the CA and client certificates of TLS settings, reloading them from disk
+// when their files change, see config.TLS.ReloadInterval.
+type certReloader struct {
+	t      *config.TLS
+	clock  config.Clock
+	logger config.Logger
+
+	mu          sync.Mutex
+	pool        *x509.CertPool
+	cert        *tls.Certificate
+	fingerprint string
+	checkedAt   time.Time
+}
+
+// newCertReloader creates a certReloader starting from the already loaded pool and cert.
+func newCertReloader(cfg *config.StooConfig, t *config.TLS, pool *x509.CertPool, cert *tls.Certificate) *certReloader {
+	r := &certReloader{
+		t:      t,
+		clock:  cfg.GetClock(),
+		logger: cfg.GetLogger(),
+		pool:   pool,
+		cert:   cert,
+	}
+	r.fingerprint = r.files()
+	r.checkedAt = r.clock.Now()
+	return r
+}
+
+// configure makes tlsConfig get its certificates from the reloader.
+func (r *certReloader) configure(tlsConfig *tls.Config) {
+	if r.t.CertPath != "" {
+		tlsConfig.Certificates = nil
+		tlsConfig.GetClientCertificate = r.clientCertificate
+	}
+	if r.t.CaCertPath != "" && !r.t.SkipTlsVerification {
+		// Verification is done by verifyConnection against the current pool instead.
+		tlsConfig.RootCAs = nil
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = r.verifyConnection
+	}
+}
+
+// clientCertificate returns the current client certificate.
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	_, cert := r.current()
+	return cert, nil
+}
+
+// verifyConnection verifies the server certificate chain and hostname against the current CA pool.
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	pool, _ := r.current()
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: server presented no certificate")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         pool,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// current returns the CA pool and client certificate, reloading them first if the reload
+// interval elapsed and their files changed. Reload failures are logged and the previous
+// certificates kept.
+func (r *certReloader) current() (*x509.CertPool, *tls.Certificate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	if now.Sub(r.checkedAt) < r.t.ReloadInterval {
+		return r.pool, r.cert
+	}
+	r.checkedAt = now
+	fingerprint := r.files()
+	if fingerprint == r.fingerprint {
+		return r.pool, r.cert
+	}
+
+	pool, err := rootCAs(r.t)
+	if err != nil {
+		r.logger.Warnf("reloading CA certificate %s: %v", r.t.CaCertPath, err)
+		return r.pool, r.cert
+	}
+	cert, err := clientCertificate(r.t)
+	if err != nil {
+		r.logger.Warnf("reloading client certificate %s: %v", r.t.CertPath, err)
+		return r.pool, r.cert
+	}
+	r.logger.Infof("reloaded TLS certificates")
+	r.pool, r.cert, r.fingerprint = pool, cert, fingerprint
+	return r.pool, r.cert
+}
+
+// files returns the size and modification time of every certificate file, to detect changes.
+func (r *certReloader) files() string {
+	var b strings.Builder
+	for _, file := range []string{r.t.CaCertPath, r.t.CertPath, r.t.KeyPath} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:%v;", file, err)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
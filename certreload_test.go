@@ -0,0 +1,247 @@
+package stogo_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCert certificate and private key, parsed and PEM encoded.
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert creates a certificate for 127.0.0.1 named cn, signed by parent or a self-signed
+// CA if parent is nil.
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// connListener listener able to close the connections it accepted, forcing clients to
+// reconnect with a new handshake.
+type connListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (l *connListener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.conns {
+		conn.Close()
+	}
+	l.conns = nil
+}
+
+// rotatingServer TLS server whose certificate can be replaced, recording the name of the
+// client certificate of the last handshake.
+type rotatingServer struct {
+	mu         sync.Mutex
+	cert       *tls.Certificate
+	clientName string
+}
+
+func (s *rotatingServer) rotate(c *testCert) {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		panic(err)
+	}
+	s.mu.Lock()
+	s.cert = &cert
+	s.mu.Unlock()
+}
+
+func (s *rotatingServer) lastClient() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientName
+}
+
+func (s *rotatingServer) tlsConfig() *tls.Config {
+	return &tls.Config{
+		ClientAuth: tls.RequireAnyClientCert,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.cert, nil
+		},
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			s.mu.Lock()
+			s.clientName = cert.Subject.CommonName
+			s.mu.Unlock()
+			return nil
+		},
+	}
+}
+
+// writeFiles writes files to dir, dated at modTime so that changes are detected whatever the
+// resolution of the file system.
+func writeFiles(t *testing.T, dir string, modTime time.Time, files map[string][]byte) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCertReload(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	if _, err := stogo.NewStoreClient(srv.Config()).Set("app", "prod", "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	oldCA, newCA := newTestCert(t, "old CA", nil), newTestCert(t, "new CA", nil)
+	oldClient, newClient := newTestCert(t, "old client", oldCA), newTestCert(t, "new client", newCA)
+	rotating := &rotatingServer{}
+	rotating.rotate(newTestCert(t, "old server", oldCA))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &connListener{Listener: lis}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(rotating.tlsConfig())))
+	proto.RegisterKVServiceServer(server, srv)
+	go server.Serve(cl)
+	defer server.Stop()
+
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour)
+	writeFiles(t, dir, modTime, map[string][]byte{"ca.pem": oldCA.certPEM, "cert.pem": oldClient.certPEM, "key.pem": oldClient.keyPEM})
+	clock := stogotest.NewFakeClock(time.Now())
+	client, err := stogo.Dial(config.NewStooConfig(lis.Addr().String(), 5*time.Second).
+		WithUseTls(true).
+		WithTls(&config.TLS{
+			CaCertPath:     filepath.Join(dir, "ca.pem"),
+			CertPath:       filepath.Join(dir, "cert.pem"),
+			KeyPath:        filepath.Join(dir, "key.pem"),
+			ReloadInterval: time.Minute,
+		}).
+		WithClock(clock).
+		WithDialOptions(grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.Config{BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}, MinConnectTimeout: time.Second})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// get reads the key on a new connection, retrying while it is being established.
+	get := func() error {
+		cl.closeConns()
+		var err error
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if _, err = client.Get("app", "prod", "key"); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	if name := rotating.lastClient(); name != "old client" {
+		t.Fatalf("got client certificate %q, want old client", name)
+	}
+
+	rotating.rotate(newTestCert(t, "new server", newCA))
+	modTime = modTime.Add(time.Minute)
+	writeFiles(t, dir, modTime, map[string][]byte{"ca.pem": newCA.certPEM, "cert.pem": newClient.certPEM, "key.pem": newClient.keyPEM})
+	cl.closeConns()
+	if _, err := client.Get("app", "prod", "key"); err == nil {
+		t.Fatal("got the server certificate of the new CA trusted before the reload interval")
+	}
+
+	clock.Advance(time.Minute)
+	if err := get(); err != nil {
+		t.Fatalf("got error %v after the reload interval, want the new CA trusted", err)
+	}
+	if name := rotating.lastClient(); name != "new client" {
+		t.Errorf("got client certificate %q after the reload interval, want new client", name)
+	}
+
+	modTime = modTime.Add(time.Minute)
+	writeFiles(t, dir, modTime, map[string][]byte{"ca.pem": []byte("not a certificate")})
+	clock.Advance(time.Minute)
+	if err := get(); err != nil {
+		t.Errorf("got error %v after an invalid CA file, want the previous certificates kept", err)
+	}
+}
@@ -0,0 +1,72 @@
+package stogo
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNoNamespaceChain thrown when a *Chained method is called without a namespace chain, see
+// config.StooConfig.WithNamespaceChain.
+var ErrNoNamespaceChain = errors.New("stogo: namespace chain is not configured")
+
+// GetChained gets the value of key in profile from the first namespace of the configured chain
+// holding it, see config.StooConfig.WithNamespaceChain. Every namespace is read whole and goes
+// through the cache, so looking up several keys costs one call per namespace at most. A namespace
+// failing to be read fails the lookup rather than letting a later namespace answer in its place.
+//
+// Usage example:
+//
+//	// With cfg.WithNamespaceChain("my-app", "shared")
+//	region, err := client.GetChained("prod", "region")
+//	if err != nil {
+//		log.Fatalf("Error reading region %v", err)
+//	}
+func (c *StooClient) GetChained(profile, key string, opts ...CallOption) (string, error) {
+	chain, err := c.namespaceChain()
+	if err != nil {
+		return "", err
+	}
+	for _, namespace := range chain {
+		values, err := c.GetAll(namespace, profile, opts...)
+		if err != nil {
+			return "", err
+		}
+		if value, ok := values[key]; ok {
+			return value, nil
+		}
+	}
+	return "", keyNotFound(strings.Join(chain, ","), profile, key, nil)
+}
+
+// GetAllChained gets all keys of profile from every namespace of the configured chain merged
+// together, the namespaces first in the chain taking precedence.
+//
+// Usage example:
+//
+//	all, err := client.GetAllChained("prod")
+func (c *StooClient) GetAllChained(profile string, opts ...CallOption) (map[string]string, error) {
+	chain, err := c.namespaceChain()
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[string]string)
+	for i := len(chain) - 1; i >= 0; i-- {
+		values, err := c.GetAll(chain[i], profile, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range values {
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
+
+// namespaceChain returns the configured namespace chain, failing if there is none.
+func (c *StooClient) namespaceChain() ([]string, error) {
+	chain := c.CurrentConfig().GetNamespaceChain()
+	if len(chain) == 0 {
+		return nil, ErrNoNamespaceChain
+	}
+	return chain, nil
+}
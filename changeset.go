@@ -0,0 +1,317 @@
+package stogo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/proto"
+	"io"
+	"strings"
+)
+
+// ErrInvalidChangeSet thrown when a ChangeSet holds an edit which can't be applied.
+var ErrInvalidChangeSet = errors.New("invalid change set")
+
+// EditOp operation of an Edit.
+type EditOp string
+
+const (
+	// EditSet sets Key to Value, with SetSecret if Secret is true.
+	EditSet EditOp = "set"
+	// EditDelete deletes Key.
+	EditDelete EditOp = "delete"
+	// EditRename moves the value of Key to NewKey.
+	EditRename EditOp = "rename"
+)
+
+// Edit single change of a ChangeSet.
+type Edit struct {
+	Op     EditOp `json:"op"`
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	NewKey string `json:"newKey,omitempty"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// ChangeSet edits of a namespace and profile, accumulated to be reviewed before being applied.
+// A ChangeSet serializes to JSON so it can be written by one person or process, approved by
+// another and applied later with StooClient.Apply or "stogo apply". Values of secret edits
+// are stored as given, the JSON document should be handled like the secrets it holds.
+//
+// Usage example:
+//
+//	cs := stogo.NewChangeSet("my-app", "prod").
+//		Set("database.pool-size", "20").
+//		Rename("db.url", "database.url").
+//		Delete("legacy.flag")
+//	data, _ := json.MarshalIndent(cs, "", "  ")
+//	os.WriteFile("change.json", data, 0o600)
+type ChangeSet struct {
+	Namespace   string `json:"namespace"`
+	Profile     string `json:"profile"`
+	Description string `json:"description,omitempty"`
+	Edits       []Edit `json:"edits"`
+}
+
+// NewChangeSet creates an empty ChangeSet for a namespace and profile.
+func NewChangeSet(namespace, profile string) *ChangeSet {
+	return &ChangeSet{Namespace: namespace, Profile: profile}
+}
+
+// ReadChangeSet decodes a JSON ChangeSet from r and validates it.
+func ReadChangeSet(r io.Reader) (*ChangeSet, error) {
+	var cs ChangeSet
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cs); err != nil {
+		return nil, fmt.Errorf("stogo: decode change set: %w", err)
+	}
+	if err := cs.Validate(); err != nil {
+		return nil, err
+	}
+	return &cs, nil
+}
+
+// Set adds an edit setting key to value.
+func (cs *ChangeSet) Set(key, value string) *ChangeSet {
+	cs.Edits = append(cs.Edits, Edit{Op: EditSet, Key: key, Value: value})
+	return cs
+}
+
+// SetSecret adds an edit setting key to value as a secret.
+func (cs *ChangeSet) SetSecret(key, value string) *ChangeSet {
+	cs.Edits = append(cs.Edits, Edit{Op: EditSet, Key: key, Value: value, Secret: true})
+	return cs
+}
+
+// Delete adds an edit deleting key.
+func (cs *ChangeSet) Delete(key string) *ChangeSet {
+	cs.Edits = append(cs.Edits, Edit{Op: EditDelete, Key: key})
+	return cs
+}
+
+// Rename adds an edit moving the value of key to newKey. The new key is written as a secret
+// when it matches the configured secret key patterns.
+func (cs *ChangeSet) Rename(key, newKey string) *ChangeSet {
+	cs.Edits = append(cs.Edits, Edit{Op: EditRename, Key: key, NewKey: newKey})
+	return cs
+}
+
+// Validate checks the ChangeSet targets a namespace and profile and every edit is well formed.
+func (cs *ChangeSet) Validate() error {
+	if cs.Namespace == "" || cs.Profile == "" {
+		return fmt.Errorf("%w: namespace and profile are required", ErrInvalidChangeSet)
+	}
+	for i, e := range cs.Edits {
+		if e.Key == "" {
+			return fmt.Errorf("%w: edit %d: key is required", ErrInvalidChangeSet, i)
+		}
+		switch e.Op {
+		case EditSet, EditDelete:
+		case EditRename:
+			if e.NewKey == "" || e.NewKey == e.Key {
+				return fmt.Errorf("%w: edit %d: rename of %s needs a different new key", ErrInvalidChangeSet, i, e.Key)
+			}
+		default:
+			return fmt.Errorf("%w: edit %d: unknown op %q", ErrInvalidChangeSet, i, e.Op)
+		}
+	}
+	return nil
+}
+
+// String renders the edits one per line for review, with secret values masked.
+func (cs *ChangeSet) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/%s", cs.Namespace, cs.Profile)
+	if cs.Description != "" {
+		fmt.Fprintf(&b, ": %s", cs.Description)
+	}
+	b.WriteString("\n")
+	for _, e := range cs.Edits {
+		switch e.Op {
+		case EditSet:
+			value := e.Value
+			if e.Secret {
+				value = MaskedValue
+			}
+			fmt.Fprintf(&b, "~ %s = %s\n", e.Key, value)
+		case EditDelete:
+			fmt.Fprintf(&b, "- %s\n", e.Key)
+		case EditRename:
+			fmt.Fprintf(&b, "> %s -> %s\n", e.Key, e.NewKey)
+		}
+	}
+	return b.String()
+}
+
+// Apply applies the edits of cs in order. StooKV has no transactions, so atomicity is best
+// effort: the edits are resolved against the current values first, nothing is written if one
+// of them can't be applied, and if a write fails the keys already written are restored to
+// their previous values. The returned error joins the write error with any restore failure.
+// With config.StooConfig.WithValidateWrites, the values the edits end up with are checked
+// against the schema once, before writing, rather than after every edit.
+//
+// Usage example:
+//
+//	f, _ := os.Open("change.json")
+//	cs, err := stogo.ReadChangeSet(f)
+//	if err != nil {
+//		log.Fatalf("Error reading change set %v", err)
+//	}
+//	if err := client.Apply(cs); err != nil {
+//		log.Fatalf("Error applying change set %v", err)
+//	}
+func (c *StooClient) Apply(cs *ChangeSet) error {
+	if err := cs.Validate(); err != nil {
+		return err
+	}
+	before, err := c.GetAll(cs.Namespace, cs.Profile)
+	if err != nil {
+		return err
+	}
+	mutations, err := c.resolveEdits(cs, before)
+	if err != nil {
+		return err
+	}
+	if err := c.checkFinalSchema(cs.Namespace, cs.Profile, before, mutations); err != nil {
+		return err
+	}
+	return c.applyAtomically(cs.Namespace, cs.Profile, before, mutations, withoutSchemaCheck())
+}
+
+// checkFinalSchema checks the values mutations leave before with against the schema, if
+// writes are validated. Intermediate states may break the schema, only the final one must
+// follow it, so the mutations are then written with withoutSchemaCheck.
+func (c *StooClient) checkFinalSchema(namespace, profile string, before map[string]string, mutations []mutation) error {
+	cfg := c.CurrentConfig()
+	schema, ok := cfg.GetSchema(namespace, profile)
+	if !ok || !cfg.GetValidateWrites() {
+		return nil
+	}
+	after, err := schemaValues(cfg, namespace, profile, applyMutations(before, mutations))
+	if err != nil {
+		return err
+	}
+	return validateSchema(schema, namespace, profile, "", after)
+}
+
+// mutation single write resolved from an edit.
+type mutation struct {
+	key    string
+	value  string
+	secret bool
+	delete bool
+	// raw writes value as is, bypassing client side encryption, used to restore previous values.
+	raw bool
+}
+
+// resolveEdits turns the edits of cs into mutations, replaying them on a copy of before so
+// that renames see the values set by earlier edits.
+func (c *StooClient) resolveEdits(cs *ChangeSet, before map[string]string) ([]mutation, error) {
+	state := copyValues(before)
+	// edited tells the keys whose state holds a value set by an earlier edit rather than the stored one.
+	edited := make(map[string]bool)
+	// stored tells the key each stored value was read from, which client side encryption may have bound it to.
+	stored := make(map[string]string, len(before))
+	for key := range before {
+		stored[key] = key
+	}
+	var mutations []mutation
+	for i, e := range cs.Edits {
+		switch e.Op {
+		case EditSet:
+			state[e.Key] = e.Value
+			edited[e.Key] = true
+			mutations = append(mutations, mutation{key: e.Key, value: e.Value, secret: e.Secret})
+		case EditDelete:
+			delete(state, e.Key)
+			delete(edited, e.Key)
+			mutations = append(mutations, mutation{key: e.Key, delete: true})
+		case EditRename:
+			value, ok := state[e.Key]
+			if !ok {
+				return nil, fmt.Errorf("%w: edit %d: rename %s: %w", ErrInvalidChangeSet, i, e.Key, ErrKeyNotFound)
+			}
+			renamed := mutation{key: e.NewKey, value: value, secret: c.CurrentConfig().IsSecretKey(e.NewKey), raw: !edited[e.Key]}
+			if cfg := c.CurrentConfig(); renamed.raw && cfg.GetEncryptionContext() && envelope.IsEncrypted(value) {
+				// The ciphertext is bound to the old key, re-encrypt it for the new one.
+				plaintext, err := decryptSecret(cfg, cs.Namespace, cs.Profile, stored[e.Key], value)
+				if err != nil {
+					return nil, fmt.Errorf("edit %d: rename %s: %w", i, e.Key, err)
+				}
+				renamed = mutation{key: e.NewKey, value: plaintext, secret: true}
+			}
+			state[e.NewKey] = value
+			edited[e.NewKey] = edited[e.Key]
+			stored[e.NewKey] = stored[e.Key]
+			delete(state, e.Key)
+			delete(edited, e.Key)
+			delete(stored, e.Key)
+			mutations = append(mutations, renamed, mutation{key: e.Key, delete: true})
+		}
+	}
+	return mutations, nil
+}
+
+// applyAtomically writes mutations in order and, on the first failure, restores the keys
+// already written to their value in before, deleting the ones which didn't exist. opts apply
+// to the writes, not to the restores which must go through even if the caller gave up.
+func (c *StooClient) applyAtomically(namespace, profile string, before map[string]string, mutations []mutation, opts ...CallOption) error {
+	for i, m := range mutations {
+		if err := c.mutate(namespace, profile, m, opts...); err != nil {
+			err = fmt.Errorf("stogo: apply %s/%s/%s: %w", namespace, profile, m.key, err)
+			return errors.Join(err, c.rollback(namespace, profile, before, mutations[:i]))
+		}
+	}
+	return nil
+}
+
+// rollback restores the keys touched by applied to their value in before, most recent first.
+// A value is restored as a secret if its key matches the secret key patterns or if it was
+// read encrypted, as written by SetSecret with client side encryption.
+func (c *StooClient) rollback(namespace, profile string, before map[string]string, applied []mutation) error {
+	var errs []error
+	restored := make(map[string]bool)
+	for i := len(applied) - 1; i >= 0; i-- {
+		key := applied[i].key
+		if restored[key] {
+			continue
+		}
+		restored[key] = true
+		m := mutation{key: key, delete: true}
+		if value, ok := before[key]; ok {
+			secret := c.CurrentConfig().IsSecretKey(key) || envelope.IsEncrypted(value)
+			m = mutation{key: key, value: value, secret: secret, raw: true}
+		}
+		if err := c.mutate(namespace, profile, m, withoutSchemaCheck()); err != nil {
+			errs = append(errs, fmt.Errorf("stogo: rollback %s/%s/%s: %w", namespace, profile, key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mutate performs a single mutation.
+func (c *StooClient) mutate(namespace, profile string, m mutation, opts ...CallOption) error {
+	var err error
+	switch {
+	case m.delete:
+		_, err = c.Delete(namespace, profile, m.key, opts...)
+	case m.raw && m.secret:
+		o := newCallOptions(opts)
+		defer c.invalidateCache(o.tenant, namespace, profile)
+		ctx, cancel := c.newContext(namespace, o)
+		defer cancel()
+		_, err = c.kv(namespace).SetSecretKeyService(ctx, &proto.SetKeyRequest{
+			Namespace: namespace,
+			Profile:   profile,
+			Key:       m.key,
+			Value:     m.value,
+		})
+	case m.secret:
+		_, err = c.SetSecret(namespace, profile, m.key, m.value, opts...)
+	default:
+		_, err = c.Set(namespace, profile, m.key, m.value, opts...)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"path"
+	"sync"
+	"testing"
+)
+
+// writeRecorder records the method of the last write of every key.
+type writeRecorder struct {
+	mu      sync.Mutex
+	methods map[string]string
+}
+
+func (r *writeRecorder) interceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if set, ok := req.(*proto.SetKeyRequest); ok {
+		r.mu.Lock()
+		r.methods[set.GetKey()] = path.Base(method)
+		r.mu.Unlock()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func TestApplyRollback(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &writeRecorder{methods: make(map[string]string)}
+	client := stogo.NewStoreClient(srv.Config().
+		WithEncrypter(envelope.NewChain(provider)).
+		WithSecretKeyPatterns("*.password").
+		WithMaxValueSize(16).
+		WithUnaryInterceptors(recorder.interceptor))
+	if _, err := client.SetSecret("app", "prod", "api.token", "t0ken"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SetSecret("app", "prod", "db.password", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Set("app", "prod", "db.host", "db.internal"); err != nil {
+		t.Fatal(err)
+	}
+	stored := srv.Data("app", "prod")
+
+	cs := stogo.NewChangeSet("app", "prod").
+		Set("api.token", "plain").
+		Set("db.password", "plain").
+		Set("db.host", "db2.internal").
+		Set("db.port", "5432").
+		Set("db.pool", "a value far too large")
+	if err := client.Apply(cs); !errors.Is(err, stogo.ErrValueTooLarge) {
+		t.Fatalf("got error %v, want %v", err, stogo.ErrValueTooLarge)
+	}
+
+	restored := srv.Data("app", "prod")
+	tests := []struct {
+		name       string
+		key        string
+		wantMethod string
+	}{
+		{"secret read encrypted", "api.token", "SetSecretKeyService"},
+		{"secret key pattern", "db.password", "SetSecretKeyService"},
+		{"plain value", "db.host", "SetKeyService"},
+		{"new key", "db.port", "SetKeyService"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := stored[tt.key]
+			if got, exists := restored[tt.key]; got != value || exists != ok {
+				t.Errorf("got %q (present %v), want %q (present %v)", got, exists, value, ok)
+			}
+			if method := recorder.methods[tt.key]; method != tt.wantMethod {
+				t.Errorf("last written with %s, want %s", method, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/mwangox/stogo"
+	"io"
+	"os"
+)
+
+// runApply applies a JSON change set, printing it first for the record.
+func runApply(args []string) error {
+	var conn connectionFlags
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	conn.register(fs)
+	file := fs.String("f", "-", "change set file, standard input if -")
+	dryRun := fs.Bool("dry-run", false, "print the change set without applying it")
+	fs.Parse(args)
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	cs, err := stogo.ReadChangeSet(r)
+	if err != nil {
+		return err
+	}
+	fmt.Print(cs)
+	if *dryRun {
+		return nil
+	}
+
+	client, err := conn.client()
+	if err != nil {
+		return err
+	}
+	if err := client.Apply(cs); err != nil {
+		return err
+	}
+	fmt.Printf("applied %d edits\n", len(cs.Edits))
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"github.com/mwangox/stogo"
+	"os"
+	"time"
+)
+
+// runDoctor runs the client checks against StooKV and prints the report, failing if a check failed.
+// With -no-write, only the checks of Diagnose run.
+func runDoctor(args []string) error {
+	var conn connectionFlags
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	conn.register(fs)
+	timeout := fs.Duration("deadline", 30*time.Second, "time allowed for all checks")
+	noWrite := fs.Bool("no-write", false, "skip the checks writing to StooKV, for read-only credentials")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	client, err := conn.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	var report *stogo.DoctorReport
+	if *noWrite {
+		report = client.Diagnose(ctx)
+	} else {
+		report = client.Doctor(ctx)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		_, err = report.WriteTo(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if !report.OK() {
+		return errors.New("some checks failed")
+	}
+	return nil
+}
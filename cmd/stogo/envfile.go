@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"github.com/mwangox/stogo/export"
+	"os"
+	"os/exec"
+)
+
+// runEnvFile writes a namespace and profile as a systemd EnvironmentFile and optionally
+// as systemd credentials, then reloads the given unit.
+func runEnvFile(args []string) error {
+	var conn connectionFlags
+	fs := flag.NewFlagSet("envfile", flag.ExitOnError)
+	conn.register(fs)
+	out := fs.String("out", "", "EnvironmentFile path, standard output if empty")
+	prefix := fs.String("prefix", "", "prefix prepended to every variable name")
+	credentialsDir := fs.String("credentials-dir", "", "directory to also write one systemd credential file per key into")
+	unit := fs.String("unit", "", "systemd unit to reload or restart once the files are written")
+	fs.Parse(args)
+
+	client, err := conn.client()
+	if err != nil {
+		return err
+	}
+	values, err := client.GetAllDefault()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := export.EnvironmentFile(&buf, values, *prefix); err != nil {
+		return err
+	}
+	if *out == "" {
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	} else if err := export.WriteFileAtomic(*out, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	if *credentialsDir != "" {
+		if err := export.SystemdCredentials(*credentialsDir, values); err != nil {
+			return err
+		}
+	}
+	if *unit != "" {
+		cmd := exec.Command("systemctl", "try-reload-or-restart", *unit)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	return nil
+}
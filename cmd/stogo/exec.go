@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"github.com/mwangox/stogo/export"
+	"os"
+	"os/exec"
+)
+
+// runExec runs a command with the default namespace and profile as environment variables,
+// replacing the stogo process where supported.
+func runExec(args []string) error {
+	var conn connectionFlags
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	conn.register(fs)
+	prefix := fs.String("prefix", "", "prefix prepended to every variable name")
+	pristine := fs.Bool("pristine", false, "don't pass the environment of stogo to the command")
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: stogo exec [flags] -- command [args...]\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	client, err := conn.client()
+	if err != nil {
+		return err
+	}
+	values, err := client.GetAllDefault()
+	if err != nil {
+		return err
+	}
+	var base []string
+	if !*pristine {
+		base = os.Environ()
+	}
+	err = export.Exec(fs.Args(), export.MergeEnviron(base, export.Environ(values, *prefix)))
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
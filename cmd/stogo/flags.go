@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"time"
+)
+
+// connectionFlags flags shared by commands talking to StooKV.
+type connectionFlags struct {
+	endpoint           string
+	timeout            time.Duration
+	useTls             bool
+	caCertPath         string
+	systemCertPool     bool
+	serverNameOverride string
+	skipTlsVerify      bool
+	proxy              string
+	compression        string
+	http               bool
+	namespace          string
+	profile            string
+}
+
+// register adds the connection flags to fs.
+func (f *connectionFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.endpoint, "endpoint", "localhost:50051", "StooKV gRPC endpoint")
+	fs.DurationVar(&f.timeout, "timeout", config.DefaultTimeout, "timeout of each call")
+	fs.BoolVar(&f.useTls, "tls", false, "connect using TLS")
+	fs.StringVar(&f.caCertPath, "ca-cert", "", "CA certificate used to verify StooKV")
+	fs.BoolVar(&f.systemCertPool, "system-ca", false, "also trust the system root CAs when -ca-cert is set")
+	fs.StringVar(&f.serverNameOverride, "server-name", "", "StooKV hostname used during TLS verification")
+	fs.BoolVar(&f.skipTlsVerify, "insecure-skip-verify", false, "skip TLS verification")
+	fs.StringVar(&f.proxy, "proxy", "", "HTTP or SOCKS5 proxy URL to reach StooKV through")
+	fs.StringVar(&f.compression, "compression", "", "compressor calls are compressed with, e.g. gzip")
+	fs.BoolVar(&f.http, "http", false, "reach StooKV through a gRPC-Web gateway, -endpoint being its URL")
+	fs.StringVar(&f.namespace, "namespace", "", "namespace")
+	fs.StringVar(&f.profile, "profile", "", "profile")
+}
+
+// config builds StooConfig from the flags.
+func (f *connectionFlags) config() *config.StooConfig {
+	cfg := config.NewStooConfig(f.endpoint, f.timeout).
+		WithClientName("stogo-cli").
+		WithDefaultNamespace(f.namespace).
+		WithDefaultProfile(f.profile).
+		WithUseTls(f.useTls).
+		WithProxy(f.proxy).
+		WithCompression(f.compression)
+	if f.http {
+		cfg.WithTransport(config.TransportHTTP)
+	}
+	if f.useTls {
+		cfg.WithTls(&config.TLS{
+			SkipTlsVerification: f.skipTlsVerify,
+			CaCertPath:          f.caCertPath,
+			UseSystemCertPool:   f.systemCertPool,
+			ServerNameOverride:  f.serverNameOverride,
+		})
+	}
+	return cfg
+}
+
+// client creates a client from the flags.
+func (f *connectionFlags) client() (*stogo.StooClient, error) {
+	return stogo.Dial(f.config())
+}
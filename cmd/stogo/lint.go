@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/mwangox/stogo"
+)
+
+// runLint prints the suspicious entries of a namespace and profile, failing if there are any.
+func runLint(args []string) error {
+	var conn connectionFlags
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	conn.register(fs)
+	keyPattern := fs.String("key-pattern", "", "regular expression key names must match, the stogo convention if empty")
+	fs.Parse(args)
+
+	cfg := conn.config().WithKeyNamePattern(*keyPattern)
+	client, err := stogo.Dial(cfg)
+	if err != nil {
+		return err
+	}
+	issues, err := client.Lint(cfg.GetDefaultNamespace(), cfg.GetDefaultProfile())
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issues found", len(issues))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Command stogo is a command line client for StooKV.
+//
+// Usage:
+//
+//	stogo <command> [flags]
+//
+// Commands:
+//
+//	apply     apply a JSON change set
+//	doctor    check DNS, connectivity, TLS, access and latency to StooKV
+//	envfile   write a namespace and profile as a systemd EnvironmentFile
+//	exec      run a command with a namespace and profile as environment variables
+//	lint      report suspicious entries of a namespace and profile
+//	reap      delete keys whose TTL has elapsed
+//	rollout   plan, apply or revert a reversible rollout of changes
+//	serve     serve values over a local HTTP API
+//
+// Run "stogo <command> -h" for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// commands maps command names to their implementation.
+var commands = map[string]func(args []string) error{
+	"apply":   runApply,
+	"doctor":  runDoctor,
+	"envfile": runEnvFile,
+	"exec":    runExec,
+	"lint":    runLint,
+	"reap":    runReap,
+	"rollout": runRollout,
+	"serve":   runServe,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "stogo %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the list of commands.
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: stogo <command> [flags]
+
+commands:
+  apply     apply a JSON change set
+  doctor    check DNS, connectivity, TLS, access and latency to StooKV
+  envfile   write a namespace and profile as a systemd EnvironmentFile
+  exec      run a command with a namespace and profile as environment variables
+  lint      report suspicious entries of a namespace and profile
+  reap      delete keys whose TTL has elapsed
+  rollout   plan, apply or revert a reversible rollout of changes
+  serve     serve values over a local HTTP API
+
+Run "stogo <command> -h" for the flags of a command.`)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"os"
+	"os/signal"
+)
+
+// runReap deletes expired keys of the default namespace and profile, once or periodically.
+func runReap(args []string) error {
+	var conn connectionFlags
+	fs := flag.NewFlagSet("reap", flag.ExitOnError)
+	conn.register(fs)
+	interval := fs.Duration("interval", 0, "keep running, reaping every interval, instead of reaping once")
+	tenant := fs.String("tenant", "", "reap the keys of a tenant")
+	fs.Parse(args)
+
+	client, err := stogo.Dial(conn.config().WithLogger(config.NewStdLogger(nil)))
+	if err != nil {
+		return err
+	}
+	reaper := stogo.NewReaper(client, conn.namespace, conn.profile).WithTenant(*tenant)
+	if *interval > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		reaper.WithInterval(*interval).Run(ctx)
+		return nil
+	}
+
+	result, err := reaper.ReapOnce()
+	if err != nil {
+		return err
+	}
+	for _, key := range result.Succeeded {
+		fmt.Printf("deleted %s\n", key)
+	}
+	return result.Err()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mwangox/stogo/rollout"
+	"io"
+	"os"
+)
+
+// runRollout plans, applies or reverts a rollout, see package rollout.
+//
+//	stogo rollout plan -f changes.json -o plan.json
+//	stogo rollout apply -f plan.json
+//	stogo rollout revert -f plan.json
+func runRollout(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: stogo rollout plan|apply|revert [flags]")
+	}
+	action := args[0]
+	var conn connectionFlags
+	fs := flag.NewFlagSet("rollout "+action, flag.ExitOnError)
+	conn.register(fs)
+	file := fs.String("f", "-", "changes file for plan, plan file otherwise, standard input if -")
+	out := fs.String("o", "-", "file the plan is written to, standard output if -")
+	dryRun := fs.Bool("dry-run", false, "print the plan without applying or reverting it")
+	fs.Parse(args[1:])
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	client, err := conn.client()
+	if err != nil {
+		return err
+	}
+	ro := rollout.New(client)
+	ctx := context.Background()
+
+	switch action {
+	case "plan":
+		var changes []rollout.Change
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&changes); err != nil {
+			return fmt.Errorf("decode changes: %w", err)
+		}
+		plan, err := ro.Plan(ctx, changes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stderr, plan)
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		if *out == "-" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(*out, data, 0o600)
+	case "apply", "revert":
+		plan, err := rollout.ReadPlan(r)
+		if err != nil {
+			return err
+		}
+		fmt.Print(plan)
+		if *dryRun {
+			return nil
+		}
+		done := "applied"
+		if action == "apply" {
+			err = ro.Apply(ctx, plan)
+		} else {
+			done = "reverted"
+			err = ro.Revert(ctx, plan)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %d steps\n", done, len(plan.Steps))
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q, expected plan, apply or revert", action)
+	}
+}
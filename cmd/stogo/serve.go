@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/httpapi"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// runServe serves a local HTTP API reading values through one shared client until interrupted.
+func runServe(args []string) error {
+	var conn connectionFlags
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	conn.register(fs)
+	listen := fs.String("listen", "127.0.0.1:8400", "address to listen on, unix:/path for a Unix socket")
+	cacheTTL := fs.Duration("cache-ttl", httpapi.DefaultCacheTTL, "time values are served from the cache, 0 to disable it")
+	pollInterval := fs.Duration("poll-interval", config.DefaultPollInterval, "interval watched profiles are polled at")
+	fs.Parse(args)
+
+	client, err := stogo.Dial(conn.config().WithLogger(config.NewStdLogger(nil)))
+	if err != nil {
+		return err
+	}
+	var listener net.Listener
+	if path, ok := strings.CutPrefix(*listen, "unix:"); ok {
+		listener, err = net.Listen("unix", path)
+	} else {
+		listener, err = net.Listen("tcp", *listen)
+	}
+	if err != nil {
+		return err
+	}
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() {
+		log.Printf("warning: %s is not a loopback address, values including secrets are readable from the network", addr)
+	}
+
+	server := &http.Server{
+		Handler:           httpapi.New(client).WithCacheTTL(*cacheTTL).WithPollInterval(*pollInterval),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+	log.Printf("serving on %s", listener.Addr())
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
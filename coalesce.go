@@ -0,0 +1,44 @@
+package stogo
+
+import "sync"
+
+// flight GetAll call in progress, shared by concurrent callers.
+type flight struct {
+	done   chan struct{}
+	values map[string]string
+	err    error
+}
+
+// flights calls in progress by profile and tenant. The zero value is ready to use.
+type flights struct {
+	mu    sync.Mutex
+	calls map[profileKey]*flight
+}
+
+// do calls fn unless a call for the same profile is already in progress, in which case its
+// result is shared instead. Every caller gets its own copy of the values.
+func (f *flights) do(p profileKey, fn func() (map[string]string, error)) (map[string]string, error) {
+	f.mu.Lock()
+	if call, ok := f.calls[p]; ok {
+		f.mu.Unlock()
+		<-call.done
+		if call.err != nil {
+			return nil, call.err
+		}
+		return copyValues(call.values), nil
+	}
+	if f.calls == nil {
+		f.calls = make(map[profileKey]*flight)
+	}
+	call := &flight{done: make(chan struct{})}
+	f.calls[p] = call
+	f.mu.Unlock()
+
+	values, err := fn()
+	call.values, call.err = copyValues(values), err
+	close(call.done)
+	f.mu.Lock()
+	delete(f.calls, p)
+	f.mu.Unlock()
+	return values, err
+}
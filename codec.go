@@ -0,0 +1,59 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/codec"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	gproto "google.golang.org/protobuf/proto"
+	"path"
+)
+
+// codecInterceptor encodes written values with the codec configured for their key or namespace,
+// unless already encoded with WithCodec, and decodes every encoded value read, see
+// config.StooConfig.WithNamespaceCodec and config.StooConfig.WithKeyCodec. Secrets are written
+// as they are, the codec being meant for plain values: encrypted values don't compress and
+// StooKV handles secrets on its side. A value of a profile which can't be decoded, e.g. written
+// by a codec which is no longer configured, is logged and returned as stored rather than failing
+// the reads of every other key.
+func codecInterceptor(c *StooClient) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		cfg := c.CurrentConfig()
+		if set, ok := req.(*proto.SetKeyRequest); ok && path.Base(method) != "SetSecretKeyService" {
+			if vc := cfg.GetKeyCodec(set.GetNamespace(), set.GetKey()); vc != nil && !codec.IsEncoded(set.GetValue()) {
+				encoded, err := codec.Encode(vc, set.GetValue())
+				if err != nil {
+					return err
+				}
+				set = gproto.Clone(set).(*proto.SetKeyRequest)
+				set.Value = encoded
+				req = set
+			}
+		}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		switch res := reply.(type) {
+		case *proto.GetResponse:
+			decoded, err := codec.Decode(res.GetData(), cfg.GetCodecs()...)
+			if err != nil {
+				return err
+			}
+			res.Data = decoded
+		case *proto.GetByNamespaceAndProfileResponse:
+			for key, value := range res.GetData() {
+				if !codec.IsEncoded(value) {
+					continue
+				}
+				decoded, err := codec.Decode(value, cfg.GetCodecs()...)
+				if err != nil {
+					r, _ := req.(*proto.GetByNamespaceAndProfileRequest)
+					cfg.GetLogger().Warnf("decoding %s/%s/%s, returning it as stored: %v", r.GetNamespace(), r.GetProfile(), key, err)
+					continue
+				}
+				res.Data[key] = decoded
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,231 @@
+// Package codec encodes values before they are stored in StooKV, e.g. to compress large ones.
+// Encoded values record the name of their codec, so readers decode them without knowing how
+// they were written: a value encoded by codec "gzip" is stored as "stogo:codec:gzip:" followed
+// by the base64 encoded output of the codec.
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Prefix starts every value produced by Encode.
+const Prefix = "stogo:codec:"
+
+// chainSeparator separates the names of chained codecs.
+const chainSeparator = "+"
+
+// ErrUnknownCodec thrown when decoding a value written by a codec which is not known.
+var ErrUnknownCodec = errors.New("codec: unknown codec")
+
+// ErrMalformed thrown when an encoded value can't be parsed.
+var ErrMalformed = errors.New("codec: malformed value")
+
+// Codec transforms values on their way to and from StooKV. Implementations must be safe for
+// concurrent use.
+type Codec interface {
+	// Name identifies the codec in encoded values, it must not contain ':' or '+'.
+	Name() string
+	// Encode transforms data before it is stored.
+	Encode(data []byte) ([]byte, error)
+	// Decode reverts Encode.
+	Decode(data []byte) ([]byte, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{}
+)
+
+func init() {
+	Register(Gzip)
+	Register(Binary)
+}
+
+// Register makes c known to Decode under its name, replacing any codec of the same name.
+func Register(c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.Name()] = c
+}
+
+// Lookup returns the registered codec named name, resolving chains such as "gzip+custom".
+func Lookup(name string) (Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return lookup(name, nil)
+}
+
+// lookup resolves name among extra then the registry, which must be locked for reading.
+func lookup(name string, extra []Codec) (Codec, bool) {
+	names := strings.Split(name, chainSeparator)
+	codecs := make([]Codec, 0, len(names))
+	for _, n := range names {
+		c := find(n, extra)
+		if c == nil {
+			return nil, false
+		}
+		codecs = append(codecs, c)
+	}
+	if len(codecs) == 1 {
+		return codecs[0], true
+	}
+	return Chain(codecs...), true
+}
+
+// find returns the codec named name among extra then the registry, nil if none.
+func find(name string, extra []Codec) Codec {
+	for _, c := range extra {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return registry[name]
+}
+
+// IsEncoded tells if value was produced by Encode.
+func IsEncoded(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encode encodes value with c and records the name of c.
+//
+// Usage example:
+//
+//	stored, err := codec.Encode(codec.Gzip, largeValue)
+func Encode(c Codec, value string) (string, error) {
+	data, err := c.Encode([]byte(value))
+	if err != nil {
+		return "", fmt.Errorf("codec %s: %w", c.Name(), err)
+	}
+	return Prefix + c.Name() + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decode decodes a value produced by Encode with the codec it records, looked up among extra
+// then the registered codecs. Values not produced by Encode are returned as they are.
+func Decode(value string, extra ...Codec) (string, error) {
+	if !IsEncoded(value) {
+		return value, nil
+	}
+	name, payload, ok := strings.Cut(strings.TrimPrefix(value, Prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	registryMu.RLock()
+	c, ok := lookup(name, extra)
+	registryMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCodec, name)
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	decoded, err := c.Decode(data)
+	if err != nil {
+		return "", fmt.Errorf("codec %s: %w", name, err)
+	}
+	return string(decoded), nil
+}
+
+// Chain returns a codec applying codecs in order when encoding and in reverse order when
+// decoding, e.g. a serialization followed by a compression. Its name joins theirs with '+'.
+func Chain(codecs ...Codec) Codec {
+	return chain(codecs)
+}
+
+// chain codec returned by Chain.
+type chain []Codec
+
+// Name joins the names of the chained codecs.
+func (ch chain) Name() string {
+	names := make([]string, len(ch))
+	for i, c := range ch {
+		names[i] = c.Name()
+	}
+	return strings.Join(names, chainSeparator)
+}
+
+// Encode applies the codecs in order.
+func (ch chain) Encode(data []byte) ([]byte, error) {
+	var err error
+	for _, c := range ch {
+		if data, err = c.Encode(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Decode applies the codecs in reverse order.
+func (ch chain) Decode(data []byte) ([]byte, error) {
+	var err error
+	for i := len(ch) - 1; i >= 0; i-- {
+		if data, err = ch[i].Decode(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Binary codec leaving data as is, registered by default. Since encoded values are base64
+// encoded, it is enough to store binary data safely.
+var Binary Codec = binaryCodec{}
+
+// binaryCodec type of Binary.
+type binaryCodec struct{}
+
+// Name returns "binary".
+func (binaryCodec) Name() string {
+	return "binary"
+}
+
+// Encode returns data.
+func (binaryCodec) Encode(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// Decode returns data.
+func (binaryCodec) Decode(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// Gzip codec compressing values with gzip, registered by default.
+var Gzip Codec = gzipCodec{}
+
+// gzipCodec type of Gzip.
+type gzipCodec struct{}
+
+// Name returns "gzip".
+func (gzipCodec) Name() string {
+	return "gzip"
+}
+
+// Encode compresses data.
+func (gzipCodec) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decompresses data.
+func (gzipCodec) Decode(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,77 @@
+package stogo_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/codec"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/stogotest"
+	"strings"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := stogo.NewStoreClient(srv.Config().
+		WithNamespaceCodec("app", codec.Gzip).
+		WithEncrypter(envelope.NewChain(provider)))
+	raw := stogo.NewStoreClient(srv.Config())
+
+	large := strings.Repeat("large value ", 100)
+	stale := codec.Prefix + "retired:AAAA"
+	writes := []struct {
+		name  string
+		write func() error
+	}{
+		{"namespace codec", func() error {
+			_, err := client.Set("app", "prod", "blob", large)
+			return err
+		}},
+		{"secret skips the codec", func() error {
+			_, err := client.SetSecret("app", "prod", "db.password", "s3cr3t")
+			return err
+		}},
+		{"value of a retired codec", func() error {
+			_, err := raw.Set("app", "prod", "stale", stale)
+			return err
+		}},
+	}
+	for _, tt := range writes {
+		if err := tt.write(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+	}
+	stored := srv.Data("app", "prod")
+	if !codec.IsEncoded(stored["blob"]) {
+		t.Errorf("blob stored as %q, want it encoded", stored["blob"])
+	}
+	if password := stored["db.password"]; codec.IsEncoded(password) || !envelope.IsEncrypted(password) {
+		t.Errorf("secret stored as %q, want it encrypted only", password)
+	}
+
+	values, err := client.GetAll("app", "prod")
+	if err != nil {
+		t.Fatalf("GetAll failed because of an undecodable value: %v", err)
+	}
+	if values["blob"] != large {
+		t.Errorf("got blob %q, want it decoded", values["blob"])
+	}
+	if values["stale"] != stale {
+		t.Errorf("got stale %q, want it as stored", values["stale"])
+	}
+	if _, err := client.Get("app", "prod", "stale"); !errors.Is(err, codec.ErrUnknownCodec) {
+		t.Errorf("got error %v, want %v", err, codec.ErrUnknownCodec)
+	}
+	password, err := client.GetSecret("app", "prod", "db.password")
+	if err != nil || password.Reveal() != "s3cr3t" {
+		t.Errorf("got secret %q, %v, want %q", password.Reveal(), err, "s3cr3t")
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"context"
+	"time"
+)
+
+// AuditOp kind of write reported to an AuditHook.
+type AuditOp string
+
+const (
+	// AuditSet a value set with Set.
+	AuditSet AuditOp = "set"
+	// AuditSetSecret a value set with SetSecret.
+	AuditSetSecret AuditOp = "set_secret"
+	// AuditDelete a key deleted.
+	AuditDelete AuditOp = "delete"
+)
+
+// AuditEvent write made through the client, see StooConfig.WithAuditHook.
+type AuditEvent struct {
+	// Time time the write completed at, from the configured clock.
+	Time time.Time
+	// Op kind of write.
+	Op AuditOp
+	// Namespace namespace written to.
+	Namespace string
+	// Profile profile written to.
+	Profile string
+	// Key key written.
+	Key string
+	// Value value written, masked for secrets and empty for deletes.
+	Value string
+	// Secret tells the value is a secret, set with SetSecret or matching a secret key pattern.
+	Secret bool
+	// Identity caller identity, given with stogo.WithIdentity or StooConfig.WithAuditIdentity.
+	Identity string
+	// DryRun tells the write was only logged, see StooConfig.WithDryRun.
+	DryRun bool
+	// Err error the write failed with, nil on success.
+	Err error
+}
+
+// AuditHook receives every write made through the client, including the ones made by helpers
+// such as change sets and through Raw, once its outcome is known. ctx is the context of the call.
+// Hooks run synchronously on the calling goroutine and must be safe for concurrent use, so
+// forwarding to a remote system should go through a buffer.
+type AuditHook func(ctx context.Context, event AuditEvent)
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// Clock abstracts time for time dependent features such as polling, caching and expiry, so
+// they can be driven deterministically in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel receiving the current time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock Clock backed by the time package.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After(d).
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
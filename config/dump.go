@@ -0,0 +1,264 @@
+package config
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MaskedValue replaces secrets, such as tokens and private keys, in values meant to be displayed.
+const MaskedValue = "******"
+
+// sensitiveHeaders headers whose values are masked in a Dump on top of the ones matching the
+// secret key patterns.
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"x-api-key":           true,
+}
+
+// Dump effective settings of a StooConfig, with defaults applied and secrets masked, meant to
+// be exposed by diagnostics endpoints. Durations are formatted like time.Duration.String and
+// settings made of code, such as the logger or the encrypter, are only reported as set.
+type Dump struct {
+	Endpoints            []string                   `json:"endpoints" yaml:"endpoints"`
+	Transport            string                     `json:"transport" yaml:"transport"`
+	UseTls               bool                       `json:"useTls" yaml:"useTls"`
+	TLS                  *TLSDump                   `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Proxy                string                     `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	CustomDialer         bool                       `json:"customDialer,omitempty" yaml:"customDialer,omitempty"`
+	ReadTimeout          string                     `json:"readTimeout" yaml:"readTimeout"`
+	DefaultNamespace     string                     `json:"defaultNamespace,omitempty" yaml:"defaultNamespace,omitempty"`
+	DefaultProfile       string                     `json:"defaultProfile,omitempty" yaml:"defaultProfile,omitempty"`
+	NamespaceChain       []string                   `json:"namespaceChain,omitempty" yaml:"namespaceChain,omitempty"`
+	NamespaceCredentials map[string]CredentialsDump `json:"namespaceCredentials,omitempty" yaml:"namespaceCredentials,omitempty"`
+	Headers              map[string]string          `json:"headers,omitempty" yaml:"headers,omitempty"`
+	ClientName           string                     `json:"clientName,omitempty" yaml:"clientName,omitempty"`
+	TenantHeader         string                     `json:"tenantHeader" yaml:"tenantHeader"`
+	MaxReceiveSize       int                        `json:"maxReceiveSize" yaml:"maxReceiveSize"`
+	MaxValueSize         int                        `json:"maxValueSize,omitempty" yaml:"maxValueSize,omitempty"`
+	Compression          string                     `json:"compression,omitempty" yaml:"compression,omitempty"`
+	Codecs               []string                   `json:"codecs,omitempty" yaml:"codecs,omitempty"`
+	KeyCodecs            []string                   `json:"keyCodecs,omitempty" yaml:"keyCodecs,omitempty"`
+	PollInterval         string                     `json:"pollInterval" yaml:"pollInterval"`
+	CacheTTL             string                     `json:"cacheTTL" yaml:"cacheTTL"`
+	Prefetch             []string                   `json:"prefetch,omitempty" yaml:"prefetch,omitempty"`
+	FallbackFile         string                     `json:"fallbackFile,omitempty" yaml:"fallbackFile,omitempty"`
+	FallbackEncryption   bool                       `json:"fallbackEncryption,omitempty" yaml:"fallbackEncryption,omitempty"`
+	Encryption           bool                       `json:"encryption" yaml:"encryption"`
+	EncryptionContext    bool                       `json:"encryptionContext,omitempty" yaml:"encryptionContext,omitempty"`
+	SecretKeyPatterns    []string                   `json:"secretKeyPatterns" yaml:"secretKeyPatterns"`
+	KeyNamePattern       string                     `json:"keyNamePattern" yaml:"keyNamePattern"`
+	Schemas              []string                   `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	ValidateWrites       bool                       `json:"validateWrites,omitempty" yaml:"validateWrites,omitempty"`
+	RateLimit            float64                    `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+	RateBurst            int                        `json:"rateBurst,omitempty" yaml:"rateBurst,omitempty"`
+	MaxInFlight          int                        `json:"maxInFlight" yaml:"maxInFlight"`
+	AsyncConcurrency     int                        `json:"asyncConcurrency" yaml:"asyncConcurrency"`
+	HedgingDelay         string                     `json:"hedgingDelay,omitempty" yaml:"hedgingDelay,omitempty"`
+	HedgingAttempts      int                        `json:"hedgingAttempts,omitempty" yaml:"hedgingAttempts,omitempty"`
+	HappyEyeballsDelay   string                     `json:"happyEyeballsDelay,omitempty" yaml:"happyEyeballsDelay,omitempty"`
+	CircuitBreaker       *BreakerDump               `json:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty"`
+	ShadowEndpoint       string                     `json:"shadowEndpoint,omitempty" yaml:"shadowEndpoint,omitempty"`
+	ReadOnly             bool                       `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	DryRun               bool                       `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
+	AuditHook            bool                       `json:"auditHook,omitempty" yaml:"auditHook,omitempty"`
+	AuditIdentity        string                     `json:"auditIdentity,omitempty" yaml:"auditIdentity,omitempty"`
+	MutationLog          bool                       `json:"mutationLog,omitempty" yaml:"mutationLog,omitempty"`
+	Metrics              bool                       `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Tracing              bool                       `json:"tracing,omitempty" yaml:"tracing,omitempty"`
+	ExemplarThreshold    string                     `json:"exemplarThreshold,omitempty" yaml:"exemplarThreshold,omitempty"`
+	Logger               bool                       `json:"logger,omitempty" yaml:"logger,omitempty"`
+	LogLevel             string                     `json:"logLevel" yaml:"logLevel"`
+	Clock                bool                       `json:"clock,omitempty" yaml:"clock,omitempty"`
+	UnaryInterceptors    int                        `json:"unaryInterceptors,omitempty" yaml:"unaryInterceptors,omitempty"`
+	DialOptions          int                        `json:"dialOptions,omitempty" yaml:"dialOptions,omitempty"`
+}
+
+// TLSDump TLS settings of a Dump. File paths are kept, PEM encoded material is only reported as
+// set, the private key being masked.
+type TLSDump struct {
+	SkipTlsVerification bool   `json:"skipTlsVerification,omitempty" yaml:"skipTlsVerification,omitempty"`
+	CaCertPath          string `json:"caCertPath,omitempty" yaml:"caCertPath,omitempty"`
+	ServerNameOverride  string `json:"serverNameOverride,omitempty" yaml:"serverNameOverride,omitempty"`
+	CertPath            string `json:"certPath,omitempty" yaml:"certPath,omitempty"`
+	KeyPath             string `json:"keyPath,omitempty" yaml:"keyPath,omitempty"`
+	UseSystemCertPool   bool   `json:"useSystemCertPool,omitempty" yaml:"useSystemCertPool,omitempty"`
+	CaCertPEM           bool   `json:"caCertPem,omitempty" yaml:"caCertPem,omitempty"`
+	CertPEM             bool   `json:"certPem,omitempty" yaml:"certPem,omitempty"`
+	KeyPEM              string `json:"keyPem,omitempty" yaml:"keyPem,omitempty"`
+	ReloadInterval      string `json:"reloadInterval,omitempty" yaml:"reloadInterval,omitempty"`
+	CustomConfig        bool   `json:"customConfig,omitempty" yaml:"customConfig,omitempty"`
+}
+
+// CredentialsDump credentials of a namespace in a Dump, with the token masked.
+type CredentialsDump struct {
+	Token string   `json:"token,omitempty" yaml:"token,omitempty"`
+	TLS   *TLSDump `json:"tls,omitempty" yaml:"tls,omitempty"`
+}
+
+// BreakerDump circuit breaker settings of a Dump.
+type BreakerDump struct {
+	ErrorRate      float64 `json:"errorRate" yaml:"errorRate"`
+	MinRequests    int     `json:"minRequests" yaml:"minRequests"`
+	Window         string  `json:"window" yaml:"window"`
+	OpenDuration   string  `json:"openDuration" yaml:"openDuration"`
+	HalfOpenProbes int     `json:"halfOpenProbes" yaml:"halfOpenProbes"`
+}
+
+// Dump returns the effective settings of s with secrets masked: namespace tokens, the private
+// key in TLS.KeyPEM, the password of the proxy URL and the values of the authorization, cookie
+// and API key headers as well as of the headers matching the secret key patterns.
+//
+// Usage example:
+//
+//	http.HandleFunc("/debug/stogo", func(w http.ResponseWriter, r *http.Request) {
+//		json.NewEncoder(w).Encode(client.CurrentConfig().Dump())
+//	})
+func (s *StooConfig) Dump() *Dump {
+	hedgingDelay, hedgingAttempts := s.GetHedging()
+	rateLimit, rateBurst := s.GetRateLimit()
+	d := &Dump{
+		Endpoints:          s.GetEndpoints(),
+		Transport:          s.GetTransport().String(),
+		UseTls:             s.GetUseTls(),
+		TLS:                dumpTLS(s.GetTls()),
+		CustomDialer:       s.GetContextDialer() != nil,
+		ReadTimeout:        s.GetReadTimeout().String(),
+		DefaultNamespace:   s.GetDefaultNamespace(),
+		DefaultProfile:     s.GetDefaultProfile(),
+		NamespaceChain:     s.GetNamespaceChain(),
+		ClientName:         s.GetClientName(),
+		TenantHeader:       s.GetTenantHeader(),
+		MaxReceiveSize:     s.GetMaxReceiveSize(),
+		MaxValueSize:       s.GetMaxValueSize(),
+		Compression:        s.GetCompression(),
+		PollInterval:       s.GetPollInterval().String(),
+		CacheTTL:           s.GetCacheTTL().String(),
+		FallbackFile:       s.GetFallbackFile(),
+		FallbackEncryption: s.GetFallbackEncrypter() != nil,
+		Encryption:         s.GetEncrypter() != nil,
+		EncryptionContext:  s.GetEncryptionContext(),
+		SecretKeyPatterns:  s.GetSecretKeyPatterns(),
+		KeyNamePattern:     s.GetKeyNamePattern(),
+		ValidateWrites:     s.GetValidateWrites(),
+		RateLimit:          rateLimit,
+		RateBurst:          rateBurst,
+		MaxInFlight:        s.GetMaxInFlight(),
+		AsyncConcurrency:   s.GetAsyncConcurrency(),
+		HedgingAttempts:    hedgingAttempts,
+		ShadowEndpoint:     s.GetShadowEndpoint(),
+		ReadOnly:           s.GetReadOnly(),
+		DryRun:             s.GetDryRun(),
+		AuditHook:          s.GetAuditHook() != nil,
+		AuditIdentity:      s.GetAuditIdentity(),
+		MutationLog:        s.GetMutationLog() != nil,
+		Metrics:            s.GetMetrics() != nil,
+		Tracing:            s.GetTracing() != nil,
+		Logger:             s.logger != nil,
+		LogLevel:           s.GetLogLevel().String(),
+		Clock:              s.clock != nil,
+		UnaryInterceptors:  len(s.GetUnaryInterceptors()),
+		DialOptions:        len(s.GetDialOptions()),
+	}
+	if proxy := s.GetProxy(); proxy != "" {
+		d.Proxy = MaskedValue
+		if u, err := url.Parse(proxy); err == nil {
+			d.Proxy = u.Redacted()
+		}
+	}
+	if creds := s.GetAllNamespaceCredentials(); len(creds) > 0 {
+		d.NamespaceCredentials = make(map[string]CredentialsDump, len(creds))
+		for namespace, c := range creds {
+			cd := CredentialsDump{TLS: dumpTLS(c.TLS)}
+			if c.Token != "" {
+				cd.Token = MaskedValue
+			}
+			d.NamespaceCredentials[namespace] = cd
+		}
+	}
+	if headers := s.GetHeaders(); len(headers) > 0 {
+		d.Headers = make(map[string]string, len(headers))
+		for name, value := range headers {
+			if sensitiveHeaders[strings.ToLower(name)] || s.IsSecretKey(name) {
+				value = MaskedValue
+			}
+			d.Headers[name] = value
+		}
+	}
+	for _, nc := range s.codecs {
+		d.Codecs = append(d.Codecs, nc.Pattern+"="+nc.Codec.Name())
+	}
+	for _, kc := range s.keyCodecs {
+		d.KeyCodecs = append(d.KeyCodecs, kc.Prefix+"="+kc.Codec.Name())
+	}
+	for _, p := range s.GetPrefetch() {
+		d.Prefetch = append(d.Prefetch, p.Namespace+"/"+p.Profile)
+	}
+	for p := range s.schemas {
+		name := p.Namespace + "/" + p.Profile
+		if p.Profile == "" {
+			name = p.Namespace + "/*"
+		}
+		d.Schemas = append(d.Schemas, name)
+	}
+	sort.Strings(d.Schemas)
+	if hedgingAttempts > 1 {
+		d.HedgingDelay = hedgingDelay.String()
+	}
+	if delay := s.GetHappyEyeballsDelay(); delay > 0 {
+		d.HappyEyeballsDelay = delay.String()
+	}
+	if s.GetMetrics() != nil {
+		d.ExemplarThreshold = s.GetExemplarThreshold().String()
+	}
+	if b := s.GetCircuitBreaker(); b != nil {
+		d.CircuitBreaker = &BreakerDump{
+			ErrorRate:      b.ErrorRate,
+			MinRequests:    b.MinRequests,
+			Window:         b.Window.String(),
+			OpenDuration:   b.OpenDuration.String(),
+			HalfOpenProbes: b.HalfOpenProbes,
+		}
+	}
+	return d
+}
+
+// MarshalJSON marshals the Dump of s, so that the configuration can be logged or served with
+// its secrets masked.
+func (s *StooConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Dump())
+}
+
+// MarshalYAML returns the Dump of s, implementing the Marshaler interface of gopkg.in/yaml.v2
+// and gopkg.in/yaml.v3.
+func (s *StooConfig) MarshalYAML() (any, error) {
+	return s.Dump(), nil
+}
+
+// dumpTLS returns the TLSDump of tls, nil if not set.
+func dumpTLS(tls *TLS) *TLSDump {
+	if tls == nil {
+		return nil
+	}
+	d := &TLSDump{
+		SkipTlsVerification: tls.SkipTlsVerification,
+		CaCertPath:          tls.CaCertPath,
+		ServerNameOverride:  tls.ServerNameOverride,
+		CertPath:            tls.CertPath,
+		KeyPath:             tls.KeyPath,
+		UseSystemCertPool:   tls.UseSystemCertPool,
+		CaCertPEM:           len(tls.CaCertPEM) > 0,
+		CertPEM:             len(tls.CertPEM) > 0,
+		CustomConfig:        tls.Config != nil,
+	}
+	if len(tls.KeyPEM) > 0 {
+		d.KeyPEM = MaskedValue
+	}
+	if tls.ReloadInterval > 0 {
+		d.ReloadInterval = tls.ReloadInterval.String()
+	}
+	return d
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel minimum severity of messages logged by the client.
+type LogLevel int
+
+const (
+	// LevelDebug logs everything.
+	LevelDebug LogLevel = iota
+	// LevelInfo logs informational messages and above.
+	LevelInfo
+	// LevelWarn logs warnings and errors.
+	LevelWarn
+	// LevelError logs errors only.
+	LevelError
+	// LevelOff logs nothing.
+	LevelOff
+)
+
+// ParseLogLevel parses debug, info, warn, error or off, case-insensitively.
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "off":
+		return LevelOff, nil
+	}
+	return LevelOff, fmt.Errorf("unknown log level %q", level)
+}
+
+// String returns debug, info, warn, error or off, as understood by ParseLogLevel.
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "off"
+}
+
+// Logger receives the messages logged by the client. Implementations must be safe for concurrent use.
+type Logger interface {
+	Debugf(format string, args ...any)
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// NopLogger Logger discarding every message, used if no logger is set.
+type NopLogger struct{}
+
+// Debugf discards the message.
+func (NopLogger) Debugf(string, ...any) {}
+
+// Infof discards the message.
+func (NopLogger) Infof(string, ...any) {}
+
+// Warnf discards the message.
+func (NopLogger) Warnf(string, ...any) {}
+
+// Errorf discards the message.
+func (NopLogger) Errorf(string, ...any) {}
+
+// StdLogger Logger writing to a standard library *log.Logger with the level as a prefix.
+type StdLogger struct {
+	logger *log.Logger
+}
+
+// NewStdLogger creates a StdLogger writing to l, or to the standard logger if l is nil.
+func NewStdLogger(l *log.Logger) *StdLogger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &StdLogger{logger: l}
+}
+
+// Debugf logs a debug message.
+func (s *StdLogger) Debugf(format string, args ...any) {
+	s.logger.Printf("DEBUG stogo: "+format, args...)
+}
+
+// Infof logs an informational message.
+func (s *StdLogger) Infof(format string, args ...any) {
+	s.logger.Printf("INFO stogo: "+format, args...)
+}
+
+// Warnf logs a warning.
+func (s *StdLogger) Warnf(format string, args ...any) {
+	s.logger.Printf("WARN stogo: "+format, args...)
+}
+
+// Errorf logs an error.
+func (s *StdLogger) Errorf(format string, args ...any) {
+	s.logger.Printf("ERROR stogo: "+format, args...)
+}
+
+// leveledLogger drops messages of logger below level.
+type leveledLogger struct {
+	logger Logger
+	level  LogLevel
+}
+
+// Debugf logs a debug message if enabled.
+func (l leveledLogger) Debugf(format string, args ...any) {
+	if l.level <= LevelDebug {
+		l.logger.Debugf(format, args...)
+	}
+}
+
+// Infof logs an informational message if enabled.
+func (l leveledLogger) Infof(format string, args ...any) {
+	if l.level <= LevelInfo {
+		l.logger.Infof(format, args...)
+	}
+}
+
+// Warnf logs a warning if enabled.
+func (l leveledLogger) Warnf(format string, args ...any) {
+	if l.level <= LevelWarn {
+		l.logger.Warnf(format, args...)
+	}
+}
+
+// Errorf logs an error if enabled.
+func (l leveledLogger) Errorf(format string, args ...any) {
+	if l.level <= LevelError {
+		l.logger.Errorf(format, args...)
+	}
+}
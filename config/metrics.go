@@ -0,0 +1,44 @@
+package config
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultExemplarThreshold latency from which calls are considered slow and carry an exemplar.
+const DefaultExemplarThreshold = 100 * time.Millisecond
+
+// CallMetric outcome of a call to StooKV.
+type CallMetric struct {
+	// Method short name of the RPC, such as GetService.
+	Method string
+	// Namespace of the call.
+	Namespace string
+	// Duration latency of the call, including the time spent waiting for client-side limits.
+	Duration time.Duration
+	// Err error the call failed with, nil on success.
+	Err error
+	// TraceID exemplar of slow calls, the trace ID of the call if tracing is set up and the call
+	// took at least the exemplar threshold, empty otherwise. It is meant to be attached to the
+	// latency histogram bucket of the call so that latency spikes link to a representative trace.
+	TraceID string
+}
+
+// Metrics receives the outcome of every call to StooKV, typically to record a latency histogram.
+// Implementations must be safe for concurrent use and should not block.
+type Metrics interface {
+	ObserveCall(call CallMetric)
+}
+
+// TraceIDFunc returns the ID of the trace ctx belongs to, empty if none, to link metrics to
+// traces without depending on a tracing library.
+//
+// Usage example, with OpenTelemetry:
+//
+//	func(ctx context.Context) string {
+//		if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+//			return sc.TraceID().String()
+//		}
+//		return ""
+//	}
+type TraceIDFunc func(ctx context.Context) string
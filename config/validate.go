@@ -0,0 +1,339 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/encoding"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError lists every problem found in a configuration by Validate.
+type ValidationError struct {
+	// Problems one error per misconfiguration, e.g. ErrEndpointRequired.
+	Problems []error
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.Error()
+	}
+	return "invalid configuration: " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the problems, so that errors.Is and errors.As match any of them.
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// Validate checks the configuration, returning a *ValidationError listing every problem found
+// or nil if there is none. Files referenced by TLS settings must exist. Validate is called by
+// stogo.Dial.
+//
+// Usage example:
+//
+//	if err := cfg.Validate(); err != nil {
+//		var verr *config.ValidationError
+//		errors.As(err, &verr)
+//		for _, problem := range verr.Problems {
+//			log.Printf("config: %v", problem)
+//		}
+//	}
+func (s *StooConfig) Validate() error {
+	var problems []error
+	if s.endpoint == "" {
+		problems = append(problems, ErrEndpointRequired)
+	}
+	for _, endpoint := range s.GetEndpoints() {
+		if endpoint == "" {
+			continue
+		}
+		if s.transport == TransportHTTP {
+			if err := validateHTTPEndpoint(endpoint, s.useTls); err != nil {
+				problems = append(problems, fmt.Errorf("endpoint %q: %w", endpoint, err))
+			}
+			continue
+		}
+		if err := validateEndpoint(endpoint); err != nil {
+			problems = append(problems, fmt.Errorf("endpoint %q: %w", endpoint, err))
+		}
+	}
+	if s.transport != TransportGRPC && s.transport != TransportHTTP {
+		problems = append(problems, fmt.Errorf("unknown transport %d", s.transport))
+	}
+	if s.shadowEndpoint != "" {
+		validate := validateEndpoint
+		if s.transport == TransportHTTP {
+			validate = func(endpoint string) error { return validateHTTPEndpoint(endpoint, s.useTls) }
+		}
+		if err := validate(s.shadowEndpoint); err != nil {
+			problems = append(problems, fmt.Errorf("shadow endpoint %q: %w", s.shadowEndpoint, err))
+		}
+	}
+	for _, key := range sortedHeaders(s.headers) {
+		if err := validateHeader(key); err != nil {
+			problems = append(problems, fmt.Errorf("header %q: %w", key, err))
+		}
+	}
+	if s.tenantHeader != "" {
+		if err := validateHeader(strings.ToLower(s.tenantHeader)); err != nil {
+			problems = append(problems, fmt.Errorf("tenant header %q: %w", s.tenantHeader, err))
+		}
+	}
+	for _, r := range s.clientName {
+		if r < ' ' || r > '~' {
+			problems = append(problems, fmt.Errorf("client name %q: invalid character %q", s.clientName, r))
+			break
+		}
+	}
+	for p, schema := range s.schemas {
+		if schema == nil {
+			problems = append(problems, fmt.Errorf("schema of %s/%s is nil", p.Namespace, p.Profile))
+		}
+	}
+	chained := make(map[string]bool, len(s.namespaceChain))
+	for _, namespace := range s.namespaceChain {
+		switch {
+		case namespace == "":
+			problems = append(problems, errors.New("namespace chain holds an empty namespace"))
+		case chained[namespace]:
+			problems = append(problems, fmt.Errorf("namespace chain holds %q twice", namespace))
+		}
+		chained[namespace] = true
+	}
+	if s.readTimeout < 0 {
+		problems = append(problems, errors.New("read timeout must not be negative"))
+	}
+	if s.pollInterval < 0 {
+		problems = append(problems, errors.New("poll interval must not be negative"))
+	}
+	if s.rateLimit > 0 && s.rateBurst < 0 {
+		problems = append(problems, errors.New("rate limit burst must not be negative"))
+	}
+	if s.exemplarThreshold < 0 {
+		problems = append(problems, errors.New("exemplar threshold must not be negative"))
+	}
+	if b := s.breaker; b != nil {
+		if b.ErrorRate < 0 || b.ErrorRate > 1 {
+			problems = append(problems, errors.New("circuit breaker error rate must be between 0 and 1"))
+		}
+		if b.MinRequests < 0 || b.Window < 0 || b.OpenDuration < 0 || b.HalfOpenProbes < 0 {
+			problems = append(problems, errors.New("circuit breaker settings must not be negative"))
+		}
+	}
+	if s.encryptionContext {
+		if _, ok := s.encrypter.(ContextEncrypter); !ok {
+			problems = append(problems, errors.New("encryption context requires an encrypter implementing ContextEncrypter"))
+		}
+	}
+	if s.happyEyeballsDelay < 0 {
+		problems = append(problems, errors.New("happy eyeballs delay must not be negative"))
+	}
+	if s.hedgingDelay < 0 || s.hedgingAttempts < 0 {
+		problems = append(problems, errors.New("hedging delay and attempts must not be negative"))
+	}
+	if s.cacheTTL < 0 {
+		problems = append(problems, errors.New("cache TTL must not be negative"))
+	}
+	if s.maxReceiveSize < 0 {
+		problems = append(problems, errors.New("max receive size must not be negative"))
+	}
+	if s.compression != "" && encoding.GetCompressor(s.compression) == nil {
+		problems = append(problems, fmt.Errorf("compressor %q is not registered", s.compression))
+	}
+	if s.maxValueSize < 0 {
+		problems = append(problems, errors.New("max value size must not be negative"))
+	}
+	for _, pattern := range s.secretKeyPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, fmt.Errorf("secret key pattern %q: %w", pattern, err))
+		}
+	}
+	if s.keyNamePattern != "" {
+		if _, err := regexp.Compile(s.keyNamePattern); err != nil {
+			problems = append(problems, fmt.Errorf("key name pattern %q: %w", s.keyNamePattern, err))
+		}
+	}
+	for _, nc := range s.codecs {
+		if _, err := path.Match(nc.Pattern, ""); err != nil {
+			problems = append(problems, fmt.Errorf("codec namespace pattern %q: %w", nc.Pattern, err))
+		}
+		if nc.Codec == nil {
+			problems = append(problems, fmt.Errorf("codec of namespace pattern %q is nil", nc.Pattern))
+		} else if strings.Contains(nc.Codec.Name(), ":") {
+			problems = append(problems, fmt.Errorf("codec name %q must not contain ':'", nc.Codec.Name()))
+		}
+	}
+	for _, kc := range s.keyCodecs {
+		if kc.Codec == nil {
+			problems = append(problems, fmt.Errorf("codec of key prefix %q is nil", kc.Prefix))
+		} else if strings.Contains(kc.Codec.Name(), ":") {
+			problems = append(problems, fmt.Errorf("codec name %q must not contain ':'", kc.Codec.Name()))
+		}
+	}
+	if s.proxy != "" && s.contextDialer != nil {
+		problems = append(problems, errors.New("a context dialer can't be combined with a proxy"))
+	}
+	if s.proxy != "" {
+		if err := validateProxy(s.proxy); err != nil {
+			problems = append(problems, fmt.Errorf("proxy: %w", err))
+		}
+		for _, endpoint := range s.GetEndpoints() {
+			if strings.HasPrefix(endpoint, "unix:") {
+				problems = append(problems, fmt.Errorf("endpoint %q: unix sockets can't be reached through a proxy", endpoint))
+			}
+		}
+	}
+	if s.useTls {
+		problems = append(problems, validateTLS(s.tls, "tls")...)
+	}
+	for _, namespace := range sortedNamespaces(s.namespaceCredentials) {
+		if creds := s.namespaceCredentials[namespace]; creds.TLS != nil {
+			problems = append(problems, validateTLS(creds.TLS, "namespace "+namespace+" tls")...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateEndpoint checks endpoint is a host:port address, a Unix domain socket or a URI with
+// a scheme, e.g. dns:///host:port.
+func validateEndpoint(endpoint string) error {
+	if strings.HasPrefix(endpoint, "unix:") {
+		if strings.TrimPrefix(strings.TrimPrefix(endpoint, "unix:"), "//") == "" {
+			return errors.New("missing socket path")
+		}
+		return nil
+	}
+	if strings.Contains(endpoint, "://") {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return err
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// validateHeader checks key is a metadata name which can be set by the client.
+func validateHeader(key string) error {
+	if key == "" {
+		return errors.New("empty name")
+	}
+	if strings.HasPrefix(key, "grpc-") {
+		return errors.New("names starting with grpc- are reserved")
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("invalid character %q", r)
+		}
+	}
+	return nil
+}
+
+// validateHTTPEndpoint checks endpoint is the base URL of a gRPC-Web gateway, over HTTPS if useTls.
+func validateHTTPEndpoint(endpoint string, useTls bool) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Errorf("the HTTP transport needs an http or https URL, not %q", u.Scheme)
+	case u.Host == "":
+		return errors.New("missing host")
+	case useTls && u.Scheme != "https":
+		return errors.New("TLS is enabled but the URL is not https")
+	}
+	return nil
+}
+
+// validateProxy checks proxy is a URL with a supported scheme and a host.
+func validateProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("missing host")
+	}
+	return nil
+}
+
+// validateTLS checks TLS settings, prefixing problems with name.
+func validateTLS(t *TLS, name string) []error {
+	if t == nil {
+		return nil
+	}
+	var problems []error
+	if t.Config != nil {
+		if t.SkipTlsVerification || t.UseSystemCertPool || t.CaCertPath != "" || len(t.CaCertPEM) > 0 ||
+			t.CertPath != "" || t.KeyPath != "" || len(t.CertPEM) > 0 || len(t.KeyPEM) > 0 {
+			problems = append(problems, fmt.Errorf("%s: only ServerNameOverride can be combined with a custom Config", name))
+		}
+		return problems
+	}
+	if t.SkipTlsVerification && (t.CaCertPath != "" || len(t.CaCertPEM) > 0) {
+		problems = append(problems, fmt.Errorf("%s: CA certificates are ignored when SkipTlsVerification is set", name))
+	}
+	if t.ReloadInterval < 0 {
+		problems = append(problems, fmt.Errorf("%s: ReloadInterval must not be negative", name))
+	}
+	if (t.CertPath == "") != (t.KeyPath == "") {
+		problems = append(problems, fmt.Errorf("%s: CertPath and KeyPath must be set together", name))
+	}
+	if (len(t.CertPEM) == 0) != (len(t.KeyPEM) == 0) {
+		problems = append(problems, fmt.Errorf("%s: CertPEM and KeyPEM must be set together", name))
+	}
+	if t.CertPath != "" && len(t.CertPEM) > 0 {
+		problems = append(problems, fmt.Errorf("%s: CertPath and CertPEM are mutually exclusive", name))
+	}
+	for _, file := range []string{t.CaCertPath, t.CertPath, t.KeyPath} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return problems
+}
+
+// sortedHeaders returns the names of headers in order, so that problems are reported in a stable order.
+func sortedHeaders(headers map[string]string) []string {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedNamespaces returns the namespaces of creds in order, so that problems are reported in a stable order.
+func sortedNamespaces(creds map[string]Credentials) []string {
+	namespaces := make([]string, 0, len(creds))
+	for namespace := range creds {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
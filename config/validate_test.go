@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo/config"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := []struct {
+		name   string
+		config *config.StooConfig
+		want   []string
+	}{
+		{
+			name:   "valid",
+			config: config.NewStooConfig("localhost:50051", 5*time.Second).WithHeader("x-team", "payments"),
+		},
+		{
+			name:   "valid endpoints",
+			config: config.NewStooConfig("localhost:50051", 5*time.Second).WithEndpoints("unix:///run/stookv.sock", "dns:///stookv:50051"),
+		},
+		{
+			name:   "valid HTTP transport",
+			config: config.NewStooConfig("https://stookv.example.com", 5*time.Second).WithTransport(config.TransportHTTP).WithUseTls(true),
+		},
+		{
+			name:   "missing endpoint",
+			config: config.NewStooConfig("", 5*time.Second),
+			want:   []string{config.ErrEndpointRequired.Error()},
+		},
+		{
+			name: "multiple problems",
+			config: config.NewStooConfig("localhost:99999", 5*time.Second).
+				WithHeader("grpc-timeout", "1s").
+				WithNamespaceChain("base", "", "base").
+				WithExemplarThreshold(-time.Second).
+				WithHedging(-time.Millisecond, 2).
+				WithSecretKeyPatterns("[").
+				WithKeyNamePattern("(").
+				WithCompression("brotli").
+				WithUseTls(true).
+				WithTls(&config.TLS{CertPath: missing}),
+			want: []string{
+				`endpoint "localhost:99999": invalid port "99999"`,
+				`header "grpc-timeout": names starting with grpc- are reserved`,
+				"namespace chain holds an empty namespace",
+				`namespace chain holds "base" twice`,
+				"exemplar threshold must not be negative",
+				"hedging delay and attempts must not be negative",
+				`compressor "brotli" is not registered`,
+				`secret key pattern "["`,
+				`key name pattern "("`,
+				"tls: CertPath and KeyPath must be set together",
+				"tls: stat " + missing,
+			},
+		},
+		{
+			name:   "HTTP endpoint over TLS",
+			config: config.NewStooConfig("http://stookv.example.com", 5*time.Second).WithTransport(config.TransportHTTP).WithUseTls(true),
+			want:   []string{`endpoint "http://stookv.example.com": TLS is enabled but the URL is not https`},
+		},
+		{
+			name:   "proxy",
+			config: config.NewStooConfig("unix:///run/stookv.sock", 5*time.Second).WithProxy("ftp://proxy:21"),
+			want: []string{
+				`proxy: unsupported scheme "ftp"`,
+				`endpoint "unix:///run/stookv.sock": unix sockets can't be reached through a proxy`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var verr *config.ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+			}
+			if len(verr.Problems) != len(tt.want) {
+				t.Fatalf("Validate() problems = %q, want %d problems", verr.Problems, len(tt.want))
+			}
+			for i, problem := range verr.Problems {
+				if !strings.HasPrefix(problem.Error(), tt.want[i]) {
+					t.Errorf("problem %d = %q, want prefix %q", i, problem, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorIs(t *testing.T) {
+	err := config.NewStooConfig("", 5*time.Second).WithCacheTTL(-time.Second).Validate()
+	if !errors.Is(err, config.ErrEndpointRequired) {
+		t.Errorf("errors.Is(%v, ErrEndpointRequired) = false, want true", err)
+	}
+	if want := "invalid configuration: endpoint must be defined; cache TTL must not be negative"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
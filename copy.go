@@ -0,0 +1,164 @@
+package stogo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/envelope"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// ErrKeyNotFound thrown when a key does not exist in a namespace and profile. Reads of missing
+// keys wrap it along with the NotFound status returned by StooKV, if any.
+var ErrKeyNotFound = errors.New("key not found")
+
+// keyNotFound wraps err with ErrKeyNotFound if it tells the key doesn't exist, or returns
+// ErrKeyNotFound if err is nil. Other errors are returned as they are.
+func keyNotFound(namespace, profile, key string, err error) error {
+	if err == nil {
+		return fmt.Errorf("%w: %s/%s/%s", ErrKeyNotFound, namespace, profile, key)
+	}
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("%w: %s/%s/%s: %w", ErrKeyNotFound, namespace, profile, key, err)
+	}
+	return err
+}
+
+// ErrKeyExists thrown by copy operations using the Fail conflict policy when a key already
+// exists in the destination.
+var ErrKeyExists = errors.New("key already exists in destination")
+
+// ConflictPolicy tells copy operations what to do when a key already exists in the destination.
+type ConflictPolicy int
+
+const (
+	// Overwrite replaces the destination value.
+	Overwrite ConflictPolicy = iota
+	// Skip keeps the destination value.
+	Skip
+	// Fail aborts the operation with ErrKeyExists before writing anything.
+	Fail
+)
+
+// CopyKey copies a key from one namespace and profile to another. It reports whether the key
+// was written, which is false when the destination exists and policy is Skip. Secrets, keys
+// matching the secret key patterns or holding a value encrypted on the client, are written as
+// secrets, re-encrypted for the destination when the encryption context binds them to the source.
+//
+// Usage example:
+//
+//	copied, err := client.CopyKey("my-app", "staging", "my-app", "prod", "database.pool-size", stogo.Fail)
+//	if err != nil {
+//		log.Fatalf("Error copying key %v", err)
+//	}
+func (c *StooClient) CopyKey(srcNamespace, srcProfile, dstNamespace, dstProfile, key string, policy ConflictPolicy) (bool, error) {
+	src, err := c.GetAll(srcNamespace, srcProfile)
+	if err != nil {
+		return false, err
+	}
+	value, ok := src[key]
+	if !ok {
+		return false, fmt.Errorf("%w: %s/%s/%s", ErrKeyNotFound, srcNamespace, srcProfile, key)
+	}
+	dst, err := c.GetAll(dstNamespace, dstProfile)
+	if err != nil {
+		return false, err
+	}
+	if _, exists := dst[key]; exists {
+		switch policy {
+		case Skip:
+			return false, nil
+		case Fail:
+			return false, fmt.Errorf("%w: %s/%s/%s", ErrKeyExists, dstNamespace, dstProfile, key)
+		}
+	}
+	m, err := c.copyMutation(srcNamespace, srcProfile, key, value)
+	if err != nil {
+		return false, err
+	}
+	if err := c.mutate(dstNamespace, dstProfile, m); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyMutation returns the mutation writing value, read from key of srcNamespace and
+// srcProfile, to the same key elsewhere. Secrets are written as secrets, as stored, unless their
+// ciphertext is bound to its location by the encryption context, in which case they are
+// re-encrypted for the destination.
+func (c *StooClient) copyMutation(srcNamespace, srcProfile, key, value string) (mutation, error) {
+	cfg := c.CurrentConfig()
+	encrypted := envelope.IsEncrypted(value)
+	if !encrypted && !cfg.IsSecretKey(key) {
+		return mutation{key: key, value: value}, nil
+	}
+	if encrypted && cfg.GetEncryptionContext() {
+		plaintext, err := decryptSecret(cfg, srcNamespace, srcProfile, key, value)
+		if err != nil {
+			return mutation{}, err
+		}
+		return mutation{key: key, value: plaintext, secret: true}, nil
+	}
+	return mutation{key: key, value: value, secret: true, raw: true}, nil
+}
+
+// MoveKey copies a key like CopyKey and then deletes it from the source. The source is kept
+// when the copy is skipped.
+func (c *StooClient) MoveKey(srcNamespace, srcProfile, dstNamespace, dstProfile, key string, policy ConflictPolicy) (bool, error) {
+	copied, err := c.CopyKey(srcNamespace, srcProfile, dstNamespace, dstProfile, key, policy)
+	if err != nil || !copied {
+		return copied, err
+	}
+	if _, err := c.Delete(srcNamespace, srcProfile, key); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// CloneProfile copies all key value pairs of fromProfile to toProfile within a namespace.
+// With the Fail policy, all conflicting keys are reported at once and nothing is written.
+// Secrets are written as secrets, like with CopyKey.
+// The returned error only reports a failure to read the profiles or a Fail conflict.
+//
+// Usage example:
+//
+//	res, err := client.CloneProfile("my-app", "staging", "prod", stogo.Skip)
+//	if err != nil {
+//		log.Fatalf("Error cloning profile %v", err)
+//	}
+//	log.Printf("cloned %d keys, skipped %v, failed %v", len(res.Succeeded), res.Skipped, res.FailedKeys())
+func (c *StooClient) CloneProfile(namespace, fromProfile, toProfile string, policy ConflictPolicy) (*BulkResult, error) {
+	src, err := c.GetAll(namespace, fromProfile)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := c.GetAll(namespace, toProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, key := range sortedKeys(src) {
+		if _, exists := dst[key]; exists {
+			conflicts = append(conflicts, key)
+		}
+	}
+	if policy == Fail && len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s/%s: %s", ErrKeyExists, namespace, toProfile, strings.Join(conflicts, ", "))
+	}
+
+	result := &BulkResult{}
+	for _, key := range sortedKeys(src) {
+		if _, exists := dst[key]; exists && policy == Skip {
+			result.Skipped = append(result.Skipped, key)
+			continue
+		}
+		m, err := c.copyMutation(namespace, fromProfile, key, src[key])
+		if err == nil {
+			err = c.mutate(namespace, toProfile, m)
+		}
+		result.add("set", key, err)
+	}
+	return result, nil
+}
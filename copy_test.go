@@ -0,0 +1,97 @@
+package stogo_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/stogotest"
+	"testing"
+)
+
+func TestCopySecrets(t *testing.T) {
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		// context enables the encryption context.
+		context bool
+		copy    func(*stogo.StooClient) error
+	}{
+		{"CopyKey", false, func(c *stogo.StooClient) error {
+			for _, key := range []string{"db.host", "db.password", "api.token"} {
+				if _, err := c.CopyKey("app", "staging", "app", "prod", key, stogo.Fail); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"CloneProfile", false, func(c *stogo.StooClient) error {
+			result, err := c.CloneProfile("app", "staging", "prod", stogo.Fail)
+			if err != nil {
+				return err
+			}
+			return result.Err()
+		}},
+		{"CloneProfile with encryption context", true, func(c *stogo.StooClient) error {
+			result, err := c.CloneProfile("app", "staging", "prod", stogo.Fail)
+			if err != nil {
+				return err
+			}
+			return result.Err()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			recorder := &writeRecorder{methods: make(map[string]string)}
+			client := stogo.NewStoreClient(srv.Config().
+				WithEncrypter(envelope.NewChain(provider)).
+				WithEncryptionContext(tt.context).
+				WithSecretKeyPatterns("*.password").
+				WithUnaryInterceptors(recorder.interceptor))
+			// db.password matches the secret key patterns but was stored before encryption.
+			writes := []struct {
+				key, value string
+				write      func(namespace, profile, key, value string, opts ...stogo.CallOption) (string, error)
+			}{
+				{"db.host", "db.internal", client.Set},
+				{"db.password", "s3cr3t", stogo.NewStoreClient(srv.Config()).SetSecret},
+				{"api.token", "t0ken", client.SetSecret},
+			}
+			for _, w := range writes {
+				if _, err := w.write("app", "staging", w.key, w.value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tt.copy(client); err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range writes {
+				wantMethod := "SetSecretKeyService"
+				if w.key == "db.host" {
+					wantMethod = "SetKeyService"
+				}
+				if method := recorder.methods[w.key]; method != wantMethod {
+					t.Errorf("%s copied with %s, want %s", w.key, method, wantMethod)
+				}
+				value, err := client.GetSecret("app", "prod", w.key)
+				if err != nil || value.Reveal() != w.value {
+					t.Errorf("got %s %q, %v at the destination, want %q", w.key, value.Reveal(), err, w.value)
+				}
+			}
+			if token := srv.Data("app", "prod")["api.token"]; !envelope.IsEncrypted(token) {
+				t.Errorf("got api.token stored as %q, want it encrypted", token)
+			}
+			if _, err := client.CopyKey("app", "staging", "app", "prod", "db.host", stogo.Fail); !errors.Is(err, stogo.ErrKeyExists) {
+				t.Errorf("got error %v copying over an existing key, want %v", err, stogo.ErrKeyExists)
+			}
+		})
+	}
+}
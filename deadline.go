@@ -0,0 +1,127 @@
+package stogo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"path"
+	"time"
+)
+
+// DeadlineError reports a call which ran out of time, with the budget it was given and whether
+// the budget was set by the read timeout or by the deadline of the caller's context, the
+// smaller of the two bounding every call. It matches context.DeadlineExceeded with errors.Is and
+// keeps the DeadlineExceeded status of the call.
+//
+// Usage example:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 50*time.Millisecond)
+//	defer cancel()
+//	_, err := client.Get("my-app", "prod", "checkout.theme", stogo.WithContext(ctx))
+//	var deadlineErr *stogo.DeadlineError
+//	if errors.As(err, &deadlineErr) && deadlineErr.Parent {
+//		log.Printf("request budget too short: %v", deadlineErr.Budget)
+//	}
+type DeadlineError struct {
+	// Method name of the call, e.g. GetService.
+	Method string
+	// Budget time the call was given when it started.
+	Budget time.Duration
+	// Elapsed time the call ran for.
+	Elapsed time.Duration
+	// ReadTimeout configured read timeout.
+	ReadTimeout time.Duration
+	// Parent tells the budget was set by the deadline of the caller's context, shorter than the
+	// read timeout, rather than by the read timeout.
+	Parent bool
+	// Err error the call failed with.
+	Err error
+}
+
+// Error implements error.
+func (e *DeadlineError) Error() string {
+	source := "read timeout"
+	if e.Parent {
+		source = fmt.Sprintf("caller's deadline, read timeout %v", e.ReadTimeout)
+	}
+	return fmt.Sprintf("%s ran out of its %v budget (%s) after %v: %v", e.Method, e.Budget.Round(time.Millisecond), source, e.Elapsed.Round(time.Millisecond), e.Err)
+}
+
+// Is tells if target is context.DeadlineExceeded.
+func (e *DeadlineError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// Unwrap returns the error the call failed with.
+func (e *DeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// callBudget time budget of a call, set by newContext.
+type callBudget struct {
+	clock       config.Clock
+	start       time.Time
+	budget      time.Duration
+	readTimeout time.Duration
+	parent      bool
+}
+
+// callBudgetKey context key of the callBudget of a call.
+type callBudgetKey struct{}
+
+// withBudget bounds ctx by readTimeout or by its own deadline, whichever comes first, and
+// records which one applies. Elapsed time is measured with clock, while context deadlines
+// are compared to the wall clock they are set with.
+func withBudget(ctx context.Context, clock config.Clock, readTimeout time.Duration) (context.Context, context.CancelFunc) {
+	b := callBudget{clock: clock, start: clock.Now(), budget: readTimeout, readTimeout: readTimeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < readTimeout {
+			b.budget, b.parent = remaining, true
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	return context.WithValue(ctx, callBudgetKey{}, b), cancel
+}
+
+// budgetFrom returns the budget of the call made with ctx, if made by the client.
+func budgetFrom(ctx context.Context) (callBudget, bool) {
+	b, ok := ctx.Value(callBudgetKey{}).(callBudget)
+	return b, ok
+}
+
+// callerDeadlineExceeded tells if err is the call running out of the budget set by the
+// caller's context, which says nothing about the health of the endpoint.
+func callerDeadlineExceeded(ctx context.Context, err error) bool {
+	b, ok := budgetFrom(ctx)
+	return ok && b.parent && status.Code(err) == codes.DeadlineExceeded
+}
+
+// deadlineInterceptor turns calls running out of time into a *DeadlineError.
+func deadlineInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || status.Code(err) != codes.DeadlineExceeded {
+			return err
+		}
+		var deadlineErr *DeadlineError
+		if errors.As(err, &deadlineErr) {
+			return err
+		}
+		b, ok := budgetFrom(ctx)
+		if !ok {
+			return err
+		}
+		return &DeadlineError{
+			Method:      path.Base(method),
+			Budget:      b.budget,
+			Elapsed:     b.clock.Now().Sub(b.start),
+			ReadTimeout: b.readTimeout,
+			Parent:      b.parent,
+			Err:         err,
+		}
+	}
+}
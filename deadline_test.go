@@ -0,0 +1,71 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"net"
+	"testing"
+	"time"
+)
+
+// stallServer advances clock by elapsed on every read, then waits for the caller to give up.
+type stallServer struct {
+	proto.UnimplementedKVServiceServer
+	clock   *stogotest.FakeClock
+	elapsed time.Duration
+}
+
+func (s *stallServer) GetService(ctx context.Context, _ *proto.GetRequest) (*proto.GetResponse, error) {
+	s.clock.Advance(s.elapsed)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDeadlineError(t *testing.T) {
+	tests := []struct {
+		name        string
+		readTimeout time.Duration
+		ctxTimeout  time.Duration
+		wantParent  bool
+	}{
+		{"read timeout", 50 * time.Millisecond, 0, false},
+		{"caller's deadline", time.Minute, 50 * time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := stogotest.NewFakeClock(time.Now())
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := grpc.NewServer()
+			proto.RegisterKVServiceServer(server, &stallServer{clock: clock, elapsed: 5 * time.Second})
+			go server.Serve(lis)
+			defer server.Stop()
+
+			client := stogo.NewStoreClient(config.NewStooConfig(lis.Addr().String(), tt.readTimeout).WithClock(clock))
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+			_, err = client.Get("app", "prod", "key", stogo.WithContext(ctx))
+			var deadlineErr *stogo.DeadlineError
+			if !errors.As(err, &deadlineErr) {
+				t.Fatalf("got error %v, want a DeadlineError", err)
+			}
+			if deadlineErr.Parent != tt.wantParent {
+				t.Errorf("got parent %v, want %v", deadlineErr.Parent, tt.wantParent)
+			}
+			if deadlineErr.Elapsed != 5*time.Second {
+				t.Errorf("got elapsed %v, want the 5s of the configured clock", deadlineErr.Elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package stogo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// happyEyeballsDialer returns a dialer connecting to every address a host resolves to in
+// parallel, starting a new attempt every attemptDelay or as soon as the previous one fails,
+// and keeping the first connection established (RFC 8305). Addresses alternate between IPv6
+// and IPv4 so that a broken family doesn't delay connecting.
+func happyEyeballsDialer(attemptDelay time.Duration) func(ctx context.Context, addr string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		var d net.Dialer
+		if net.ParseIP(host) != nil {
+			return d.DialContext(ctx, "tcp", addr)
+		}
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		addrs := interleaveFamilies(ips)
+		if len(addrs) == 1 {
+			return d.DialContext(ctx, "tcp", net.JoinHostPort(addrs[0].String(), port))
+		}
+		return raceDial(ctx, &d, addrs, port, attemptDelay)
+	}
+}
+
+// dialResult outcome of a single connection attempt.
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// raceDial dials addrs with staggered attempts and returns the first connection established.
+func raceDial(ctx context.Context, d *net.Dialer, addrs []net.IPAddr, port string, attemptDelay time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, len(addrs))
+	started, pending := 0, 0
+	start := func() {
+		addr := net.JoinHostPort(addrs[started].String(), port)
+		started++
+		pending++
+		go func() {
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			results <- dialResult{conn: conn, err: err}
+		}()
+	}
+
+	var errs []error
+	start()
+	for pending > 0 {
+		var timer *time.Timer
+		var next <-chan time.Time
+		if started < len(addrs) {
+			timer = time.NewTimer(attemptDelay)
+			next = timer.C
+		}
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				go closeLate(results, pending)
+				return res.conn, nil
+			}
+			errs = append(errs, res.err)
+			if started < len(addrs) {
+				start()
+			}
+		case <-next:
+			start()
+		case <-ctx.Done():
+			go closeLate(results, pending)
+			return nil, ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// closeLate closes the connections of the pending attempts which lost the race.
+func closeLate(results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		if late := <-results; late.conn != nil {
+			late.conn.Close()
+		}
+	}
+}
+
+// interleaveFamilies orders ips alternating IPv6 and IPv4 addresses, starting with IPv6.
+func interleaveFamilies(ips []net.IPAddr) []net.IPAddr {
+	var v6, v4 []net.IPAddr
+	for _, ip := range ips {
+		if ip.IP.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	ordered := make([]net.IPAddr, 0, len(ips))
+	for i := 0; i < len(v6) || i < len(v4); i++ {
+		if i < len(v6) {
+			ordered = append(ordered, v6[i])
+		}
+		if i < len(v4) {
+			ordered = append(ordered, v4[i])
+		}
+	}
+	return ordered
+}
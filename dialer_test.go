@@ -0,0 +1,115 @@
+package stogo
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInterleaveFamilies(t *testing.T) {
+	v4a, v4b := net.IPAddr{IP: net.ParseIP("10.0.0.1")}, net.IPAddr{IP: net.ParseIP("10.0.0.2")}
+	v6a, v6b := net.IPAddr{IP: net.ParseIP("fd00::1")}, net.IPAddr{IP: net.ParseIP("fd00::2")}
+	tests := []struct {
+		name string
+		ips  []net.IPAddr
+		want []net.IPAddr
+	}{
+		{"IPv6 first", []net.IPAddr{v4a, v4b, v6a, v6b}, []net.IPAddr{v6a, v4a, v6b, v4b}},
+		{"more IPv4", []net.IPAddr{v4a, v6a, v4b}, []net.IPAddr{v6a, v4a, v4b}},
+		{"IPv4 only", []net.IPAddr{v4a, v4b}, []net.IPAddr{v4a, v4b}},
+		{"IPv6 only", []net.IPAddr{v6b, v6a}, []net.IPAddr{v6b, v6a}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interleaveFamilies(tt.ips); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interleaveFamilies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRaceDial(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+	// other is a loopback address nothing listens on, refusing connections right away unless
+	// the attempt is made to hang.
+	other := net.IPAddr{IP: net.ParseIP("127.0.0.2")}
+	listening := net.IPAddr{IP: net.ParseIP("127.0.0.1")}
+
+	tests := []struct {
+		name         string
+		addrs        []net.IPAddr
+		hang         bool
+		attemptDelay time.Duration
+		wantErr      bool
+	}{
+		{"first attempt failing", []net.IPAddr{other, listening}, false, time.Hour, false},
+		{"first attempt hanging", []net.IPAddr{other, listening}, true, 50 * time.Millisecond, false},
+		{"every attempt failing", []net.IPAddr{other, other}, false, time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canceled := make(chan struct{})
+			d := &net.Dialer{}
+			if tt.hang {
+				d.ControlContext = func(ctx context.Context, _, address string, _ syscall.RawConn) error {
+					if address != net.JoinHostPort(other.String(), port) {
+						return nil
+					}
+					<-ctx.Done()
+					close(canceled)
+					return ctx.Err()
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			start := time.Now()
+			conn, err := raceDial(ctx, d, tt.addrs, port, tt.attemptDelay)
+			elapsed := time.Since(start)
+			if tt.wantErr {
+				if err == nil {
+					conn.Close()
+					t.Fatal("got a connection, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if got := conn.RemoteAddr().String(); got != lis.Addr().String() {
+				t.Errorf("got connection to %s, want %s", got, lis.Addr())
+			}
+			if !tt.hang {
+				if elapsed > time.Second {
+					t.Errorf("got connected after %v, want the next attempt started as soon as the first failed", elapsed)
+				}
+				return
+			}
+			if elapsed < tt.attemptDelay {
+				t.Errorf("got connected after %v, want the next attempt started after %v", elapsed, tt.attemptDelay)
+			}
+			select {
+			case <-canceled:
+			case <-time.After(time.Second):
+				t.Error("the hanging attempt was not canceled")
+			}
+		})
+	}
+}
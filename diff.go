@@ -0,0 +1,105 @@
+package stogo
+
+import (
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"strings"
+)
+
+// MaskedValue replaces values of secret keys in results meant to be displayed.
+const MaskedValue = config.MaskedValue
+
+// DiffEntry a key which differs between two profiles. From is empty for added keys and
+// To is empty for removed keys. Values of secret keys are replaced by MaskedValue.
+type DiffEntry struct {
+	Key  string
+	From string
+	To   string
+}
+
+// ProfileDiff differences between two namespaces and profiles, each list sorted by key.
+type ProfileDiff struct {
+	// Added keys present only in the second profile.
+	Added []DiffEntry
+	// Removed keys present only in the first profile.
+	Removed []DiffEntry
+	// Changed keys present in both profiles with different values.
+	Changed []DiffEntry
+}
+
+// Empty tells if both profiles hold the same key value pairs.
+func (d *ProfileDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String renders the diff one key per line, prefixed with +, - or ~.
+func (d *ProfileDiff) String() string {
+	var b strings.Builder
+	for _, e := range d.Added {
+		fmt.Fprintf(&b, "+ %s = %s\n", e.Key, e.To)
+	}
+	for _, e := range d.Removed {
+		fmt.Fprintf(&b, "- %s = %s\n", e.Key, e.From)
+	}
+	for _, e := range d.Changed {
+		fmt.Fprintf(&b, "~ %s: %s -> %s\n", e.Key, e.From, e.To)
+	}
+	return b.String()
+}
+
+// Diff compares two profiles of a namespace. Keys matching the configured secret key
+// patterns are compared on their real values but reported masked.
+//
+// Usage example:
+//
+//	diff, err := client.Diff("my-app", "staging", "prod")
+//	if err != nil {
+//		log.Fatalf("Error comparing profiles %v", err)
+//	}
+//	if !diff.Empty() {
+//		log.Fatalf("prod drifted from staging:\n%s", diff)
+//	}
+func (c *StooClient) Diff(namespace, profileA, profileB string) (*ProfileDiff, error) {
+	return c.DiffNamespaces(namespace, profileA, namespace, profileB)
+}
+
+// DiffNamespaces compares a profile of a namespace with a profile of another namespace, see Diff.
+func (c *StooClient) DiffNamespaces(namespaceA, profileA, namespaceB, profileB string) (*ProfileDiff, error) {
+	a, err := c.GetAll(namespaceA, profileA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.GetAll(namespaceB, profileB)
+	if err != nil {
+		return nil, err
+	}
+	return c.diffValues(a, b), nil
+}
+
+// diffValues compares a with b, masking secret values.
+func (c *StooClient) diffValues(a, b map[string]string) *ProfileDiff {
+	diff := &ProfileDiff{}
+	for _, key := range sortedKeys(a) {
+		to, ok := b[key]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, DiffEntry{Key: key, From: c.mask(key, a[key])})
+		case to != a[key]:
+			diff.Changed = append(diff.Changed, DiffEntry{Key: key, From: c.mask(key, a[key]), To: c.mask(key, to)})
+		}
+	}
+	for _, key := range sortedKeys(b) {
+		if _, ok := a[key]; !ok {
+			diff.Added = append(diff.Added, DiffEntry{Key: key, To: c.mask(key, b[key])})
+		}
+	}
+	return diff
+}
+
+// mask returns MaskedValue if key is a secret key, value otherwise.
+func (c *StooClient) mask(key, value string) string {
+	if c.CurrentConfig().IsSecretKey(key) {
+		return MaskedValue
+	}
+	return value
+}
@@ -0,0 +1,180 @@
+package stogo
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy tells a Dispatcher what to do with an event when the queue of its worker is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the submitted event.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued event to make room for the submitted one.
+	OverflowDropOldest
+)
+
+// DispatcherStats counters of a Dispatcher.
+type DispatcherStats struct {
+	// Submitted events submitted.
+	Submitted uint64
+	// Handled events passed to the handler.
+	Handled uint64
+	// Dropped events dropped because of a full queue.
+	Dropped uint64
+}
+
+// Dispatcher runs a handler for change events on a pool of workers. Events of the same key
+// always go to the same worker, so they are handled in the order they were submitted, while
+// events of different keys are handled concurrently. Every worker has a bounded queue whose
+// overflow is handled according to the dispatcher OverflowPolicy.
+type Dispatcher struct {
+	handler func(Event)
+	policy  OverflowPolicy
+	queues  []chan Event
+	locks   []sync.Mutex
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	submitted atomic.Uint64
+	handled   atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewDispatcher creates a Dispatcher with the given number of workers, each with a queue
+// holding up to queueSize events, and starts the workers.
+//
+// Usage example:
+//
+//	d := stogo.NewDispatcher(4, 100, stogo.OverflowDropOldest, func(e stogo.Event) {
+//		log.Printf("%s %s: %q -> %q", e.Type, e.Key, e.OldValue, e.NewValue)
+//	})
+//	defer d.Close()
+//	go d.Run(ctx, client, "my-app", "prod", 10*time.Second)
+func NewDispatcher(workers, queueSize int, policy OverflowPolicy, handler func(Event)) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	d := &Dispatcher{
+		handler: handler,
+		policy:  policy,
+		queues:  make([]chan Event, workers),
+		locks:   make([]sync.Mutex, workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan Event, queueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+// Submit queues an event. It returns false if the event was dropped, either because of the
+// overflow policy or because the dispatcher is closed.
+func (d *Dispatcher) Submit(e Event) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return false
+	}
+	d.submitted.Add(1)
+
+	i := d.worker(e)
+	queue := d.queues[i]
+	switch d.policy {
+	case OverflowDropNewest:
+		select {
+		case queue <- e:
+			return true
+		default:
+			d.dropped.Add(1)
+			return false
+		}
+	case OverflowDropOldest:
+		d.locks[i].Lock()
+		defer d.locks[i].Unlock()
+		for {
+			select {
+			case queue <- e:
+				return true
+			default:
+			}
+			select {
+			case <-queue:
+				d.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		queue <- e
+		return true
+	}
+}
+
+// Run watches a namespace and profile with StooClient.Watch and submits an event for every
+// changed key until ctx is done. Keys present at the first poll are not reported.
+func (d *Dispatcher) Run(ctx context.Context, client *StooClient, namespace, profile string, interval time.Duration) error {
+	var last map[string]string
+	return client.Watch(ctx, namespace, profile, interval, func(all map[string]string) {
+		if last != nil {
+			for _, e := range diffEvents(namespace, profile, last, all, client.CurrentConfig().GetClock().Now()) {
+				d.Submit(e)
+			}
+		}
+		last = all
+		if last == nil {
+			last = map[string]string{}
+		}
+	})
+}
+
+// Stats returns the dispatcher counters.
+func (d *Dispatcher) Stats() DispatcherStats {
+	return DispatcherStats{
+		Submitted: d.submitted.Load(),
+		Handled:   d.handled.Load(),
+		Dropped:   d.dropped.Load(),
+	}
+}
+
+// Close stops accepting events and waits for queued ones to be handled.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// worker returns the index of the worker handling the key of e.
+func (d *Dispatcher) worker(e Event) int {
+	h := fnv.New32a()
+	h.Write([]byte(e.Namespace + "/" + e.Profile + "/" + e.Key))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+// work handles the events of a queue until it is closed.
+func (d *Dispatcher) work(queue chan Event) {
+	defer d.wg.Done()
+	for e := range queue {
+		d.handler(e)
+		d.handled.Add(1)
+	}
+}
@@ -0,0 +1,455 @@
+package stogo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DoctorNamespace scratch namespace Doctor writes its round trip key to.
+const DoctorNamespace = "stogo-doctor"
+
+// doctorProfile scratch profile Doctor writes its round trip key to.
+const doctorProfile = "doctor"
+
+// certExpiryWarning time before expiry from which Doctor warns about a certificate.
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// latencySamples number of reads Doctor times.
+const latencySamples = 5
+
+// DoctorStatus outcome of a Doctor check.
+type DoctorStatus string
+
+const (
+	// DoctorOK the check passed.
+	DoctorOK DoctorStatus = "ok"
+	// DoctorWarn the check passed with something worth looking at.
+	DoctorWarn DoctorStatus = "warn"
+	// DoctorFail the check failed.
+	DoctorFail DoctorStatus = "fail"
+	// DoctorSkip the check does not apply to the configuration.
+	DoctorSkip DoctorStatus = "skip"
+)
+
+// DoctorCheck result of a single Doctor check.
+type DoctorCheck struct {
+	// Name of the check.
+	Name string `json:"name"`
+	// Status outcome of the check.
+	Status DoctorStatus `json:"status"`
+	// Detail what was found.
+	Detail string `json:"detail"`
+	// Duration time the check took.
+	Duration time.Duration `json:"duration"`
+}
+
+// DoctorCertificate certificate seen during the TLS check.
+type DoctorCertificate struct {
+	// Endpoint address of the endpoint the certificate was seen with.
+	Endpoint string `json:"endpoint"`
+	// Role server for the certificates presented by StooKV, leaf first, client for the configured one.
+	Role string `json:"role"`
+	// Subject distinguished name of the certificate.
+	Subject string `json:"subject"`
+	// Issuer distinguished name of the issuer of the certificate.
+	Issuer string `json:"issuer"`
+	// DNSNames names the certificate is valid for.
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// NotBefore start of validity of the certificate.
+	NotBefore time.Time `json:"notBefore"`
+	// NotAfter end of validity of the certificate.
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// DoctorReport results of all Doctor checks, in the order they ran.
+type DoctorReport struct {
+	// Endpoints addresses of the configured endpoints.
+	Endpoints []string `json:"endpoints"`
+	// Checks results of the checks.
+	Checks []DoctorCheck `json:"checks"`
+	// Certificates certificates seen during the TLS checks, empty if TLS is disabled.
+	Certificates []DoctorCertificate `json:"certificates,omitempty"`
+}
+
+// OK tells if no check failed.
+func (r *DoctorReport) OK() bool {
+	for _, check := range r.Checks {
+		if check.Status == DoctorFail {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the report as text, one line per check, suitable for support tickets.
+func (r *DoctorReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "endpoints: %s\n", strings.Join(r.Endpoints, ", "))
+	for _, check := range r.Checks {
+		fmt.Fprintf(&b, "[%-4s] %-12s %-10s %s\n", check.Status, check.Name, check.Duration.Round(time.Microsecond), check.Detail)
+	}
+	for _, cert := range r.Certificates {
+		fmt.Fprintf(&b, "%s %s certificate: %s, issued by %s, valid %s to %s\n", cert.Endpoint, cert.Role, cert.Subject, cert.Issuer,
+			cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// add records a check which started at start.
+func (r *DoctorReport) add(name string, start time.Time, now time.Time, status DoctorStatus, detail string) {
+	r.Checks = append(r.Checks, DoctorCheck{Name: name, Status: status, Detail: detail, Duration: now.Sub(start)})
+}
+
+// Diagnose checks every layer between the client and StooKV without writing anything, so it
+// can run with read-only credentials: DNS resolution and TCP reachability of every endpoint,
+// the TLS handshake along with the certificate chain presented and its expiry, gRPC
+// connectivity, authorization, a test Get, the version and capabilities of the server and
+// whether the fallback file, if any, can be loaded.
+// Checks don't stop at the first failure, so the report tells at which layer something like
+// "connection refused" comes from.
+//
+// Usage example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	report := client.Diagnose(ctx)
+//	report.WriteTo(os.Stdout)
+//	if !report.OK() {
+//		os.Exit(1)
+//	}
+func (c *StooClient) Diagnose(ctx context.Context) *DoctorReport {
+	cfg := c.CurrentConfig()
+	clock := cfg.GetClock()
+	report := &DoctorReport{}
+	for _, e := range c.endpoints {
+		report.Endpoints = append(report.Endpoints, e.addr)
+	}
+
+	for _, e := range c.endpoints {
+		start := clock.Now()
+		s, detail := c.checkDNS(ctx, e.addr)
+		report.add("dns", start, clock.Now(), s, detail)
+	}
+
+	for _, e := range c.endpoints {
+		start := clock.Now()
+		s, detail := c.checkTCP(ctx, e.addr)
+		report.add("tcp", start, clock.Now(), s, detail)
+	}
+
+	for _, e := range c.endpoints {
+		start := clock.Now()
+		s, detail := c.checkTLS(ctx, e.addr, report)
+		report.add("tls", start, clock.Now(), s, detail)
+	}
+
+	for _, e := range c.endpoints {
+		start := clock.Now()
+		if err := e.healthCheck(ctx); err != nil {
+			report.add("connectivity", start, clock.Now(), DoctorFail, fmt.Sprintf("%s: %v", e.addr, err))
+		} else {
+			report.add("connectivity", start, clock.Now(), DoctorOK, e.addr)
+		}
+	}
+
+	opts := []CallOption{WithContext(ctx)}
+	start := clock.Now()
+	_, err := c.GetAll(DoctorNamespace, doctorProfile, opts...)
+	switch status.Code(err) {
+	case codes.OK:
+		report.add("auth", start, clock.Now(), DoctorOK, "reads allowed")
+	case codes.Unauthenticated, codes.PermissionDenied:
+		report.add("auth", start, clock.Now(), DoctorFail, err.Error())
+	default:
+		report.add("auth", start, clock.Now(), DoctorWarn, fmt.Sprintf("could not tell: %v", err))
+	}
+
+	start = clock.Now()
+	if _, err := c.Get(DoctorNamespace, doctorProfile, healthCheckKey, opts...); err != nil && !isNotFound(err) {
+		report.add("get", start, clock.Now(), DoctorFail, err.Error())
+	} else {
+		report.add("get", start, clock.Now(), DoctorOK, fmt.Sprintf("read %s/%s/%s", DoctorNamespace, doctorProfile, healthCheckKey))
+	}
+
+	start = clock.Now()
+	if info, err := c.ServerInfo(ctx); err != nil {
+		report.add("server", start, clock.Now(), DoctorWarn, fmt.Sprintf("could not tell: %v", err))
+	} else {
+		version := info.Version
+		if version == "" {
+			version = "unknown"
+		}
+		report.add("server", start, clock.Now(), DoctorOK, fmt.Sprintf("%s: version %s, supports %v, emulating %v", info.Endpoint, version, info.Capabilities, info.Emulated))
+	}
+
+	start = clock.Now()
+	s, detail := c.checkFallback()
+	report.add("fallback", start, clock.Now(), s, detail)
+	return report
+}
+
+// Doctor runs the checks of Diagnose, then a write, read and delete round trip of a reserved
+// key in DoctorNamespace and times reads. Checks don't stop at the first failure, so the report
+// gives the full picture, e.g. to attach to a support ticket.
+//
+// Usage example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	report := client.Doctor(ctx)
+//	report.WriteTo(os.Stdout)
+func (c *StooClient) Doctor(ctx context.Context) *DoctorReport {
+	cfg := c.CurrentConfig()
+	clock := cfg.GetClock()
+	report := c.Diagnose(ctx)
+
+	opts := []CallOption{WithContext(ctx)}
+	start := clock.Now()
+	if err := c.roundTrip(opts); err != nil {
+		report.add("round-trip", start, clock.Now(), DoctorFail, err.Error())
+	} else {
+		report.add("round-trip", start, clock.Now(), DoctorOK, fmt.Sprintf("set, get and delete in %s/%s", DoctorNamespace, doctorProfile))
+	}
+
+	start = clock.Now()
+	var worst, total time.Duration
+	for i := 0; i < latencySamples; i++ {
+		callStart := clock.Now()
+		if _, err := c.Get(DoctorNamespace, doctorProfile, healthCheckKey, opts...); err != nil && !isNotFound(err) {
+			report.add("latency", start, clock.Now(), DoctorFail, err.Error())
+			return report
+		}
+		elapsed := clock.Now().Sub(callStart)
+		total += elapsed
+		if elapsed > worst {
+			worst = elapsed
+		}
+	}
+	detail := fmt.Sprintf("%d reads, avg %s, max %s", latencySamples, (total / latencySamples).Round(time.Microsecond), worst.Round(time.Microsecond))
+	if worst > cfg.GetReadTimeout()/2 {
+		report.add("latency", start, clock.Now(), DoctorWarn, detail+", above half the read timeout")
+	} else {
+		report.add("latency", start, clock.Now(), DoctorOK, detail)
+	}
+	return report
+}
+
+// isNotFound tells if err reports a missing key.
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrKeyNotFound) || status.Code(err) == codes.NotFound
+}
+
+// roundTrip writes, reads back and deletes a scratch key.
+func (c *StooClient) roundTrip(opts []CallOption) error {
+	now := c.CurrentConfig().GetClock().Now()
+	key := fmt.Sprintf("%sdoctor.%d", ReservedKeyPrefix, now.UnixNano())
+	want := now.UTC().Format(time.RFC3339Nano)
+	if _, err := c.Set(DoctorNamespace, doctorProfile, key, want, opts...); err != nil {
+		return fmt.Errorf("set: %w", err)
+	}
+	got, err := c.Get(DoctorNamespace, doctorProfile, key, opts...)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("get: read %q back instead of %q", got, want)
+	}
+	if _, err := c.Delete(DoctorNamespace, doctorProfile, key, opts...); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
+
+// endpointAddress returns the network and address to dial to reach the endpoint at addr, and
+// whether calls to it use TLS.
+func endpointAddress(cfg *config.StooConfig, addr string) (network, address string, useTls bool) {
+	if rest, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return "unix", strings.TrimPrefix(rest, "//"), false
+	}
+	if !strings.Contains(addr, "://") {
+		return "tcp", addr, cfg.GetUseTls()
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "tcp", addr, cfg.GetUseTls()
+	}
+	switch u.Scheme {
+	case "http", "https":
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+		return "tcp", net.JoinHostPort(u.Hostname(), port), u.Scheme == "https"
+	default:
+		// gRPC targets such as dns:///host:port.
+		return "tcp", strings.TrimPrefix(u.Path, "/"), cfg.GetUseTls()
+	}
+}
+
+// checkFallback checks the fallback file, if configured, can be loaded. A file which can't be
+// loaded is neither served nor replaced, so it fails the check.
+func (c *StooClient) checkFallback() (DoctorStatus, string) {
+	cfg := c.CurrentConfig()
+	path := cfg.GetFallbackFile()
+	if path == "" {
+		return DoctorSkip, "no fallback file configured"
+	}
+	f := &c.fallback
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.load(path, cfg.GetFallbackEncrypter())
+	switch {
+	case errors.Is(err, errPlainFallback):
+		return DoctorWarn, fmt.Sprintf("%s: %v, it is replaced on the next save", path, err)
+	case err != nil:
+		return DoctorFail, fmt.Sprintf("%s: %v, it is neither served nor replaced until fixed or removed", path, err)
+	}
+	return DoctorOK, fmt.Sprintf("%s: %d namespaces saved", path, len(f.profiles))
+}
+
+// checkDNS resolves the host of addr.
+func (c *StooClient) checkDNS(ctx context.Context, addr string) (DoctorStatus, string) {
+	cfg := c.CurrentConfig()
+	network, address, _ := endpointAddress(cfg, addr)
+	if network == "unix" {
+		return DoctorSkip, fmt.Sprintf("%s: Unix socket", addr)
+	}
+	if cfg.GetProxy() != "" {
+		return DoctorSkip, fmt.Sprintf("%s: resolved by the proxy", addr)
+	}
+	if cfg.GetContextDialer() != nil {
+		return DoctorSkip, fmt.Sprintf("%s: resolved by the context dialer", addr)
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return DoctorFail, fmt.Sprintf("%s: %v", addr, err)
+	}
+	if net.ParseIP(host) != nil {
+		return DoctorOK, fmt.Sprintf("%s: %s is an IP address", addr, host)
+	}
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return DoctorFail, fmt.Sprintf("%s: %v", addr, err)
+	}
+	return DoctorOK, fmt.Sprintf("%s resolves to %s", host, strings.Join(ips, ", "))
+}
+
+// checkTCP opens a connection to addr, see dialEndpoint.
+func (c *StooClient) checkTCP(ctx context.Context, addr string) (DoctorStatus, string) {
+	conn, err := c.dialEndpoint(ctx, addr)
+	if err != nil {
+		return DoctorFail, fmt.Sprintf("%s: %v", addr, err)
+	}
+	defer conn.Close()
+	return DoctorOK, fmt.Sprintf("%s: connected to %s", addr, conn.RemoteAddr())
+}
+
+// dialEndpoint opens a connection to the endpoint at addr with the configured dialer, or through
+// the proxy if one is configured.
+func (c *StooClient) dialEndpoint(ctx context.Context, addr string) (net.Conn, error) {
+	cfg := c.CurrentConfig()
+	network, address, _ := endpointAddress(cfg, addr)
+	if dialer := cfg.GetContextDialer(); dialer != nil {
+		return dialer(ctx, address)
+	}
+	if network == "tcp" && cfg.GetProxy() != "" {
+		dialer, err := proxyDialer(cfg.GetProxy())
+		if err != nil {
+			return nil, err
+		}
+		return dialer(ctx, address)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, network, address)
+}
+
+// checkTLS performs a TLS handshake with addr using the configured TLS settings, adds the
+// certificates presented by the server and the client certificate to report and reports
+// their expiry.
+func (c *StooClient) checkTLS(ctx context.Context, addr string, report *DoctorReport) (DoctorStatus, string) {
+	cfg := c.CurrentConfig()
+	_, address, useTls := endpointAddress(cfg, addr)
+	if !useTls {
+		return DoctorSkip, fmt.Sprintf("%s: TLS disabled", addr)
+	}
+	tlsConfig, err := clientTLSConfig(cfg, cfg.GetTls())
+	if err != nil {
+		return DoctorFail, err.Error()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(address)
+	}
+
+	conn, err := c.dialEndpoint(ctx, addr)
+	if err != nil {
+		return DoctorFail, fmt.Sprintf("%s: %v", addr, err)
+	}
+	defer conn.Close()
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return DoctorFail, fmt.Sprintf("%s: %v", addr, err)
+	}
+
+	now := cfg.GetClock().Now()
+	state := tlsConn.ConnectionState()
+	result := DoctorOK
+	details := []string{addr, tls.VersionName(state.Version), fmt.Sprintf("chain of %d", len(state.PeerCertificates))}
+	var certs []namedCert
+	for _, cert := range state.PeerCertificates {
+		certs = append(certs, namedCert{name: "server", cert: cert})
+	}
+	if t := cfg.GetTls(); t != nil {
+		if cert, err := clientCertificate(t); err == nil && cert != nil {
+			if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+				certs = append(certs, namedCert{name: "client", cert: leaf})
+			}
+		}
+	}
+	for i, cert := range certs {
+		report.Certificates = append(report.Certificates, DoctorCertificate{
+			Endpoint:  addr,
+			Role:      cert.name,
+			Subject:   cert.cert.Subject.String(),
+			Issuer:    cert.cert.Issuer.String(),
+			DNSNames:  cert.cert.DNSNames,
+			NotBefore: cert.cert.NotBefore,
+			NotAfter:  cert.cert.NotAfter,
+		})
+		// Only the leaf of the chain is reported in the detail, the full chain is in report.
+		if cert.name == "server" && i > 0 {
+			continue
+		}
+		left := cert.cert.NotAfter.Sub(now)
+		switch {
+		case left <= 0:
+			result = DoctorFail
+			details = append(details, fmt.Sprintf("%s certificate expired on %s", cert.name, cert.cert.NotAfter.Format(time.RFC3339)))
+		case left < certExpiryWarning:
+			if result == DoctorOK {
+				result = DoctorWarn
+			}
+			details = append(details, fmt.Sprintf("%s certificate expires soon, on %s", cert.name, cert.cert.NotAfter.Format(time.RFC3339)))
+		default:
+			details = append(details, fmt.Sprintf("%s certificate valid until %s", cert.name, cert.cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	return result, strings.Join(details, ", ")
+}
+
+// namedCert certificate along with the role it plays.
+type namedCert struct {
+	name string
+	cert *x509.Certificate
+}
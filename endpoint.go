@@ -0,0 +1,229 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// healthDecay weight of the latest call in the moving averages of an endpoint.
+	healthDecay = 0.2
+	// ejectionErrorRate error rate above which an endpoint is ejected.
+	ejectionErrorRate = 0.5
+	// ejectionMinRequests calls an endpoint must have served before it can be ejected.
+	ejectionMinRequests = 5
+	// ejectionDuration time an ejected endpoint stops receiving calls, unless all are ejected.
+	ejectionDuration = 30 * time.Second
+)
+
+// endpoint connection to one StooKV endpoint and its health.
+type endpoint struct {
+	addr string
+	conn *grpc.ClientConn
+	kv   proto.KVServiceClient
+
+	mu           sync.Mutex
+	requests     uint64
+	errors       uint64
+	latency      time.Duration
+	errorRate    float64
+	ejectedUntil time.Time
+}
+
+// EndpointStats health of an endpoint as seen by the client.
+type EndpointStats struct {
+	// Address endpoint address.
+	Address string
+	// Requests calls made to the endpoint.
+	Requests uint64
+	// Errors calls which failed because of the endpoint, e.g. unavailable or timed out.
+	Errors uint64
+	// Latency moving average of call latency.
+	Latency time.Duration
+	// ErrorRate moving average of the error rate, between 0 and 1.
+	ErrorRate float64
+	// Score routing score, lower is better.
+	Score float64
+	// Ejected tells if the endpoint is temporarily excluded from routing for failing too often.
+	Ejected bool
+	// State connection state, see WatchState.
+	State connectivity.State
+}
+
+// Stats statistics of a client.
+type Stats struct {
+	// Endpoints health of every configured endpoint.
+	Endpoints []EndpointStats
+}
+
+// Stats returns statistics of the client, such as the health of its endpoints.
+func (c *StooClient) Stats() Stats {
+	now := c.CurrentConfig().GetClock().Now()
+	stats := Stats{}
+	for _, e := range c.endpoints {
+		stats.Endpoints = append(stats.Endpoints, e.stats(now))
+	}
+	return stats
+}
+
+// dialEndpoints creates connections to every configured endpoint, calls going through
+// interceptors before reaching the endpoint.
+func dialEndpoints(cfg *config.StooConfig, useTls bool, t *config.TLS, interceptors ...grpc.UnaryClientInterceptor) ([]*endpoint, error) {
+	var endpoints []*endpoint
+	for _, addr := range cfg.GetEndpoints() {
+		e := &endpoint{addr: addr}
+		chain := append(append([]grpc.UnaryClientInterceptor(nil), interceptors...), e.interceptor(cfg.GetClock()))
+		if cfg.GetTransport() == config.TransportHTTP {
+			conn, err := newGRPCWebConn(cfg, addr, t, chain...)
+			if err != nil {
+				return nil, err
+			}
+			e.kv = proto.NewKVServiceClient(conn)
+			endpoints = append(endpoints, e)
+			continue
+		}
+		conn, err := dial(cfg, addr, useTls, t, chain...)
+		if err != nil {
+			return nil, err
+		}
+		e.conn = conn
+		e.kv = proto.NewKVServiceClient(conn)
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
+// pick returns the endpoint to be used for a call made on namespace. Two endpoints are drawn
+// at random and the one with the better score wins, skipping ejected endpoints unless all are.
+func (c *StooClient) pick(namespace string) *endpoint {
+	return c.pickFrom(c.endpointsFor(namespace))
+}
+
+// pickFrom returns the endpoint to be used for a call among endpoints, see pick.
+func (c *StooClient) pickFrom(endpoints []*endpoint) *endpoint {
+	if len(endpoints) == 1 {
+		return endpoints[0]
+	}
+
+	now := c.CurrentConfig().GetClock().Now()
+	healthy := make([]*endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		if !e.ejected(now) {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = endpoints
+	}
+	if len(healthy) == 1 {
+		return healthy[0]
+	}
+	a := healthy[rand.Intn(len(healthy))]
+	b := healthy[rand.Intn(len(healthy))]
+	if b.score() < a.score() {
+		return b
+	}
+	return a
+}
+
+// endpointsFor returns the endpoints serving calls made on namespace.
+func (c *StooClient) endpointsFor(namespace string) []*endpoint {
+	if namespaced, ok := c.namespaceEndpoints[namespace]; ok {
+		return namespaced
+	}
+	return c.endpoints
+}
+
+// interceptor records the outcome of every call made through the endpoint.
+func (e *endpoint) interceptor(clock config.Clock) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := clock.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		end := clock.Now()
+		e.record(end, end.Sub(start), isEndpointError(ctx, err))
+		return err
+	}
+}
+
+// record updates the health of the endpoint with the outcome of a call.
+func (e *endpoint) record(now time.Time, latency time.Duration, failed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	failure := 0.0
+	if failed {
+		e.errors++
+		failure = 1
+	}
+	if e.requests == 1 {
+		e.latency = latency
+		e.errorRate = failure
+	} else {
+		e.latency = time.Duration(healthDecay*float64(latency) + (1-healthDecay)*float64(e.latency))
+		e.errorRate = healthDecay*failure + (1-healthDecay)*e.errorRate
+	}
+	if e.requests >= ejectionMinRequests && e.errorRate > ejectionErrorRate && !now.Before(e.ejectedUntil) {
+		e.ejectedUntil = now.Add(ejectionDuration)
+	}
+}
+
+// score returns the routing score of the endpoint, latency penalized by errors, lower is better.
+func (e *endpoint) score() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return float64(e.latency) * (1 + 10*e.errorRate)
+}
+
+// ejected tells if the endpoint is excluded from routing at now.
+func (e *endpoint) ejected(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return now.Before(e.ejectedUntil)
+}
+
+// stats returns the health of the endpoint at now.
+func (e *endpoint) stats(now time.Time) EndpointStats {
+	score := e.score()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return EndpointStats{
+		Address:   e.addr,
+		Requests:  e.requests,
+		Errors:    e.errors,
+		Latency:   e.latency,
+		ErrorRate: e.errorRate,
+		Score:     score,
+		Ejected:   now.Before(e.ejectedUntil),
+		State:     e.state(),
+	}
+}
+
+// state returns the connection state of the endpoint, connectivity.Shutdown if it has no connection,
+// as over config.TransportHTTP.
+func (e *endpoint) state() connectivity.State {
+	if e.conn == nil {
+		return connectivity.Shutdown
+	}
+	return e.conn.GetState()
+}
+
+// isEndpointError tells if err is caused by the endpoint rather than by the request, calls
+// running out of a budget shortened by the caller's deadline not counting.
+func isEndpointError(ctx context.Context, err error) bool {
+	if callerDeadlineExceeded(ctx, err) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package stogo_test
+
+import (
+	"context"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+// failingServer fails every read with code.
+type failingServer struct {
+	proto.UnimplementedKVServiceServer
+	code codes.Code
+}
+
+func (s *failingServer) GetService(context.Context, *proto.GetRequest) (*proto.GetResponse, error) {
+	return nil, status.Error(s.code, "failing replica")
+}
+
+func TestEndpointEjection(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        codes.Code
+		wantEjected bool
+	}{
+		{"unavailable replica", codes.Unavailable, true},
+		{"replica timing out", codes.DeadlineExceeded, true},
+		{"request error", codes.NotFound, false},
+		{"permission error", codes.PermissionDenied, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := grpc.NewServer()
+			proto.RegisterKVServiceServer(server, &failingServer{code: tt.code})
+			go server.Serve(lis)
+			defer server.Stop()
+
+			if _, err := stogo.NewStoreClient(srv.Config()).Set("app", "prod", "key", "value"); err != nil {
+				t.Fatal(err)
+			}
+			clock := stogotest.NewFakeClock(time.Now())
+			client := stogo.NewStoreClient(srv.Config().WithEndpoints(lis.Addr().String()).WithClock(clock))
+			for i := 0; i < 40; i++ {
+				client.Get("app", "prod", "key")
+			}
+			failing := client.Stats().Endpoints[1]
+			if failing.Ejected != tt.wantEjected {
+				t.Fatalf("got ejected %v after %d calls and %d errors, want %v", failing.Ejected, failing.Requests, failing.Errors, tt.wantEjected)
+			}
+			if !tt.wantEjected {
+				if failing.Errors != 0 {
+					t.Fatalf("got %d endpoint errors, want none", failing.Errors)
+				}
+				return
+			}
+
+			for i := 0; i < 20; i++ {
+				if _, err := client.Get("app", "prod", "key"); err != nil {
+					t.Fatalf("call routed to the ejected endpoint: %v", err)
+				}
+			}
+			clock.Advance(30 * time.Second)
+			if client.Stats().Endpoints[1].Ejected {
+				t.Fatal("endpoint still ejected once the ejection expired")
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package envelope
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+)
+
+// maxCachedKeys max number of unwrapped data keys kept by a Chain, oldest are evicted first.
+const maxCachedKeys = 256
+
+// ProviderStats counters of a provider of a Chain.
+type ProviderStats struct {
+	// Wraps data keys wrapped.
+	Wraps uint64
+	// Unwraps data keys unwrapped.
+	Unwraps uint64
+	// Failures failed wraps and unwraps, including unknown keys.
+	Failures uint64
+	// CacheHits decryptions served from a data key previously unwrapped by the provider.
+	CacheHits uint64
+}
+
+// Chain encrypts with its first provider and decrypts by trying each provider in order,
+// caching unwrapped data keys.
+type Chain struct {
+	providers []KeyProvider
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+	order []string
+	stats map[string]*ProviderStats
+}
+
+// cachedKey unwrapped data key and the provider which unwrapped it.
+type cachedKey struct {
+	provider string
+	key      []byte
+}
+
+// NewChain creates a Chain. New values are encrypted with the first provider.
+//
+// Usage example:
+//
+//	local, err := envelope.NewLocalProvider("2024-01", key)
+//	if err != nil {
+//		log.Fatalf("Error creating local provider %v", err)
+//	}
+//	chain := envelope.NewChain(local, envelope.NewKMSProvider("kms", kmsClient, "alias/stookv"))
+func NewChain(providers ...KeyProvider) *Chain {
+	stats := make(map[string]*ProviderStats, len(providers))
+	for _, p := range providers {
+		stats[p.Name()] = &ProviderStats{}
+	}
+	return &Chain{
+		providers: providers,
+		cache:     make(map[string]cachedKey),
+		stats:     stats,
+	}
+}
+
+// Encrypt encrypts plaintext under a new data key wrapped by the first provider.
+func (c *Chain) Encrypt(plaintext []byte) (string, error) {
+	return c.encrypt(plaintext, nil)
+}
+
+// EncryptWithContext encrypts plaintext like Encrypt, binding aad into the ciphertext, e.g. the
+// namespace, profile and key the value is stored under. The value can then only be decrypted
+// by DecryptWithContext with the same aad, so that it can't be moved elsewhere undetected.
+//
+// Usage example:
+//
+//	value, err := chain.EncryptWithContext([]byte("s3cr3t"), []byte("my-app/prod/database.password"))
+func (c *Chain) EncryptWithContext(plaintext, aad []byte) (string, error) {
+	if aad == nil {
+		aad = []byte{}
+	}
+	return c.encrypt(plaintext, aad)
+}
+
+// Decrypt decrypts a value produced by Encrypt. Values bound to an encryption context fail
+// with ErrContextMismatch.
+func (c *Chain) Decrypt(value string) ([]byte, error) {
+	return c.decrypt(value, nil)
+}
+
+// DecryptWithContext decrypts a value produced by EncryptWithContext, failing with
+// ErrContextMismatch if aad differs from the one it was encrypted with. Values produced by
+// Encrypt are decrypted as they are, so that values written before contexts were used stay readable.
+func (c *Chain) DecryptWithContext(value string, aad []byte) ([]byte, error) {
+	if aad == nil {
+		aad = []byte{}
+	}
+	return c.decrypt(value, aad)
+}
+
+// encrypt encrypts plaintext, binding aad unless nil.
+func (c *Chain) encrypt(plaintext, aad []byte) (string, error) {
+	if len(c.providers) == 0 {
+		return "", ErrNoProvider
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	provider := c.providers[0]
+	keyID, wrapped, err := provider.WrapKey(dataKey)
+	c.count(provider.Name(), func(s *ProviderStats) {
+		if err != nil {
+			s.Failures++
+		} else {
+			s.Wraps++
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	nonce, ciphertext, err := seal(dataKey, plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+	return encode(&header{KeyID: keyID, WrappedKey: wrapped, Nonce: nonce, Ciphertext: ciphertext, Bound: aad != nil})
+}
+
+// decrypt decrypts value, checking aad if value is bound to a context.
+func (c *Chain) decrypt(value string, aad []byte) ([]byte, error) {
+	h, err := decode(value)
+	if err != nil {
+		return nil, err
+	}
+	if h.Bound && aad == nil {
+		return nil, ErrContextMismatch
+	}
+	dataKey, err := c.unwrap(h)
+	if err != nil {
+		return nil, err
+	}
+	if !h.Bound {
+		return open(dataKey, h.Nonce, h.Ciphertext, nil)
+	}
+	plaintext, err := open(dataKey, h.Nonce, h.Ciphertext, aad)
+	if err != nil {
+		return nil, ErrContextMismatch
+	}
+	return plaintext, nil
+}
+
+// Stats returns a copy of the counters of every provider, keyed by provider name.
+func (c *Chain) Stats() map[string]ProviderStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make(map[string]ProviderStats, len(c.stats))
+	for name, s := range c.stats {
+		stats[name] = *s
+	}
+	return stats
+}
+
+// unwrap returns the data key of h from the cache or from the first provider able to unwrap it.
+func (c *Chain) unwrap(h *header) ([]byte, error) {
+	cacheKey := h.KeyID + "\x00" + string(h.WrappedKey)
+	c.mu.Lock()
+	if cached, ok := c.cache[cacheKey]; ok {
+		c.stats[cached.provider].CacheHits++
+		c.mu.Unlock()
+		return cached.key, nil
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for _, provider := range c.providers {
+		dataKey, err := provider.UnwrapKey(h.KeyID, h.WrappedKey)
+		if err != nil {
+			c.count(provider.Name(), func(s *ProviderStats) { s.Failures++ })
+			errs = append(errs, err)
+			continue
+		}
+		c.mu.Lock()
+		c.stats[provider.Name()].Unwraps++
+		c.remember(cacheKey, cachedKey{provider: provider.Name(), key: dataKey})
+		c.mu.Unlock()
+		return dataKey, nil
+	}
+	return nil, errors.Join(append([]error{ErrNoProvider}, errs...)...)
+}
+
+// remember caches an unwrapped data key, the caller must hold mu.
+func (c *Chain) remember(cacheKey string, key cachedKey) {
+	if _, ok := c.cache[cacheKey]; !ok {
+		c.order = append(c.order, cacheKey)
+	}
+	c.cache[cacheKey] = key
+	if len(c.order) > maxCachedKeys {
+		delete(c.cache, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// count updates the stats of a provider.
+func (c *Chain) count(provider string, fn func(*ProviderStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(c.stats[provider])
+}
@@ -0,0 +1,71 @@
+package envelope_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/mwangox/stogo/envelope"
+	"testing"
+)
+
+func TestChainDecrypt(t *testing.T) {
+	current, err := envelope.NewLocalProvider("2024", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := envelope.NewLocalProvider("2023", bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := envelope.NewLocalProvider("2023", bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envelope.NewLocalProvider("short", make([]byte, 16)); !errors.Is(err, envelope.ErrInvalidKeySize) {
+		t.Fatalf("got error %v, want %v", err, envelope.ErrInvalidKeySize)
+	}
+
+	old, err := envelope.NewChain(previous).Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound, err := envelope.NewChain(current).EncryptWithContext([]byte("secret"), []byte("app/prod/db.password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		chain   *envelope.Chain
+		value   string
+		aad     []byte
+		wantErr []error
+	}{
+		{"rotated key", envelope.NewChain(current, previous), old, nil, nil},
+		{"bound value", envelope.NewChain(current), bound, []byte("app/prod/db.password"), nil},
+		{"plain value", envelope.NewChain(current), "secret", nil, []error{envelope.ErrNotEncrypted}},
+		{"malformed value", envelope.NewChain(current), envelope.Prefix + "!!!", nil, []error{envelope.ErrMalformed}},
+		{"unknown key", envelope.NewChain(current), old, nil, []error{envelope.ErrNoProvider, envelope.ErrUnknownKey}},
+		{"wrong key", envelope.NewChain(forged), old, nil, []error{envelope.ErrNoProvider}},
+		{"bound value without context", envelope.NewChain(current), bound, nil, []error{envelope.ErrContextMismatch}},
+		{"bound value of another key", envelope.NewChain(current), bound, []byte("app/prod/api.token"), []error{envelope.ErrContextMismatch}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var plaintext []byte
+			var err error
+			if tt.aad != nil {
+				plaintext, err = tt.chain.DecryptWithContext(tt.value, tt.aad)
+			} else {
+				plaintext, err = tt.chain.Decrypt(tt.value)
+			}
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("got error %v, want %v", err, want)
+				}
+			}
+			if tt.wantErr == nil && (err != nil || string(plaintext) != "secret") {
+				t.Errorf("got %q, %v, want %q", plaintext, err, "secret")
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Package envelope implements envelope encryption of values: each value is encrypted with
+// its own random data key using AES-256-GCM and the data key is wrapped by a KeyProvider,
+// such as a local key or a KMS. Decryption goes through a Chain of providers so values
+// written under different keys stay readable while keys are being migrated.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Prefix starts every value produced by Chain.Encrypt.
+const Prefix = "stogo:enc:v1:"
+
+// dataKeySize size in bytes of data keys, AES-256.
+const dataKeySize = 32
+
+// ErrNotEncrypted thrown when decrypting a value not produced by Chain.Encrypt.
+var ErrNotEncrypted = errors.New("envelope: value is not encrypted")
+
+// ErrNoProvider thrown when no provider of a chain could unwrap the data key of a value.
+var ErrNoProvider = errors.New("envelope: no provider could unwrap the data key")
+
+// ErrUnknownKey thrown by providers asked to unwrap a data key wrapped by a key they don't hold.
+var ErrUnknownKey = errors.New("envelope: unknown key")
+
+// ErrContextMismatch thrown when decrypting a value bound to an encryption context with
+// another context, e.g. a value copied to another key, or a value tampered with.
+var ErrContextMismatch = errors.New("envelope: value is bound to another encryption context")
+
+// ErrMalformed thrown when an encrypted value can't be parsed.
+var ErrMalformed = errors.New("envelope: malformed value")
+
+// KeyProvider wraps and unwraps data keys.
+type KeyProvider interface {
+	// Name identifies the provider in stats.
+	Name() string
+	// WrapKey encrypts dataKey, returning the id of the key used and the wrapped data key.
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts a data key wrapped under keyID. Providers not holding keyID
+	// return ErrUnknownKey.
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// header encoded form of an encrypted value.
+type header struct {
+	KeyID      string `json:"k"`
+	WrappedKey []byte `json:"w"`
+	Nonce      []byte `json:"n"`
+	Ciphertext []byte `json:"c"`
+	// Bound tells the ciphertext is bound to additional authenticated data, see Chain.EncryptWithContext.
+	Bound bool `json:"b,omitempty"`
+}
+
+// IsEncrypted tells if value was produced by Chain.Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// encode serializes h into an encrypted value.
+func encode(h *header) (string, error) {
+	raw, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decode parses an encrypted value.
+func decode(value string) (*header, error) {
+	if !IsEncrypted(value) {
+		return nil, ErrNotEncrypted
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	h := &header{}
+	if err := json.Unmarshal(raw, h); err != nil {
+		return nil, ErrMalformed
+	}
+	return h, nil
+}
+
+// seal encrypts plaintext with key using AES-GCM and a random nonce, authenticating aad.
+func seal(key, plaintext, aad []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// open decrypts ciphertext sealed with key, nonce and aad.
+func open(key, nonce, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+// newGCM creates an AES-GCM AEAD from key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,123 @@
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrInvalidKeySize thrown when a local key is not 32 bytes long.
+var ErrInvalidKeySize = errors.New("envelope: local key must be 32 bytes long")
+
+// LocalProvider wraps data keys with a local AES-256 key.
+type LocalProvider struct {
+	keyID string
+	key   []byte
+}
+
+// NewLocalProvider creates a LocalProvider from a 32 bytes key identified by keyID.
+func NewLocalProvider(keyID string, key []byte) (*LocalProvider, error) {
+	if len(key) != dataKeySize {
+		return nil, ErrInvalidKeySize
+	}
+	return &LocalProvider{keyID: keyID, key: key}, nil
+}
+
+// Name returns local:<keyID>.
+func (p *LocalProvider) Name() string {
+	return "local:" + p.keyID
+}
+
+// WrapKey implements KeyProvider.
+func (p *LocalProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	nonce, ciphertext, err := seal(p.key, dataKey, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.keyID, append(nonce, ciphertext...), nil
+}
+
+// UnwrapKey implements KeyProvider.
+func (p *LocalProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	if keyID != p.keyID {
+		return nil, ErrUnknownKey
+	}
+	gcm, err := newGCM(p.key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return open(p.key, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], nil)
+}
+
+// KMS key management service able to encrypt and decrypt small payloads with keys it holds.
+// Adapt the SDK of your cloud provider to this interface to use it with NewKMSProvider.
+type KMS interface {
+	Encrypt(ctx context.Context, keyID string, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// KMSProvider wraps data keys with a KMS key.
+type KMSProvider struct {
+	name  string
+	kms   KMS
+	keyID string
+}
+
+// NewKMSProvider creates a KMSProvider wrapping new data keys with keyID. Data keys wrapped
+// under other keys of the same KMS are unwrapped with the key id stored along the value.
+func NewKMSProvider(name string, kms KMS, keyID string) *KMSProvider {
+	return &KMSProvider{name: name, kms: kms, keyID: keyID}
+}
+
+// Name returns the provider name.
+func (p *KMSProvider) Name() string {
+	return p.name
+}
+
+// WrapKey implements KeyProvider.
+func (p *KMSProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	wrapped, err := p.kms.Encrypt(context.Background(), p.keyID, dataKey)
+	return p.keyID, wrapped, err
+}
+
+// UnwrapKey implements KeyProvider.
+func (p *KMSProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	return p.kms.Decrypt(context.Background(), keyID, wrapped)
+}
+
+// NewLocalProviderFromEnv creates a LocalProvider from a base64 encoded 32 bytes key held
+// in the environment variable envVar.
+func NewLocalProviderFromEnv(keyID, envVar string) (*LocalProvider, error) {
+	encoded, ok := os.LookupEnv(envVar)
+	if !ok {
+		return nil, fmt.Errorf("envelope: environment variable %s is not set", envVar)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: environment variable %s: %w", envVar, err)
+	}
+	return NewLocalProvider(keyID, key)
+}
+
+// NewLocalProviderFromFile creates a LocalProvider from a file holding either the raw
+// 32 bytes key or its base64 encoding.
+func NewLocalProviderFromFile(keyID, path string) (*LocalProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == dataKeySize {
+		return NewLocalProvider(keyID, raw)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: key file %s: %w", path, err)
+	}
+	return NewLocalProvider(keyID, key)
+}
@@ -0,0 +1,69 @@
+package stogo
+
+import "time"
+
+// EventType kind of change of a key.
+type EventType int
+
+const (
+	// EventPut key was added or its value changed.
+	EventPut EventType = iota
+	// EventDelete key was removed.
+	EventDelete
+)
+
+// String returns put or delete.
+func (t EventType) String() string {
+	if t == EventDelete {
+		return "delete"
+	}
+	return "put"
+}
+
+// Event change of a single key detected while watching a namespace and profile.
+type Event struct {
+	Type      EventType
+	Namespace string
+	Profile   string
+	Key       string
+	// OldValue value before the change, empty for new keys.
+	OldValue string
+	// NewValue value after the change, empty for deleted keys.
+	NewValue string
+	// Timestamp time the change was detected.
+	Timestamp time.Time
+}
+
+// diffEvents returns the events turning old into new, sorted by key.
+func diffEvents(namespace, profile string, old, new map[string]string, now time.Time) []Event {
+	var events []Event
+	for _, key := range sortedKeys(new) {
+		oldValue, ok := old[key]
+		if ok && oldValue == new[key] {
+			continue
+		}
+		events = append(events, Event{
+			Type:      EventPut,
+			Namespace: namespace,
+			Profile:   profile,
+			Key:       key,
+			OldValue:  oldValue,
+			NewValue:  new[key],
+			Timestamp: now,
+		})
+	}
+	for _, key := range sortedKeys(old) {
+		if _, ok := new[key]; ok {
+			continue
+		}
+		events = append(events, Event{
+			Type:      EventDelete,
+			Namespace: namespace,
+			Profile:   profile,
+			Key:       key,
+			OldValue:  old[key],
+			Timestamp: now,
+		})
+	}
+	return events
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Environ converts values into NAME=value environment entries, in key order, with names built
+// by EnvName after prepending prefix to the key. Reserved keys written by stogo for its own
+// bookkeeping are left out.
+func Environ(values map[string]string, prefix string) []string {
+	env := make([]string, 0, len(values))
+	for _, key := range configKeys(values) {
+		env = append(env, EnvName(prefix+key)+"="+values[key])
+	}
+	return env
+}
+
+// MergeEnviron returns base with the entries of extra added, replacing entries of base with
+// the same name.
+func MergeEnviron(base, extra []string) []string {
+	replaced := make(map[string]bool, len(extra))
+	for _, entry := range extra {
+		name, _, _ := strings.Cut(entry, "=")
+		replaced[name] = true
+	}
+	merged := make([]string, 0, len(base)+len(extra))
+	for _, entry := range base {
+		name, _, _ := strings.Cut(entry, "=")
+		if !replaced[name] {
+			merged = append(merged, entry)
+		}
+	}
+	return append(merged, extra...)
+}
+
+// Exec runs the command args[0], looked up in PATH, with arguments args[1:] and environment
+// env, e.g. built with Environ and MergeEnviron, so that programs which can't talk to StooKV
+// get their configuration as environment variables. On Unix the command replaces the current
+// process, signals and exit code included, and Exec only returns on failure. Elsewhere the
+// command runs as a child process with the standard streams of the current one and Exec
+// returns once it exits, an *exec.ExitError if it failed.
+//
+// Usage example:
+//
+//	values, _ := client.GetAll("my-app", "prod")
+//	err := export.Exec([]string{"./server", "--port", "8080"}, export.MergeEnviron(os.Environ(), export.Environ(values, "")))
+//	log.Fatalf("Error running server %v", err)
+func Exec(args []string, env []string) error {
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+	return execve(path, args, env)
+}
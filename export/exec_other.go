@@ -0,0 +1,18 @@
+//go:build !unix
+
+package export
+
+import (
+	"os"
+	"os/exec"
+)
+
+// execve runs path as a child process and waits for it, as processes can't be replaced.
+func execve(path string, args []string, env []string) error {
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,53 @@
+package export_test
+
+import (
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/export"
+	"reflect"
+	"testing"
+)
+
+func TestEnviron(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "key order",
+			values: map[string]string{"server.port": "8080", "database.url": "postgres://db"},
+			want:   []string{"DATABASE_URL=postgres://db", "SERVER_PORT=8080"},
+		},
+		{
+			name:   "prefix",
+			values: map[string]string{"port": "8080"},
+			prefix: "app.",
+			want:   []string{"APP_PORT=8080"},
+		},
+		{
+			name: "reserved keys",
+			values: map[string]string{
+				"port":                               "8080",
+				stogo.ReservedKeyPrefix + "ttl.port": "1700000000",
+				stogo.ReservedKeyPrefix + "override.port": `{"original":"80"}`,
+			},
+			want: []string{"PORT=8080"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := export.Environ(tt.values, tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Environ() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeEnviron(t *testing.T) {
+	got := export.MergeEnviron([]string{"HOME=/root", "PORT=80"}, []string{"PORT=8080", "DEBUG=true"})
+	want := []string{"HOME=/root", "PORT=8080", "DEBUG=true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeEnviron() = %v, want %v", got, want)
+	}
+}
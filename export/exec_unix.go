@@ -0,0 +1,10 @@
+//go:build unix
+
+package export
+
+import "syscall"
+
+// execve replaces the current process with path.
+func execve(path string, args []string, env []string) error {
+	return syscall.Exec(path, args, env)
+}
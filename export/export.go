@@ -0,0 +1,120 @@
+// Package export converts StooKV key value pairs into formats consumed by tools and
+// processes that can't talk to StooKV directly.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvName converts a key into an environment variable name by upper casing it and replacing
+// every character other than letters, digits and underscores with an underscore,
+// e.g. database.username becomes DATABASE_USERNAME.
+func EnvName(key string) string {
+	var b strings.Builder
+	for i, r := range strings.ToUpper(key) {
+		switch {
+		case r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// EnvironmentFile writes values in systemd EnvironmentFile format, one double quoted
+// NAME="value" assignment per key, in key order. Names are built with EnvName after
+// prepending prefix to the key. Reserved keys written by stogo for its own bookkeeping are
+// left out.
+func EnvironmentFile(w io.Writer, values map[string]string, prefix string) error {
+	bw := bufio.NewWriter(w)
+	for _, key := range configKeys(values) {
+		if _, err := fmt.Fprintf(bw, "%s=\"%s\"\n", EnvName(prefix+key), escapeEnvValue(values[key])); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// SystemdCredentials writes every value to its own file named after the key in dir, readable
+// by the owner only, to be loaded with the LoadCredential= setting of a systemd unit. Reserved
+// keys written by stogo for its own bookkeeping are left out.
+func SystemdCredentials(dir string, values map[string]string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for _, key := range configKeys(values) {
+		value := values[key]
+		if strings.ContainsAny(key, `/\`) {
+			return fmt.Errorf("export: key %q can't be used as a credential name", key)
+		}
+		if err := WriteFileAtomic(filepath.Join(dir, key), []byte(value), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it over path, so
+// readers never see a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// escapeEnvValue escapes characters which are special within double quotes of an EnvironmentFile.
+func escapeEnvValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(value)
+}
+
+// reservedKeyPrefix mirrors stogo.ReservedKeyPrefix, which this package can't import, for
+// keys written by stogo for its own bookkeeping such as TTL and override records.
+const reservedKeyPrefix = "__stogo."
+
+// sortedKeys returns the keys of values in ascending order.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configKeys returns the keys of values in ascending order, leaving out reserved keys which
+// are not configuration.
+func configKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for _, key := range sortedKeys(values) {
+		if !strings.HasPrefix(key, reservedKeyPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,115 @@
+package export_test
+
+import (
+	"bytes"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/export"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "database.username", want: "DATABASE_USERNAME"},
+		{key: "server-port", want: "SERVER_PORT"},
+		{key: "9lives", want: "_9LIVES"},
+		{key: "already_UPPER", want: "ALREADY_UPPER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := export.EnvName(tt.key); got != tt.want {
+				t.Errorf("EnvName(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		prefix string
+		want   string
+	}{
+		{
+			name:   "key order",
+			values: map[string]string{"server.port": "8080", "database.url": "postgres://db"},
+			want:   "DATABASE_URL=\"postgres://db\"\nSERVER_PORT=\"8080\"\n",
+		},
+		{
+			name:   "escaping",
+			values: map[string]string{"motd": "say \"hi\" to $USER `now` \\o/"},
+			prefix: "app.",
+			want:   "APP_MOTD=\"say \\\"hi\\\" to \\$USER \\`now\\` \\\\o/\"\n",
+		},
+		{
+			name: "reserved keys",
+			values: map[string]string{
+				"port":                               "8080",
+				stogo.ReservedKeyPrefix + "ttl.port": "1700000000",
+			},
+			want: "PORT=\"8080\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := export.EnvironmentFile(&buf, tt.values, tt.prefix); err != nil {
+				t.Fatalf("EnvironmentFile() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("EnvironmentFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemdCredentials(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "credentials")
+	values := map[string]string{
+		"database.password": "s3cret",
+		"api.token":         "t0ken",
+		stogo.ReservedKeyPrefix + "ttl.api.token": "1700000000",
+	}
+	if err := export.SystemdCredentials(dir, values); err != nil {
+		t.Fatalf("SystemdCredentials() error = %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s has permissions %v, want 0600", entry.Name(), perm)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != values[entry.Name()] {
+			t.Errorf("%s = %q, want %q", entry.Name(), data, values[entry.Name()])
+		}
+	}
+	sort.Strings(names)
+	if want := []string{"api.token", "database.password"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("credentials = %v, want %v", names, want)
+	}
+}
+
+func TestSystemdCredentialsInvalidKey(t *testing.T) {
+	if err := export.SystemdCredentials(t.TempDir(), map[string]string{"../escape": "x"}); err == nil {
+		t.Error("SystemdCredentials() error = nil, want an error for a key with a path separator")
+	}
+}
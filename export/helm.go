@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// helmInt values written as YAML integers, leading zeros excluded as YAML 1.1 reads them as octal.
+	helmInt = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	// helmFloat values written as YAML floats.
+	helmFloat = regexp.MustCompile(`^-?(0|[1-9][0-9]*)\.[0-9]+$`)
+	// helmPlainKey keys written without quotes.
+	helmPlainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// helmNode node of the values tree, either a scalar or a mapping.
+type helmNode struct {
+	value    *string
+	children map[string]*helmNode
+}
+
+// HelmValues writes values as a Helm values.yaml document. Dotted keys are nested, e.g.
+// database.pool.size becomes size under pool under database, and values are typed: true and
+// false become booleans, numbers become integers or floats and everything else a double quoted
+// string. Keys in which a prefix is both a value and a parent, such as database and
+// database.url, can't be represented and fail. Mappings are written in key order. Reserved
+// keys written by stogo for its own bookkeeping are left out.
+//
+// Usage example:
+//
+//	values, _ := client.GetAll("my-app", "prod")
+//	f, _ := os.Create("values-prod.yaml")
+//	defer f.Close()
+//	if err := export.HelmValues(f, values); err != nil {
+//		log.Fatalf("Error writing values %v", err)
+//	}
+func HelmValues(w io.Writer, values map[string]string) error {
+	root := &helmNode{children: map[string]*helmNode{}}
+	for _, key := range configKeys(values) {
+		if err := root.insert(key, strings.Split(key, "."), values[key]); err != nil {
+			return err
+		}
+	}
+	bw := bufio.NewWriter(w)
+	root.write(bw, 0)
+	return bw.Flush()
+}
+
+// insert adds value under path, key being the full key for errors.
+func (n *helmNode) insert(key string, path []string, value string) error {
+	for _, part := range path {
+		if part == "" {
+			return fmt.Errorf("export: key %q has an empty segment", key)
+		}
+	}
+	for i, part := range path {
+		child, ok := n.children[part]
+		last := i == len(path)-1
+		switch {
+		case !ok && last:
+			n.children[part] = &helmNode{value: &value}
+			return nil
+		case !ok:
+			child = &helmNode{children: map[string]*helmNode{}}
+			n.children[part] = child
+		case last || child.value != nil:
+			return fmt.Errorf("export: key %q conflicts with key %q", key, strings.Join(path[:i+1], "."))
+		}
+		n = child
+	}
+	return nil
+}
+
+// write writes the children of n indented by depth levels.
+func (n *helmNode) write(w *bufio.Writer, depth int) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	indent := strings.Repeat("  ", depth)
+	for _, name := range names {
+		child := n.children[name]
+		if child.value != nil {
+			fmt.Fprintf(w, "%s%s: %s\n", indent, helmKey(name), helmScalar(*child.value))
+			continue
+		}
+		fmt.Fprintf(w, "%s%s:\n", indent, helmKey(name))
+		child.write(w, depth+1)
+	}
+}
+
+// helmKey returns name as a YAML mapping key, quoted if it would otherwise read as another type.
+func helmKey(name string) string {
+	switch strings.ToLower(name) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return strconv.Quote(name)
+	}
+	if helmPlainKey.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// helmScalar returns value as a typed YAML scalar.
+func helmScalar(value string) string {
+	switch {
+	case value == "true", value == "false":
+		return value
+	case helmInt.MatchString(value), helmFloat.MatchString(value):
+		return value
+	}
+	return strconv.Quote(value)
+}
@@ -0,0 +1,65 @@
+package export_test
+
+import (
+	"bytes"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/export"
+	"testing"
+)
+
+func TestHelmValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "nesting and types",
+			values: map[string]string{
+				"database.pool.size": "10",
+				"database.url":       "postgres://db",
+				"debug":              "true",
+				"ratio":              "0.5",
+				"zip":                "0123",
+			},
+			want: "database:\n  pool:\n    size: 10\n  url: \"postgres://db\"\ndebug: true\nratio: 0.5\nzip: \"0123\"\n",
+		},
+		{
+			name:   "quoted keys",
+			values: map[string]string{"true": "x", "my key": "a\"b"},
+			want:   "\"my key\": \"a\\\"b\"\n\"true\": \"x\"\n",
+		},
+		{
+			name: "reserved keys",
+			values: map[string]string{
+				"port":                               "8080",
+				stogo.ReservedKeyPrefix + "ttl.port": "1700000000",
+				stogo.ReservedKeyPrefix + "override.port": `{"original":"80"}`,
+			},
+			want: "port: 8080\n",
+		},
+		{
+			name:    "value and parent",
+			values:  map[string]string{"database": "x", "database.url": "postgres://db"},
+			wantErr: true,
+		},
+		{
+			name:    "empty segment",
+			values:  map[string]string{"database..url": "postgres://db"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := export.HelmValues(&buf, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HelmValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && buf.String() != tt.want {
+				t.Errorf("HelmValues() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package stogo
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/export"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"sync"
+	"time"
+)
+
+// errPlainFallback thrown when loading a plain text fallback file while encryption is configured.
+var errPlainFallback = errors.New("file is not encrypted, ignoring it")
+
+// fallbackContext additional authenticated data binding encrypted fallback files to their use,
+// when the encrypter supports it.
+var fallbackContext = []byte("stogo fallback file")
+
+// fallbackProfile snapshot of a namespace and profile saved to the fallback file.
+type fallbackProfile struct {
+	SavedAt time.Time         `json:"savedAt"`
+	Values  map[string]string `json:"values"`
+}
+
+// fallbackStore in memory copy of the fallback file, keyed by namespace then profile. The zero
+// value is ready to use, the file is read on first use.
+type fallbackStore struct {
+	mu       sync.Mutex
+	path     string
+	profiles map[string]map[string]fallbackProfile
+}
+
+// load reads the file at path unless it is already loaded, decrypting it with encrypter if not
+// nil, the caller must hold mu. The store is only marked loaded once the file was read, so a
+// failed load is retried on next use, except for a plain text file found while encryption is
+// configured, which is ignored and replaced by the next save.
+func (f *fallbackStore) load(path string, encrypter config.Encrypter) error {
+	if f.profiles != nil && f.path == path {
+		return nil
+	}
+	profiles, err := readFallback(path, encrypter)
+	if errors.Is(err, errPlainFallback) {
+		f.path, f.profiles = path, make(map[string]map[string]fallbackProfile)
+		return err
+	}
+	if err != nil {
+		f.path, f.profiles = "", nil
+		return err
+	}
+	f.path, f.profiles = path, profiles
+	return nil
+}
+
+// readFallback reads the profiles saved in the file at path, none if it doesn't exist,
+// decrypting it with encrypter if not nil.
+func readFallback(path string, encrypter config.Encrypter) (map[string]map[string]fallbackProfile, error) {
+	profiles := make(map[string]map[string]fallbackProfile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if encrypter != nil {
+		plaintext, err := decryptFallback(encrypter, string(data))
+		if err != nil && json.Valid(data) {
+			return nil, errPlainFallback
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = plaintext
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// encryptFallback encrypts the content of a fallback file.
+func encryptFallback(encrypter config.Encrypter, data []byte) (string, error) {
+	if e, ok := encrypter.(config.ContextEncrypter); ok {
+		return e.EncryptWithContext(data, fallbackContext)
+	}
+	return encrypter.Encrypt(data)
+}
+
+// decryptFallback decrypts the content of a fallback file.
+func decryptFallback(encrypter config.Encrypter, data string) ([]byte, error) {
+	if e, ok := encrypter.(config.ContextEncrypter); ok {
+		return e.DecryptWithContext(data, fallbackContext)
+	}
+	return encrypter.Decrypt(data)
+}
+
+// saveFallback records values of a namespace and profile in the fallback file, if configured
+// and the values changed since the last save. Values read for a tenant are not saved, the file
+// holding the values of calls made without WithTenant only. Nothing is saved while the file
+// can't be loaded, so that the profiles it holds aren't overwritten.
+func (c *StooClient) saveFallback(tenant, namespace, profile string, values map[string]string) {
+	cfg := c.CurrentConfig()
+	path := cfg.GetFallbackFile()
+	if path == "" || tenant != "" {
+		return
+	}
+	f := &c.fallback
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(path, cfg.GetFallbackEncrypter()); err != nil {
+		cfg.GetLogger().Warnf("fallback file %s: %v", path, err)
+		if !errors.Is(err, errPlainFallback) {
+			return
+		}
+	}
+	if saved, ok := f.profiles[namespace][profile]; ok && equalValues(saved.Values, values) {
+		return
+	}
+	if f.profiles[namespace] == nil {
+		f.profiles[namespace] = make(map[string]fallbackProfile)
+	}
+	f.profiles[namespace][profile] = fallbackProfile{SavedAt: cfg.GetClock().Now(), Values: copyValues(values)}
+	data, err := json.Marshal(f.profiles)
+	if encrypter := cfg.GetFallbackEncrypter(); err == nil && encrypter != nil {
+		var encrypted string
+		encrypted, err = encryptFallback(encrypter, data)
+		data = []byte(encrypted)
+	}
+	if err == nil {
+		err = export.WriteFileAtomic(path, data, 0o600)
+	}
+	if err != nil {
+		cfg.GetLogger().Warnf("saving fallback file %s: %v", path, err)
+	}
+}
+
+// loadFallback returns the saved values of a namespace and profile when err tells StooKV is
+// unreachable and a fallback file is configured, for calls made without a tenant.
+func (c *StooClient) loadFallback(tenant, namespace, profile string, err error) (map[string]string, bool) {
+	cfg := c.CurrentConfig()
+	path := cfg.GetFallbackFile()
+	if path == "" || tenant != "" || !isUnreachable(err) {
+		return nil, false
+	}
+	f := &c.fallback
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(path, cfg.GetFallbackEncrypter()); err != nil {
+		cfg.GetLogger().Warnf("fallback file %s: %v", path, err)
+		return nil, false
+	}
+	saved, ok := f.profiles[namespace][profile]
+	if !ok {
+		return nil, false
+	}
+	cfg.GetLogger().Warnf("serving %s/%s from fallback file saved at %s: %v", namespace, profile, saved.SavedAt.Format(time.RFC3339), err)
+	return copyValues(saved.Values), true
+}
+
+// isUnreachable tells if err means StooKV could not be reached in time.
+func isUnreachable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// copyValues returns a copy of values.
+func copyValues(values map[string]string) map[string]string {
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,128 @@
+package stogo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/stogotest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFallbackFile(t *testing.T) {
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherProvider, err := envelope.NewLocalProvider("test", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := json.Marshal(map[string]map[string]any{
+		"app": {"prod": map[string]any{"savedAt": time.Now(), "values": map[string]string{"key": "saved"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKey, err := envelope.NewChain(otherProvider).Encrypt(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		content   []byte
+		encrypted bool
+		// preserved tells the unreadable file must be left as is by saves.
+		preserved bool
+		// check status of the fallback check of Diagnose after the save.
+		check stogo.DoctorStatus
+	}{
+		{"malformed JSON", []byte(`{"app": `), false, true, stogo.DoctorFail},
+		{"encrypted with another key", []byte(wrongKey), true, true, stogo.DoctorFail},
+		{"plain text while encryption is configured", saved, true, false, stogo.DoctorOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			path := filepath.Join(t.TempDir(), "fallback.json")
+			if err := os.WriteFile(path, tt.content, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg := srv.Config().WithFallbackFile(path).WithReadTimeout(time.Second)
+			if tt.encrypted {
+				cfg.WithFallbackEncryption(envelope.NewChain(provider))
+			}
+			client := stogo.NewStoreClient(cfg)
+			if _, err := client.Set("app", "staging", "key", "live"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.GetAll("app", "staging"); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if preserved := bytes.Equal(content, tt.content); preserved != tt.preserved {
+				t.Fatalf("got file preserved %v, want %v", preserved, tt.preserved)
+			}
+			var check stogo.DoctorCheck
+			for _, c := range client.Diagnose(context.Background()).Checks {
+				if c.Name == "fallback" {
+					check = c
+				}
+			}
+			if check.Status != tt.check {
+				t.Fatalf("got fallback check %q (%s), want %q", check.Status, check.Detail, tt.check)
+			}
+		})
+	}
+}
+
+func TestFallbackFileRetriesLoad(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "fallback.json")
+	cfg := srv.Config().WithFallbackFile(path).WithReadTimeout(time.Second)
+	writer := stogo.NewStoreClient(cfg)
+	if _, err := writer.Set("app", "prod", "key", "saved"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.GetAll("app", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	good, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := stogo.NewStoreClient(cfg)
+	if _, err := client.GetAll("app", "prod"); err == nil {
+		t.Fatal("got values from a malformed fallback file")
+	}
+	if err := os.WriteFile(path, good, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	values, err := client.GetAll("app", "prod")
+	if err != nil {
+		t.Fatalf("fallback file not loaded again: %v", err)
+	}
+	if values["key"] != "saved" {
+		t.Fatalf("got %q, want %q", values["key"], "saved")
+	}
+}
@@ -0,0 +1,211 @@
+package stogo
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// DefaultFileChunkSize size in bytes of the chunks files are split into if not specified.
+const DefaultFileChunkSize = 256 * 1024
+
+// ErrNotAFile thrown by GetFile when the key doesn't hold a file stored with PutFile.
+var ErrNotAFile = errors.New("key does not hold a file")
+
+// ErrChecksumMismatch thrown by GetFile when the content read doesn't match the stored checksum.
+var ErrChecksumMismatch = errors.New("file checksum mismatch")
+
+// FileManifest describes a file stored with PutFile. It is the value of the file key while
+// the content is kept base64 encoded in reserved chunk keys.
+type FileManifest struct {
+	Size      int64  `json:"size"`
+	Chunks    int    `json:"chunks"`
+	ChunkSize int    `json:"chunk_size"`
+	SHA256    string `json:"sha256"`
+}
+
+// FileOption configures PutFile and GetFile.
+type FileOption func(*fileOptions)
+
+// fileOptions holds settings of a file transfer.
+type fileOptions struct {
+	chunkSize int
+	progress  func(done, total int64)
+}
+
+// WithChunkSize sets the size in bytes of the chunks written by PutFile.
+func WithChunkSize(size int) FileOption {
+	return func(o *fileOptions) {
+		if size > 0 {
+			o.chunkSize = size
+		}
+	}
+}
+
+// WithProgress sets a callback invoked after every chunk with the bytes transferred so far
+// and the total size, which is -1 when PutFile can't tell the size of its reader.
+func WithProgress(fn func(done, total int64)) FileOption {
+	return func(o *fileOptions) {
+		o.progress = fn
+	}
+}
+
+// newFileOptions applies opts on top of the default file transfer settings.
+func newFileOptions(opts []FileOption) *fileOptions {
+	o := &fileOptions{chunkSize: DefaultFileChunkSize, progress: func(int64, int64) {}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// PutFile stores the content of r under key, split in chunks with a SHA-256 checksum. Chunks
+// are written first and the manifest last, so readers never see a partially written file.
+// Chunks left over from a previous, bigger version of the file are removed.
+//
+// Usage example:
+//
+//	f, err := os.Open("/etc/app/license.jks")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer f.Close()
+//	err = client.PutFile("my-app", "prod", "license.keystore", f, stogo.WithProgress(func(done, total int64) {
+//		log.Printf("uploaded %d/%d bytes", done, total)
+//	}))
+func (c *StooClient) PutFile(namespace, profile, key string, r io.Reader, opts ...FileOption) error {
+	o := newFileOptions(opts)
+	previous, _ := c.fileManifest(namespace, profile, key)
+	total := readerSize(r)
+
+	hash := sha256.New()
+	buf := make([]byte, o.chunkSize)
+	manifest := FileManifest{ChunkSize: o.chunkSize}
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			chunk := base64.StdEncoding.EncodeToString(buf[:n])
+			if _, err := c.Set(namespace, profile, fileChunkKey(key, manifest.Chunks), chunk); err != nil {
+				return err
+			}
+			manifest.Chunks++
+			manifest.Size += int64(n)
+			o.progress(manifest.Size, total)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	manifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	raw, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Set(namespace, profile, key, string(raw)); err != nil {
+		return err
+	}
+	if previous != nil {
+		for i := manifest.Chunks; i < previous.Chunks; i++ {
+			if _, err := c.Delete(namespace, profile, fileChunkKey(key, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetFile writes the content of a file stored with PutFile to w, verifying its checksum.
+// Content is written as chunks arrive, so on ErrChecksumMismatch w already holds the bad content.
+func (c *StooClient) GetFile(namespace, profile, key string, w io.Writer, opts ...FileOption) error {
+	o := newFileOptions(opts)
+	manifest, err := c.fileManifest(namespace, profile, key)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	var done int64
+	for i := 0; i < manifest.Chunks; i++ {
+		encoded, err := c.Get(namespace, profile, fileChunkKey(key, i))
+		if err != nil {
+			return err
+		}
+		chunk, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("stogo: file %s chunk %d: %w", key, i, err)
+		}
+		hash.Write(chunk)
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+		done += int64(len(chunk))
+		o.progress(done, manifest.Size)
+	}
+	if done != manifest.Size || hex.EncodeToString(hash.Sum(nil)) != manifest.SHA256 {
+		return fmt.Errorf("%w: %s/%s/%s", ErrChecksumMismatch, namespace, profile, key)
+	}
+	return nil
+}
+
+// DeleteFile removes a file stored with PutFile, chunks included.
+func (c *StooClient) DeleteFile(namespace, profile, key string) error {
+	manifest, err := c.fileManifest(namespace, profile, key)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Delete(namespace, profile, key); err != nil {
+		return err
+	}
+	return c.DeleteMany(namespace, profile, fileChunkKeys(key, manifest.Chunks)).Err()
+}
+
+// fileManifest reads the manifest of a file stored under key.
+func (c *StooClient) fileManifest(namespace, profile, key string) (*FileManifest, error) {
+	raw, err := c.Get(namespace, profile, key)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &FileManifest{}
+	if err := json.Unmarshal([]byte(raw), manifest); err != nil || manifest.SHA256 == "" {
+		return nil, fmt.Errorf("%w: %s/%s/%s", ErrNotAFile, namespace, profile, key)
+	}
+	return manifest, nil
+}
+
+// fileChunkKey returns the key of chunk i of the file stored under key.
+func fileChunkKey(key string, i int) string {
+	return ReservedKeyPrefix + "file." + key + "." + strconv.Itoa(i)
+}
+
+// fileChunkKeys returns the keys of the first n chunks of the file stored under key.
+func fileChunkKeys(key string, n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fileChunkKey(key, i)
+	}
+	return keys
+}
+
+// readerSize returns the number of bytes r will return if it can tell, -1 otherwise.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		if info, err := v.Stat(); err == nil && info.Mode().IsRegular() {
+			return info.Size()
+		}
+	}
+	return -1
+}
@@ -0,0 +1,53 @@
+package stogo
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// GetAllFiltered gets the keys of a namespace and profile matching pattern, a glob pattern as
+// understood by path.Match, e.g. feature.* for the keys under feature. StooKV has no
+// way to filter keys on its side, so the profile is read like GetAll, sharing its cache and
+// coalesced calls, and filtered on the client. Bookkeeping keys are left out. A malformed
+// pattern fails with path.ErrBadPattern before any call is made.
+//
+// Usage example:
+//
+//	features, err := client.GetAllFiltered("my-app", "prod", "feature.*")
+//	if err != nil {
+//		log.Fatalf("Error reading features %v", err)
+//	}
+func (c *StooClient) GetAllFiltered(namespace, profile, pattern string, opts ...CallOption) (map[string]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("stogo: key pattern %q: %w", pattern, err)
+	}
+	return c.getAllMatching(namespace, profile, func(key string) bool {
+		matched, _ := path.Match(pattern, key)
+		return matched
+	}, opts)
+}
+
+// GetAllMatching gets the keys of a namespace and profile matching re, like GetAllFiltered.
+//
+// Usage example:
+//
+//	limits, err := client.GetAllMatching("my-app", "prod", regexp.MustCompile(`^limits\.(api|web)\.`))
+func (c *StooClient) GetAllMatching(namespace, profile string, re *regexp.Regexp, opts ...CallOption) (map[string]string, error) {
+	return c.getAllMatching(namespace, profile, re.MatchString, opts)
+}
+
+// getAllMatching gets the keys of a namespace and profile for which match returns true.
+func (c *StooClient) getAllMatching(namespace, profile string, match func(key string) bool, opts []CallOption) (map[string]string, error) {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	matching := make(map[string]string)
+	for key, value := range values {
+		if match(key) && !IsReservedKey(key) {
+			matching[key] = value
+		}
+	}
+	return matching, nil
+}
@@ -0,0 +1,157 @@
+// Package flags implements feature flags on top of a StooKV namespace and profile.
+//
+// A flag is a key whose value is either a boolean understood by strconv.ParseBool, e.g.
+// "true", or a JSON object enabling the flag for a percentage of subjects:
+//
+//	{"enabled": true, "percentage": 25}
+//
+// Usage example:
+//
+//	ff := flags.New(client, "my-app", "prod")
+//	if ff.IsEnabledFor("checkout.new-ui", userID, false) {
+//		renderNewCheckout()
+//	}
+package flags
+
+import (
+	"encoding/json"
+	"github.com/mwangox/stogo"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshInterval interval between refreshes of flag values if not specified.
+const DefaultRefreshInterval = 30 * time.Second
+
+// Flags evaluates feature flags stored in a namespace and profile. All flags are fetched at
+// once and cached locally, they are refreshed on the first evaluation after the refresh
+// interval. When a refresh fails, the previous values keep being used until the next interval.
+type Flags struct {
+	client    *stogo.StooClient
+	namespace string
+	profile   string
+	refresh   time.Duration
+
+	mu        sync.Mutex
+	flags     map[string]flag
+	fetchedAt time.Time
+}
+
+// flag parsed value of a feature flag.
+type flag struct {
+	Enabled    bool     `json:"enabled"`
+	Percentage *float64 `json:"percentage"`
+}
+
+// New creates Flags reading from a namespace and profile.
+func New(client *stogo.StooClient, namespace, profile string) *Flags {
+	return &Flags{
+		client:    client,
+		namespace: namespace,
+		profile:   profile,
+		refresh:   DefaultRefreshInterval,
+	}
+}
+
+// WithRefreshInterval sets the interval between refreshes of flag values.
+func (f *Flags) WithRefreshInterval(refresh time.Duration) *Flags {
+	if refresh > 0 {
+		f.refresh = refresh
+	}
+	return f
+}
+
+// IsEnabled tells if a flag is enabled, def if the flag is missing or invalid. A flag rolled
+// out to a percentage of subjects is only enabled once the percentage reaches 100, use
+// IsEnabledFor to evaluate it for a subject.
+func (f *Flags) IsEnabled(key string, def bool) bool {
+	fl, ok := f.lookup(key)
+	if !ok {
+		return def
+	}
+	if fl.Percentage != nil {
+		return fl.Enabled && *fl.Percentage >= 100
+	}
+	return fl.Enabled
+}
+
+// IsEnabledFor tells if a flag is enabled for subject, e.g. a user or tenant id, def if the flag
+// is missing or invalid. Subjects are assigned to a stable bucket per flag, so a subject keeps
+// its outcome while the percentage grows.
+func (f *Flags) IsEnabledFor(key, subject string, def bool) bool {
+	fl, ok := f.lookup(key)
+	if !ok {
+		return def
+	}
+	if !fl.Enabled || fl.Percentage == nil {
+		return fl.Enabled
+	}
+	return bucket(key, subject) < *fl.Percentage
+}
+
+// Refresh fetches flag values now.
+func (f *Flags) Refresh() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fetch()
+}
+
+// lookup returns the flag stored under key, refreshing values if they are stale.
+func (f *Flags) lookup(key string) (flag, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fetchedAt.IsZero() || f.now().Sub(f.fetchedAt) >= f.refresh {
+		_ = f.fetch()
+	}
+	fl, ok := f.flags[key]
+	return fl, ok
+}
+
+// fetch replaces the cached flags with the ones in the store, the caller must hold mu.
+func (f *Flags) fetch() error {
+	f.fetchedAt = f.now()
+	values, err := f.client.GetAll(f.namespace, f.profile)
+	if err != nil {
+		return err
+	}
+	flags := make(map[string]flag, len(values))
+	for key, value := range values {
+		if fl, ok := parse(value); ok {
+			flags[key] = fl
+		}
+	}
+	f.flags = flags
+	return nil
+}
+
+// now returns the current time of the client clock.
+func (f *Flags) now() time.Time {
+	return f.client.CurrentConfig().GetClock().Now()
+}
+
+// parse parses a flag value, reporting if it is valid.
+func parse(value string) (flag, bool) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		var fl flag
+		if err := json.Unmarshal([]byte(value), &fl); err != nil {
+			return flag{}, false
+		}
+		return fl, true
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return flag{}, false
+	}
+	return flag{Enabled: enabled}, true
+}
+
+// bucket maps subject to a stable position between 0 and 100 for the flag key.
+func bucket(key, subject string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(key + "\x00" + subject))
+	return float64(h.Sum32()%10000) / 100
+}
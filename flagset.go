@@ -0,0 +1,144 @@
+package stogo
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mwangox/stogo/envelope"
+	"os"
+	"strings"
+)
+
+// FlagLoader fills the flags of a flag.FlagSet left unset on the command line with the values
+// of a namespace and profile, so that flags take their value from the command line first, then
+// from the environment if an env prefix is set, and finally from StooKV, before falling back
+// to their defaults.
+type FlagLoader struct {
+	client    *StooClient
+	namespace string
+	profile   string
+	keyFunc   func(name string) string
+	envPrefix string
+	lookupEnv func(string) (string, bool)
+}
+
+// NewFlagLoader creates a FlagLoader reading from the given namespace and profile. Flags are
+// mapped to the key of the same name unless WithKeyFunc or WithKeyPrefix says otherwise.
+//
+// Usage example:
+//
+//	addr := flag.String("listen-addr", ":8080", "address to listen on")
+//	flag.Parse()
+//	err := stogo.NewFlagLoader(client, "my-app", "prod").
+//		WithKeyPrefix("server.").
+//		WithEnvPrefix("MY_APP_").
+//		Populate(flag.CommandLine)
+//	if err != nil {
+//		log.Fatalf("Error loading flags %v", err)
+//	}
+func NewFlagLoader(client *StooClient, namespace, profile string) *FlagLoader {
+	return &FlagLoader{
+		client:    client,
+		namespace: namespace,
+		profile:   profile,
+		keyFunc:   func(name string) string { return name },
+		lookupEnv: os.LookupEnv,
+	}
+}
+
+// WithKeyFunc sets the function mapping a flag name to the key holding its value, flags mapped
+// to an empty key are left alone.
+func (l *FlagLoader) WithKeyFunc(fn func(name string) string) *FlagLoader {
+	if fn != nil {
+		l.keyFunc = fn
+	}
+	return l
+}
+
+// WithKeyPrefix maps every flag to the key of the same name under prefix, e.g. flag
+// listen-addr to key server.listen-addr with prefix server.
+func (l *FlagLoader) WithKeyPrefix(prefix string) *FlagLoader {
+	l.keyFunc = func(name string) string { return prefix + name }
+	return l
+}
+
+// WithEnvPrefix makes flags set in the environment take precedence over StooKV. The variable
+// of a flag is its name upper cased after prefix, with dashes and dots turned into underscores,
+// e.g. MY_APP_LISTEN_ADDR for flag listen-addr with prefix MY_APP_. The environment is not
+// read unless a prefix is set.
+func (l *FlagLoader) WithEnvPrefix(prefix string) *FlagLoader {
+	l.envPrefix = prefix
+	return l
+}
+
+// Populate sets the flags of fs which weren't set on the command line from the environment or
+// from the keys they map to, leaving the flags found in neither to their defaults. It must be
+// called after fs was parsed. Values encrypted client side are decrypted. Values a flag
+// rejects are reported together once every flag was tried.
+func (l *FlagLoader) Populate(fs *flag.FlagSet, opts ...CallOption) error {
+	values, err := l.client.GetAll(l.namespace, l.profile, opts...)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] {
+			return
+		}
+		if value, ok := l.env(f.Name); ok {
+			if err := fs.Set(f.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("stogo: flag -%s from $%s: %w", f.Name, l.envName(f.Name), err))
+			}
+			return
+		}
+		key := l.keyFunc(f.Name)
+		value, ok := values[key]
+		if key == "" || !ok {
+			return
+		}
+		if envelope.IsEncrypted(value) {
+			secret, err := l.client.GetSecret(l.namespace, l.profile, key, opts...)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("stogo: flag -%s from key %s: %w", f.Name, key, err))
+				return
+			}
+			value = secret.Reveal()
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("stogo: flag -%s from key %s: %w", f.Name, key, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// env returns the value of the variable of flag name, if an env prefix is set.
+func (l *FlagLoader) env(name string) (string, bool) {
+	if l.envPrefix == "" {
+		return "", false
+	}
+	return l.lookupEnv(l.envName(name))
+}
+
+// envName returns the environment variable of flag name.
+func (l *FlagLoader) envName(name string) string {
+	return l.envPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// PopulateFlags sets the flags of the command line which weren't set explicitly from the keys
+// of the same name in a namespace and profile, see FlagLoader to map flags to other keys or
+// read the environment too.
+//
+// Usage example:
+//
+//	flag.Parse()
+//	if err := client.PopulateFlags("my-app", "prod"); err != nil {
+//		log.Fatalf("Error loading flags %v", err)
+//	}
+func (c *StooClient) PopulateFlags(namespace, profile string, opts ...CallOption) error {
+	return NewFlagLoader(c, namespace, profile).Populate(flag.CommandLine, opts...)
+}
@@ -0,0 +1,284 @@
+package stogo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileFS read-only fs.FS holding the keys of a namespace and profile as files, dots
+// becoming directories: tls.ca is the file ca in the directory tls. Files stored with PutFile
+// hold their content rather than their manifest. It is a snapshot of the values read when it
+// was created, safe for concurrent use.
+type ProfileFS struct {
+	files   map[string][]byte
+	dirs    map[string][]string
+	modTime time.Time
+}
+
+// FS reads the values of a namespace and profile and returns them as a ProfileFS, so that code
+// loading configuration files, such as certificates, templates or rules, can read them from
+// StooKV through the standard interfaces. Keys which don't make a valid path, e.g. with empty
+// segments, and bookkeeping keys are left out, as are keys whose name is also a directory,
+// e.g. tls when tls.ca exists.
+//
+// Usage example:
+//
+//	fsys, err := client.FS("my-app", "prod")
+//	if err != nil {
+//		log.Fatalf("Error reading configuration %v", err)
+//	}
+//	tmpl, err := template.ParseFS(fsys, "templates/*")
+func (c *StooClient) FS(namespace, profile string, opts ...CallOption) (*ProfileFS, error) {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	fsys := &ProfileFS{
+		files:   make(map[string][]byte),
+		dirs:    map[string][]string{".": nil},
+		modTime: c.CurrentConfig().GetClock().Now(),
+	}
+	for key, value := range values {
+		name := strings.ReplaceAll(key, ".", "/")
+		if IsReservedKey(key) || !fs.ValidPath(name) || name == "." {
+			continue
+		}
+		content, err := fileContent(values, key, value)
+		if err != nil {
+			return nil, fmt.Errorf("stogo: %s/%s/%s: %w", namespace, profile, key, err)
+		}
+		fsys.files[name] = content
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			if _, ok := fsys.dirs[dir]; ok && dir != "." {
+				break
+			}
+			fsys.dirs[dir] = nil
+			if dir == "." {
+				break
+			}
+		}
+	}
+	for name := range fsys.files {
+		if _, ok := fsys.dirs[name]; ok {
+			delete(fsys.files, name)
+		}
+	}
+	for name := range fsys.files {
+		dir := path.Dir(name)
+		fsys.dirs[dir] = append(fsys.dirs[dir], path.Base(name))
+	}
+	for name := range fsys.dirs {
+		if name != "." {
+			parent := path.Dir(name)
+			fsys.dirs[parent] = append(fsys.dirs[parent], path.Base(name))
+		}
+	}
+	for _, entries := range fsys.dirs {
+		sort.Strings(entries)
+	}
+	return fsys, nil
+}
+
+// Open implements fs.FS.
+func (f *ProfileFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if content, ok := f.files[name]; ok {
+		return &profileFile{info: f.info(name, false), Reader: bytes.NewReader(content)}, nil
+	}
+	if _, ok := f.dirs[name]; ok {
+		return &profileDir{info: f.info(name, true), entries: f.entries(name)}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (f *ProfileFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	content, ok := f.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(content), nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *ProfileFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, ok := f.dirs[name]; !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.entries(name), nil
+}
+
+// Stat implements fs.StatFS.
+func (f *ProfileFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, ok := f.files[name]; ok {
+		return f.info(name, false), nil
+	}
+	if _, ok := f.dirs[name]; ok {
+		return f.info(name, true), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// info returns the fs.FileInfo of the file or directory name.
+func (f *ProfileFS) info(name string, dir bool) *profileFileInfo {
+	info := &profileFileInfo{name: path.Base(name), modTime: f.modTime, dir: dir}
+	if !dir {
+		info.size = int64(len(f.files[name]))
+	}
+	return info
+}
+
+// entries returns the entries of the directory name, in order.
+func (f *ProfileFS) entries(name string) []fs.DirEntry {
+	names := f.dirs[name]
+	entries := make([]fs.DirEntry, len(names))
+	for i, base := range names {
+		child := path.Join(name, base)
+		_, dir := f.dirs[child]
+		entries[i] = fs.FileInfoToDirEntry(f.info(child, dir))
+	}
+	return entries
+}
+
+// profileFileInfo fs.FileInfo of a ProfileFS file or directory.
+type profileFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+// Name implements fs.FileInfo.
+func (i *profileFileInfo) Name() string {
+	return i.name
+}
+
+// Size implements fs.FileInfo.
+func (i *profileFileInfo) Size() int64 {
+	return i.size
+}
+
+// ModTime returns the time the values were read at.
+func (i *profileFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+// IsDir implements fs.FileInfo.
+func (i *profileFileInfo) IsDir() bool {
+	return i.dir
+}
+
+// Sys implements fs.FileInfo.
+func (i *profileFileInfo) Sys() any {
+	return nil
+}
+
+// Mode returns read-only permissions, with fs.ModeDir for directories.
+func (i *profileFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// profileFile open ProfileFS file.
+type profileFile struct {
+	info *profileFileInfo
+	*bytes.Reader
+}
+
+// Stat implements fs.File.
+func (f *profileFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Close implements fs.File.
+func (f *profileFile) Close() error {
+	return nil
+}
+
+// profileDir open ProfileFS directory.
+type profileDir struct {
+	info    *profileFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+// Stat implements fs.File.
+func (d *profileDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+// Read implements fs.File, failing as directories can't be read.
+func (d *profileDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// Close implements fs.File.
+func (d *profileDir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *profileDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+// fileContent returns the content of key: the content of the file stored under it with
+// PutFile, read from the chunk keys of values, or value itself.
+func fileContent(values map[string]string, key, value string) ([]byte, error) {
+	manifest := &FileManifest{}
+	if !strings.HasPrefix(value, "{") || json.Unmarshal([]byte(value), manifest) != nil || manifest.SHA256 == "" {
+		return []byte(value), nil
+	}
+	content := make([]byte, 0, manifest.Size)
+	for i := 0; i < manifest.Chunks; i++ {
+		encoded, ok := values[fileChunkKey(key, i)]
+		if !ok {
+			return nil, fmt.Errorf("file chunk %d: %w", i, ErrKeyNotFound)
+		}
+		chunk, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("file chunk %d: %w", i, err)
+		}
+		content = append(content, chunk...)
+	}
+	sum := sha256.Sum256(content)
+	if int64(len(content)) != manifest.Size || hex.EncodeToString(sum[:]) != manifest.SHA256 {
+		return nil, ErrChecksumMismatch
+	}
+	return content, nil
+}
@@ -0,0 +1,115 @@
+package stogo_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/stogotest"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestProfileFS(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := stogo.NewStoreClient(srv.Config().WithClock(stogotest.NewFakeClock(now)))
+	for key, value := range map[string]string{
+		"server.port":         "8080",
+		"templates.welcome":   "Hello {{.Name}}",
+		"templates.bye":       "Bye",
+		"tls":                 "conflicts with the tls directory",
+		"invalid..path":       "left out",
+		"log.level":           "debug",
+		"tls.cert":            "-----BEGIN CERTIFICATE-----",
+		"__stogo.ttl.feature": "1700000000",
+	} {
+		if _, err := client.Set("app", "prod", key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ca := bytes.Repeat([]byte("certificate authority "), 100)
+	if err := client.PutFile("app", "prod", "tls.ca", bytes.NewReader(ca), stogo.WithChunkSize(512)); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := client.FS("app", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "server/port", "templates/welcome", "templates/bye", "log/level", "tls/cert", "tls/ca"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := fs.ReadFile(fsys, "tls/ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, ca) {
+		t.Errorf("got tls/ca holding %d bytes, want the %d bytes of the file", len(content), len(ca))
+	}
+	if content, err := fs.ReadFile(fsys, "templates/welcome"); err != nil || string(content) != "Hello {{.Name}}" {
+		t.Errorf("got %q, %v, want the value of templates.welcome", content, err)
+	}
+
+	var names []string
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"log/level", "server/port", "templates/bye", "templates/welcome", "tls/ca", "tls/cert"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got files %v, want %v without reserved, conflicting or invalid keys", names, want)
+	}
+
+	info, err := fs.Stat(fsys, "server/port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 4 || !info.ModTime().Equal(now) || info.Mode() != 0o444 {
+		t.Errorf("got size %d, time %v, mode %v, want 4, the read time and read-only", info.Size(), info.ModTime(), info.Mode())
+	}
+	if _, err := fsys.Open("server/host"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v opening a missing file, want %v", err, fs.ErrNotExist)
+	}
+	if _, err := fsys.Open("/server/port"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("got error %v opening an invalid path, want %v", err, fs.ErrInvalid)
+	}
+}
+
+func TestProfileFSCorruptFile(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	client := stogo.NewStoreClient(srv.Config())
+	if err := client.PutFile("app", "prod", "rules", strings.NewReader("allow all")); err != nil {
+		t.Fatal(err)
+	}
+	for key := range srv.Data("app", "prod") {
+		if strings.HasPrefix(key, stogo.ReservedKeyPrefix) {
+			if _, err := client.Set("app", "prod", key, "ZGVueSBhbGw="); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := client.FS("app", "prod"); !errors.Is(err, stogo.ErrChecksumMismatch) {
+		t.Errorf("got error %v, want %v", err, stogo.ErrChecksumMismatch)
+	}
+}
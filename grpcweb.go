@@ -0,0 +1,272 @@
+package stogo
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// grpcWebContentType content type of gRPC-Web requests and responses carrying binary protobuf.
+	grpcWebContentType = "application/grpc-web+proto"
+	// grpcWebTrailerFlag flag of the frame holding the trailers.
+	grpcWebTrailerFlag = 0x80
+	// grpcWebCompressedFlag flag of frames holding a compressed message.
+	grpcWebCompressedFlag = 0x01
+)
+
+// grpcWebConn grpc.ClientConnInterface calling the gRPC-Web gateway at base over HTTP, see
+// config.TransportHTTP. Calls go through interceptors, which are given a nil *grpc.ClientConn.
+type grpcWebConn struct {
+	base         string
+	client       *http.Client
+	interceptors []grpc.UnaryClientInterceptor
+	maxReceive   int
+	userAgent    string
+}
+
+// newGRPCWebConn creates a grpcWebConn to the gateway at addr using the given TLS settings
+// for https URLs. interceptors run after the configured ones, closest to the network.
+func newGRPCWebConn(cfg *config.StooConfig, addr string, t *config.TLS, interceptors ...grpc.UnaryClientInterceptor) (*grpcWebConn, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if strings.HasPrefix(addr, "https://") {
+		tlsConfig, err := clientTLSConfig(cfg, t)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	dialer := cfg.GetContextDialer()
+	if dialer == nil && cfg.GetProxy() != "" {
+		var err error
+		if dialer, err = proxyDialer(cfg.GetProxy()); err != nil {
+			return nil, err
+		}
+	}
+	if dialer != nil {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer(ctx, addr)
+		}
+	}
+	return &grpcWebConn{
+		base:         strings.TrimSuffix(addr, "/"),
+		client:       &http.Client{Transport: transport},
+		interceptors: append(append([]grpc.UnaryClientInterceptor(nil), cfg.GetUnaryInterceptors()...), interceptors...),
+		maxReceive:   cfg.GetMaxReceiveSize(),
+		userAgent:    userAgent(cfg),
+	}, nil
+}
+
+// Invoke implements grpc.ClientConnInterface.
+func (w *grpcWebConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return w.invoke(0, ctx, method, args, reply, opts)
+}
+
+// NewStream implements grpc.ClientConnInterface, streams are not supported.
+func (w *grpcWebConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "streams are not supported by the HTTP transport")
+}
+
+// invoke runs the interceptors from the i-th one, the last one calling the gateway.
+func (w *grpcWebConn) invoke(i int, ctx context.Context, method string, args, reply any, opts []grpc.CallOption) error {
+	if i == len(w.interceptors) {
+		return w.call(ctx, method, args, reply, opts)
+	}
+	next := func(ctx context.Context, method string, args, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return w.invoke(i+1, ctx, method, args, reply, opts)
+	}
+	return w.interceptors[i](ctx, method, args, reply, nil, next, opts...)
+}
+
+// call sends args to the gateway and decodes the response into reply.
+func (w *grpcWebConn) call(ctx context.Context, method string, args, reply any, opts []grpc.CallOption) error {
+	in, ok := args.(gproto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "%T is not a protobuf message", args)
+	}
+	out, ok := reply.(gproto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "%T is not a protobuf message", reply)
+	}
+	payload, err := gproto.Marshal(in)
+	if err != nil {
+		return status.Errorf(codes.Internal, "marshaling request: %v", err)
+	}
+	body := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(payload)))
+	body = append(body, payload...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.base+method, bytes.NewReader(body))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	req.Header.Set("Content-Type", grpcWebContentType)
+	req.Header.Set("Accept", grpcWebContentType)
+	req.Header.Set("X-Grpc-Web", "1")
+	req.Header.Set("User-Agent", w.userAgent)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline).Milliseconds()
+		if timeout < 1 {
+			timeout = 1
+		}
+		req.Header.Set("Grpc-Timeout", strconv.FormatInt(timeout, 10)+"m")
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, values := range md {
+		for _, value := range values {
+			if strings.HasSuffix(key, "-bin") {
+				value = base64.StdEncoding.EncodeToString([]byte(value))
+			}
+			req.Header.Add(key, value)
+		}
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer res.Body.Close()
+	header := httpMetadata(res.Header)
+	trailer, received, err := w.readFrames(res.Body, out)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return err
+	}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = trailer
+		}
+	}
+
+	// Trailers-only responses carry the status in the headers.
+	st := grpcWebStatus(trailer)
+	if st == nil {
+		st = grpcWebStatus(header)
+	}
+	switch {
+	case st != nil:
+		if err := st.Err(); err != nil {
+			return err
+		}
+	case res.StatusCode != http.StatusOK:
+		return status.Errorf(httpStatusCode(res.StatusCode), "gateway answered %s", res.Status)
+	default:
+		return status.Error(codes.Internal, "gateway response has no grpc-status")
+	}
+	if !received {
+		return status.Error(codes.Internal, "gateway response has no message")
+	}
+	return nil
+}
+
+// readFrames reads the frames of a response, decoding the message into out and returning the trailers.
+func (w *grpcWebConn) readFrames(r io.Reader, out gproto.Message) (metadata.MD, bool, error) {
+	trailer := metadata.MD{}
+	received := false
+	var prefix [5]byte
+	for {
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return trailer, received, nil
+			}
+			return nil, false, status.Errorf(codes.Unavailable, "reading response: %v", err)
+		}
+		length := binary.BigEndian.Uint32(prefix[1:])
+		if w.maxReceive > 0 && int64(length) > int64(w.maxReceive) {
+			return nil, false, status.Errorf(codes.ResourceExhausted, "received message larger than max (%d vs. %d)", length, w.maxReceive)
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, false, status.Errorf(codes.Unavailable, "reading response: %v", err)
+		}
+		switch {
+		case prefix[0]&grpcWebTrailerFlag != 0:
+			for _, line := range strings.Split(string(frame), "\r\n") {
+				if key, value, ok := strings.Cut(line, ":"); ok {
+					trailer.Append(strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value))
+				}
+			}
+		case prefix[0]&grpcWebCompressedFlag != 0:
+			return nil, false, status.Error(codes.Internal, "compressed responses are not supported by the HTTP transport")
+		case received:
+			return nil, false, status.Error(codes.Internal, "gateway sent more than one message")
+		default:
+			if err := gproto.Unmarshal(frame, out); err != nil {
+				return nil, false, status.Errorf(codes.Internal, "unmarshaling response: %v", err)
+			}
+			received = true
+		}
+	}
+}
+
+// httpMetadata converts HTTP headers to metadata with lowercase keys.
+func httpMetadata(h http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range h {
+		md.Append(strings.ToLower(key), values...)
+	}
+	return md
+}
+
+// grpcWebStatus returns the status held by md, nil if it has none.
+func grpcWebStatus(md metadata.MD) *status.Status {
+	values := md.Get("grpc-status")
+	if len(values) == 0 {
+		return nil
+	}
+	code, err := strconv.Atoi(values[0])
+	if err != nil {
+		return status.New(codes.Internal, fmt.Sprintf("malformed grpc-status %q", values[0]))
+	}
+	var message string
+	if values := md.Get("grpc-message"); len(values) > 0 {
+		message, err = url.PathUnescape(values[0])
+		if err != nil {
+			message = values[0]
+		}
+	}
+	return status.New(codes.Code(code), message)
+}
+
+// httpStatusCode maps an HTTP status carrying no gRPC status to a gRPC code, as gRPC does.
+func httpStatusCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
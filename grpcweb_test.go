@@ -0,0 +1,212 @@
+package stogo_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webFrame returns a gRPC-Web frame holding data with flags.
+func webFrame(flags byte, data []byte) []byte {
+	frame := make([]byte, 5, 5+len(data))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+// grpcWebGateway gRPC-Web gateway in front of a stogotest.Server, answering with a
+// stookv-author header and a stookv-version trailer and recording the last request headers.
+type grpcWebGateway struct {
+	srv *stogotest.Server
+
+	mu     sync.Mutex
+	header http.Header
+}
+
+func (g *grpcWebGateway) lastHeader() http.Header {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.header
+}
+
+func (g *grpcWebGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	g.header = r.Header.Clone()
+	g.mu.Unlock()
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/grpc-web+proto" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil || len(body) < 5 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	msg := body[5:]
+
+	var res gproto.Message
+	switch r.URL.Path {
+	case proto.KVService_GetService_FullMethodName:
+		req := &proto.GetRequest{}
+		if err = gproto.Unmarshal(msg, req); err == nil {
+			res, err = g.srv.GetService(r.Context(), req)
+		}
+	case proto.KVService_GetServiceByNamespaceAndProfile_FullMethodName:
+		req := &proto.GetByNamespaceAndProfileRequest{}
+		if err = gproto.Unmarshal(msg, req); err == nil {
+			res, err = g.srv.GetServiceByNamespaceAndProfile(r.Context(), req)
+		}
+	case proto.KVService_SetKeyService_FullMethodName:
+		req := &proto.SetKeyRequest{}
+		if err = gproto.Unmarshal(msg, req); err == nil {
+			res, err = g.srv.SetKeyService(r.Context(), req)
+		}
+	case proto.KVService_SetSecretKeyService_FullMethodName:
+		req := &proto.SetKeyRequest{}
+		if err = gproto.Unmarshal(msg, req); err == nil {
+			res, err = g.srv.SetSecretKeyService(r.Context(), req)
+		}
+	case proto.KVService_DeleteKeyService_FullMethodName:
+		req := &proto.DeleteKeyRequest{}
+		if err = gproto.Unmarshal(msg, req); err == nil {
+			res, err = g.srv.DeleteKeyService(r.Context(), req)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/grpc-web+proto")
+	if err != nil {
+		// Trailers-only response.
+		st := status.Convert(err)
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code())))
+		w.Header().Set("Grpc-Message", url.PathEscape(st.Message()))
+		return
+	}
+	data, err := gproto.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Stookv-Author", "gateway")
+	w.Write(webFrame(0, data))
+	w.Write(webFrame(0x80, []byte("grpc-status: 0\r\nstookv-version: 7\r\n")))
+}
+
+func TestGRPCWeb(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	gateway := &grpcWebGateway{srv: srv}
+	gw := httptest.NewServer(gateway)
+	defer gw.Close()
+	client, err := stogo.Dial(config.NewStooConfig(gw.URL, 5*time.Second).
+		WithTransport(config.TransportHTTP).
+		WithHeader("x-team", "payments"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Set("app", "prod", "db.host", "db.internal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SetSecret("app", "prod", "db.password", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	if team := gateway.lastHeader().Get("X-Team"); team != "payments" {
+		t.Errorf("got x-team header %q, want the configured payments", team)
+	}
+	if value, err := client.Get("app", "prod", "db.host"); err != nil || value != "db.internal" {
+		t.Fatalf("got %q, %v, want db.internal", value, err)
+	}
+	all, err := client.GetAll("app", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all["db.password"] != "s3cr3t" {
+		t.Errorf("got %v, want both keys", all)
+	}
+	_, meta, err := client.GetWithMeta("app", "prod", "db.host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Author != "gateway" || meta.Version != 7 {
+		t.Errorf("got meta %+v, want the author header and version trailer of the gateway", meta)
+	}
+	if _, err := client.Delete("app", "prod", "db.host"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get("app", "prod", "db.host"); !errors.Is(err, stogo.ErrKeyNotFound) {
+		t.Errorf("got error %v reading a deleted key, want %v", err, stogo.ErrKeyNotFound)
+	}
+}
+
+func TestGRPCWebErrors(t *testing.T) {
+	response := &proto.GetResponse{Data: "a value of some length"}
+	data, err := gproto.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := webFrame(0x80, []byte("grpc-status: 0\r\n"))
+	tests := []struct {
+		name           string
+		status         int
+		header         map[string]string
+		body           []byte
+		maxReceiveSize int
+		want           codes.Code
+	}{
+		{name: "gRPC status", status: http.StatusOK, header: map[string]string{"Grpc-Status": "7", "Grpc-Message": "no%20access"}, want: codes.PermissionDenied},
+		{name: "unavailable gateway", status: http.StatusServiceUnavailable, want: codes.Unavailable},
+		{name: "unauthorized", status: http.StatusUnauthorized, want: codes.Unauthenticated},
+		{name: "no gRPC status", status: http.StatusOK, body: webFrame(0, data), want: codes.Internal},
+		{name: "no message", status: http.StatusOK, body: ok, want: codes.Internal},
+		{name: "compressed message", status: http.StatusOK, body: append(webFrame(1, data), ok...), want: codes.Internal},
+		{name: "message too large", status: http.StatusOK, body: append(webFrame(0, data), ok...), maxReceiveSize: 8, want: codes.ResourceExhausted},
+		{name: "truncated message", status: http.StatusOK, body: webFrame(0, data)[:10], want: codes.Unavailable},
+		{name: "valid", status: http.StatusOK, body: append(webFrame(0, data), ok...), want: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/grpc-web+proto")
+				for key, value := range tt.header {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+				w.Write(tt.body)
+			}))
+			defer gw.Close()
+			client, err := stogo.Dial(config.NewStooConfig(gw.URL, 5*time.Second).
+				WithTransport(config.TransportHTTP).
+				WithMaxReceiveSize(tt.maxReceiveSize))
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, err := client.Get("app", "prod", "key")
+			if code := status.Code(err); code != tt.want {
+				t.Fatalf("got error %v, want code %v", err, tt.want)
+			}
+			if tt.want == codes.OK && value != response.Data {
+				t.Errorf("got %q, want %q", value, response.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package stogo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// ErrNotServing thrown by HealthCheck when StooKV reports it is not serving.
+var ErrNotServing = errors.New("stooKV is not serving")
+
+// healthCheckKey key read to check endpoints which don't implement the gRPC health protocol.
+const healthCheckKey = ReservedKeyPrefix + "health"
+
+// HealthCheck tells if StooKV can be reached, returning nil as soon as one endpoint is
+// healthy. Endpoints are checked with the standard gRPC health protocol, falling back to
+// reading a single key from those which don't implement it.
+//
+// Usage example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+//	defer cancel()
+//	if err := client.HealthCheck(ctx); err != nil {
+//		log.Printf("stooKV unreachable: %v", err)
+//	}
+func (c *StooClient) HealthCheck(ctx context.Context) error {
+	var errs []error
+	for _, e := range c.endpoints {
+		err := e.healthCheck(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", e.addr, err))
+	}
+	return errors.Join(errs...)
+}
+
+// ReadinessHandler returns an http.Handler answering 200 when HealthCheck succeeds and 503
+// otherwise, for use as a readiness probe. Checks are bounded by the read timeout.
+//
+// Usage example:
+//
+//	http.Handle("/readyz", client.ReadinessHandler())
+func (c *StooClient) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), c.CurrentConfig().GetReadTimeout())
+		defer cancel()
+		if err := c.HealthCheck(ctx); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+}
+
+// healthCheck checks the endpoint with the gRPC health protocol or, if unimplemented, a read.
+func (e *endpoint) healthCheck(ctx context.Context) error {
+	if e.conn != nil {
+		res, err := grpc_health_v1.NewHealthClient(e.conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if err == nil {
+			if res.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+				return fmt.Errorf("%w: %s", ErrNotServing, res.GetStatus())
+			}
+			return nil
+		}
+		if status.Code(err) != codes.Unimplemented {
+			return err
+		}
+	}
+	_, err := e.kv.GetService(ctx, &proto.GetRequest{Namespace: healthCheckKey, Profile: healthCheckKey, Key: healthCheckKey})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// hedgedKVClient KVServiceClient hedging the reads made on namespace, see
+// config.StooConfig.WithHedging. Writes go to a single endpoint.
+type hedgedKVClient struct {
+	c         *StooClient
+	namespace string
+}
+
+// GetService hedges the read of a key.
+func (h hedgedKVClient) GetService(ctx context.Context, in *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+	return hedge(ctx, h.c, h.namespace, opts, func(ctx context.Context, kv proto.KVServiceClient, opts []grpc.CallOption) (*proto.GetResponse, error) {
+		return kv.GetService(ctx, in, opts...)
+	})
+}
+
+// GetServiceByNamespaceAndProfile hedges the read of a profile.
+func (h hedgedKVClient) GetServiceByNamespaceAndProfile(ctx context.Context, in *proto.GetByNamespaceAndProfileRequest, opts ...grpc.CallOption) (*proto.GetByNamespaceAndProfileResponse, error) {
+	return hedge(ctx, h.c, h.namespace, opts, func(ctx context.Context, kv proto.KVServiceClient, opts []grpc.CallOption) (*proto.GetByNamespaceAndProfileResponse, error) {
+		return kv.GetServiceByNamespaceAndProfile(ctx, in, opts...)
+	})
+}
+
+// SetKeyService sets a key through a single endpoint.
+func (h hedgedKVClient) SetKeyService(ctx context.Context, in *proto.SetKeyRequest, opts ...grpc.CallOption) (*proto.SetKeyResponse, error) {
+	return h.c.pick(h.namespace).kv.SetKeyService(ctx, in, opts...)
+}
+
+// SetSecretKeyService sets a secret key through a single endpoint.
+func (h hedgedKVClient) SetSecretKeyService(ctx context.Context, in *proto.SetKeyRequest, opts ...grpc.CallOption) (*proto.SetKeyResponse, error) {
+	return h.c.pick(h.namespace).kv.SetSecretKeyService(ctx, in, opts...)
+}
+
+// DeleteKeyService deletes a key through a single endpoint.
+func (h hedgedKVClient) DeleteKeyService(ctx context.Context, in *proto.DeleteKeyRequest, opts ...grpc.CallOption) (*proto.DeleteKeyResponse, error) {
+	return h.c.pick(h.namespace).kv.DeleteKeyService(ctx, in, opts...)
+}
+
+// attempt outcome of a hedged attempt, along with the response metadata it received.
+type attempt[T any] struct {
+	res     T
+	err     error
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+// hedge makes call on an endpoint serving namespace, then on another one every hedging delay
+// without an answer, or right away when an attempt fails because of its endpoint, until the
+// max attempts are made. The first answer wins and the other attempts are canceled. Each
+// attempt receives its own header and trailer, those of the winning one being copied to the
+// destinations of opts.
+func hedge[T any](ctx context.Context, c *StooClient, namespace string, opts []grpc.CallOption, call func(context.Context, proto.KVServiceClient, []grpc.CallOption) (T, error)) (T, error) {
+	cfg := c.CurrentConfig()
+	delay, maxAttempts := cfg.GetHedging()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	endpoints := c.endpointsFor(namespace)
+	if len(endpoints) == 0 {
+		var zero T
+		return zero, status.Errorf(codes.Unavailable, "no endpoint serves namespace %s", namespace)
+	}
+	tried := make(map[*endpoint]bool, len(endpoints))
+	attempts := make(chan attempt[T], maxAttempts)
+	launched, pending := 0, 0
+	launch := func() {
+		untried := make([]*endpoint, 0, len(endpoints))
+		for _, e := range endpoints {
+			if !tried[e] {
+				untried = append(untried, e)
+			}
+		}
+		if len(untried) == 0 {
+			untried = endpoints
+		}
+		e := c.pickFrom(untried)
+		tried[e] = true
+		launched++
+		pending++
+		go func() {
+			var a attempt[T]
+			a.res, a.err = call(ctx, e.kv, attemptOptions(opts, &a.header, &a.trailer))
+			attempts <- a
+		}()
+	}
+
+	launch()
+	var err error
+	for {
+		var timer <-chan time.Time
+		if launched < maxAttempts {
+			timer = cfg.GetClock().After(delay)
+		}
+		select {
+		case a := <-attempts:
+			pending--
+			if a.err == nil || !isEndpointError(ctx, a.err) {
+				commitOptions(opts, a.header, a.trailer)
+				return a.res, a.err
+			}
+			err = a.err
+			if launched < maxAttempts && ctx.Err() == nil {
+				launch()
+			} else if pending == 0 {
+				commitOptions(opts, a.header, a.trailer)
+				var zero T
+				return zero, err
+			}
+		case <-timer:
+			launch()
+		}
+	}
+}
+
+// attemptOptions returns opts with the header and trailer destinations replaced by header and
+// trailer, so that concurrent attempts don't write to the same ones.
+func attemptOptions(opts []grpc.CallOption, header, trailer *metadata.MD) []grpc.CallOption {
+	replaced := make([]grpc.CallOption, 0, len(opts))
+	for _, opt := range opts {
+		switch opt.(type) {
+		case grpc.HeaderCallOption:
+			replaced = append(replaced, grpc.Header(header))
+		case grpc.TrailerCallOption:
+			replaced = append(replaced, grpc.Trailer(trailer))
+		default:
+			replaced = append(replaced, opt)
+		}
+	}
+	return replaced
+}
+
+// commitOptions copies header and trailer to the destinations of opts.
+func commitOptions(opts []grpc.CallOption, header, trailer metadata.MD) {
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = trailer
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hedgeRace decides how the reads of the hedgeServers sharing it answer: the first one fails
+// with first, or waits for the caller to give up when first is codes.OK, the others are served.
+type hedgeRace struct {
+	first    codes.Code
+	canceled chan struct{}
+
+	mu       sync.Mutex
+	arrivals []string
+}
+
+// arrive records a read reaching name, returning its rank.
+func (r *hedgeRace) arrive(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.arrivals = append(r.arrivals, name)
+	return len(r.arrivals)
+}
+
+// reads returns the servers reached so far, in order.
+func (r *hedgeRace) reads() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.arrivals...)
+}
+
+// hedgeServer stogotest.Server reporting its name in the stookv-author header and the rank of
+// the read in the stookv-version trailer, reads answering as decided by race.
+type hedgeServer struct {
+	*stogotest.Server
+	name string
+	race *hedgeRace
+}
+
+func (s *hedgeServer) GetService(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	rank := s.race.arrive(s.name)
+	grpc.SetHeader(ctx, metadata.Pairs("stookv-author", s.name))
+	grpc.SetTrailer(ctx, metadata.Pairs("stookv-version", strconv.Itoa(rank)))
+	if rank > 1 {
+		return s.Server.GetService(ctx, req)
+	}
+	if s.race.first != codes.OK {
+		return nil, status.Error(s.race.first, "first read")
+	}
+	<-ctx.Done()
+	close(s.race.canceled)
+	return nil, ctx.Err()
+}
+
+// newHedgeServer starts a hedgeServer named name, holding name as the value of key in app/prod.
+func newHedgeServer(t *testing.T, name string, race *hedgeRace) string {
+	t.Helper()
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	if _, err := stogo.NewStoreClient(srv.Config()).Set("app", "prod", "key", name); err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	proto.RegisterKVServiceServer(server, &hedgeServer{Server: srv, name: name, race: race})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestHedging(t *testing.T) {
+	tests := []struct {
+		name       string
+		first      codes.Code
+		wantHedged bool
+		wantErr    error
+	}{
+		{"slow endpoint", codes.OK, true, nil},
+		{"endpoint error", codes.Unavailable, false, nil},
+		{"request error", codes.NotFound, false, stogo.ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			race := &hedgeRace{first: tt.first, canceled: make(chan struct{})}
+			clock := stogotest.NewFakeClock(time.Now())
+			cfg := config.NewStooConfig(newHedgeServer(t, "a", race), time.Minute).
+				WithEndpoints(newHedgeServer(t, "b", race)).
+				WithHedging(time.Second, 2).
+				WithClock(clock)
+			client, err := stogo.Dial(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			type result struct {
+				value string
+				meta  stogo.ValueMeta
+				err   error
+			}
+			done := make(chan result, 1)
+			go func() {
+				value, meta, err := client.GetWithMeta("app", "prod", "key")
+				done <- result{value, meta, err}
+			}()
+
+			var res result
+			if tt.wantHedged {
+				for clock.Waiters() == 0 || len(race.reads()) == 0 {
+					time.Sleep(time.Millisecond)
+				}
+				select {
+				case res = <-done:
+					t.Fatalf("got %+v before the hedging delay", res)
+				case <-time.After(50 * time.Millisecond):
+				}
+				if reads := race.reads(); len(reads) != 1 {
+					t.Fatalf("got reads %v before the hedging delay, want 1", reads)
+				}
+				clock.Advance(time.Second)
+			}
+			res = <-done
+
+			reads := race.reads()
+			if tt.wantErr != nil {
+				if !errors.Is(res.err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", res.err, tt.wantErr)
+				}
+				if len(reads) != 1 {
+					t.Errorf("got reads %v, want a single one for a request error", reads)
+				}
+				return
+			}
+			if res.err != nil {
+				t.Fatal(res.err)
+			}
+			if len(reads) != 2 || reads[0] == reads[1] {
+				t.Fatalf("got reads %v, want one on each endpoint", reads)
+			}
+			winner := reads[1]
+			if res.value != winner {
+				t.Errorf("got value %q, want %q of the winning endpoint", res.value, winner)
+			}
+			if res.meta.Author != winner || res.meta.Version != 2 {
+				t.Errorf("got meta %+v, want the header and trailer of %s", res.meta, winner)
+			}
+			if tt.wantHedged {
+				select {
+				case <-race.canceled:
+				case <-time.After(5 * time.Second):
+					t.Error("the losing attempt was not canceled")
+				}
+			}
+		})
+	}
+}
+
+func TestHedgingNoEndpoint(t *testing.T) {
+	client := &stogo.StooClient{Config: config.NewStooConfig("localhost:50051", time.Second).WithHedging(time.Millisecond, 2)}
+	_, err := client.Get("app", "prod", "key")
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("got error %v, want Unavailable", err)
+	}
+}
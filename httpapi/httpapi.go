@@ -0,0 +1,215 @@
+// Package httpapi serves the values of StooKV over a minimal HTTP/JSON API, so that scripts and
+// non-Go processes on a host can read them through one shared client, connection and cache. It
+// is what "stogo serve" runs. Values of secret keys are served as the client reads them, so the
+// API must only be exposed on a loopback address or a Unix socket.
+//
+// Endpoints, all GET:
+//
+//	/v1/{namespace}/{profile}        all values as a JSON object
+//	/v1/{namespace}/{profile}/{key}  {"key": ..., "value": ...}, 404 if the key is missing
+//	/v1/{namespace}/{profile}?watch  Server-Sent Events, a "values" event holding all values
+//	                                 as a JSON object first and on every change
+//
+// Errors are returned as {"error": "..."} with a status code derived from the gRPC one.
+//
+// Usage example:
+//
+//	handler := httpapi.New(client).WithCacheTTL(5 * time.Second)
+//	log.Fatal(http.ListenAndServe("127.0.0.1:8400", handler))
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL time values are served from the cache of a Handler before being read again.
+const DefaultCacheTTL = 5 * time.Second
+
+// Handler serves the HTTP API. It is safe for concurrent use.
+type Handler struct {
+	client       *stogo.StooClient
+	cacheTTL     time.Duration
+	pollInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[config.NamespaceProfile]cacheEntry
+}
+
+// cacheEntry values of a profile and the time they were read at.
+type cacheEntry struct {
+	values map[string]string
+	readAt time.Time
+}
+
+// New creates a Handler reading values through client.
+func New(client *stogo.StooClient) *Handler {
+	return &Handler{
+		client:   client,
+		cacheTTL: DefaultCacheTTL,
+		cache:    make(map[config.NamespaceProfile]cacheEntry),
+	}
+}
+
+// WithCacheTTL sets the time values of a profile are served from the cache before being read
+// again, DefaultCacheTTL by default. Zero disables the cache.
+func (h *Handler) WithCacheTTL(ttl time.Duration) *Handler {
+	h.cacheTTL = ttl
+	return h
+}
+
+// WithPollInterval sets the interval watched profiles are polled at, the poll interval of the
+// client configuration by default. Watchers of a profile share a single poll loop.
+func (h *Handler) WithPollInterval(interval time.Duration) *Handler {
+	h.pollInterval = interval
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/")
+	parts := strings.SplitN(rest, "/", 3)
+	if !ok || len(parts) < 2 || parts[0] == "" || parts[1] == "" || (len(parts) == 3 && parts[2] == "") {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	namespace, profile := parts[0], parts[1]
+	switch {
+	case len(parts) == 3:
+		h.serveKey(w, namespace, profile, parts[2])
+	case r.URL.Query().Has("watch") || r.Header.Get("Accept") == "text/event-stream":
+		h.serveWatch(w, r, namespace, profile)
+	default:
+		h.serveAll(w, namespace, profile)
+	}
+}
+
+// serveAll writes all values of a profile.
+func (h *Handler) serveAll(w http.ResponseWriter, namespace, profile string) {
+	values, err := h.values(namespace, profile)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	if values == nil {
+		// An empty profile is an empty object, not null.
+		values = map[string]string{}
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+// serveKey writes the value of a key.
+func (h *Handler) serveKey(w http.ResponseWriter, namespace, profile, key string) {
+	values, err := h.values(namespace, profile)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	value, ok := values[key]
+	if !ok {
+		err := fmt.Errorf("%w: %s/%s/%s", stogo.ErrKeyNotFound, namespace, profile, key)
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"key": key, "value": value})
+}
+
+// serveWatch streams the values of a profile as Server-Sent Events until the client goes away.
+func (h *Handler) serveWatch(w http.ResponseWriter, r *http.Request, namespace, profile string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	h.client.Watch(r.Context(), namespace, profile, h.pollInterval, func(values map[string]string) {
+		h.store(namespace, profile, values)
+		data, err := json.Marshal(values)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: values\ndata: %s\n\n", data)
+		flusher.Flush()
+	})
+}
+
+// values returns the values of a profile from the cache or the client.
+func (h *Handler) values(namespace, profile string) (map[string]string, error) {
+	p := config.NamespaceProfile{Namespace: namespace, Profile: profile}
+	now := h.client.CurrentConfig().GetClock().Now()
+	h.mu.Lock()
+	entry, ok := h.cache[p]
+	h.mu.Unlock()
+	if ok && now.Sub(entry.readAt) < h.cacheTTL {
+		return entry.values, nil
+	}
+	values, err := h.client.GetAll(namespace, profile)
+	if err != nil {
+		return nil, err
+	}
+	h.store(namespace, profile, values)
+	return values, nil
+}
+
+// store caches the values of a profile. Cached maps are never modified.
+func (h *Handler) store(namespace, profile string, values map[string]string) {
+	if h.cacheTTL <= 0 {
+		return
+	}
+	now := h.client.CurrentConfig().GetClock().Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cache[config.NamespaceProfile{Namespace: namespace, Profile: profile}] = cacheEntry{values: values, readAt: now}
+}
+
+// statusOf maps err to an HTTP status code.
+func statusOf(err error) int {
+	if errors.Is(err, stogo.ErrKeyNotFound) {
+		return http.StatusNotFound
+	}
+	switch status.Code(err) {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// writeJSON writes v as JSON with status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as a JSON error with status code.
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,195 @@
+package httpapi_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/httpapi"
+	"github.com/mwangox/stogo/stogotest"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	client := stogo.NewStoreClient(srv.Config())
+	if _, err := client.Set("app", "prod", "db.host", "db.internal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Set("app", "prod", "db.port", "5432"); err != nil {
+		t.Fatal(err)
+	}
+	handler := httpapi.New(client)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		want       map[string]string
+	}{
+		{"profile", http.MethodGet, "/v1/app/prod", http.StatusOK, map[string]string{"db.host": "db.internal", "db.port": "5432"}},
+		{"key", http.MethodGet, "/v1/app/prod/db.host", http.StatusOK, map[string]string{"key": "db.host", "value": "db.internal"}},
+		{"missing key", http.MethodGet, "/v1/app/prod/db.user", http.StatusNotFound, nil},
+		{"empty profile", http.MethodGet, "/v1/app/staging", http.StatusOK, map[string]string{}},
+		{"no profile", http.MethodGet, "/v1/app", http.StatusNotFound, nil},
+		{"empty key", http.MethodGet, "/v1/app/prod/", http.StatusNotFound, nil},
+		{"unknown version", http.MethodGet, "/v2/app/prod", http.StatusNotFound, nil},
+		{"write", http.MethodPut, "/v1/app/prod/db.host", http.StatusMethodNotAllowed, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("got content type %q, want application/json", ct)
+			}
+			var got map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				if got["error"] == "" {
+					t.Errorf("got %v, want an error", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerUnavailable(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := srv.Config()
+	srv.Close()
+	rec := httptest.NewRecorder()
+	httpapi.New(stogo.NewStoreClient(cfg)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/app/prod", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d with StooKV down, want %d: %s", rec.Code, http.StatusServiceUnavailable, rec.Body)
+	}
+}
+
+func TestHandlerCache(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	clock := stogotest.NewFakeClock(time.Now())
+	client := stogo.NewStoreClient(srv.Config().WithClock(clock))
+	if _, err := client.Set("app", "prod", "key", "old"); err != nil {
+		t.Fatal(err)
+	}
+	handler := httpapi.New(client).WithCacheTTL(time.Minute)
+	get := func() string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/app/prod/key", nil))
+		var got map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got["value"]
+	}
+
+	if value := get(); value != "old" {
+		t.Fatalf("got %q, want old", value)
+	}
+	if _, err := client.Set("app", "prod", "key", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if value := get(); value != "old" {
+		t.Errorf("got %q within the cache TTL, want the cached old", value)
+	}
+	clock.Advance(time.Minute)
+	if value := get(); value != "new" {
+		t.Errorf("got %q after the cache TTL, want new", value)
+	}
+}
+
+func TestHandlerWatch(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	clock := stogotest.NewFakeClock(time.Now())
+	client := stogo.NewStoreClient(srv.Config().WithClock(clock))
+	if _, err := client.Set("app", "prod", "key", "old"); err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(httpapi.New(client).WithPollInterval(time.Second))
+	defer hs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hs.URL+"/v1/app/prod?watch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got content type %q, want text/event-stream", ct)
+	}
+	events := bufio.NewReader(res.Body)
+	// next reads the next event, returning its values.
+	next := func() map[string]string {
+		var event string
+		var values map[string]string
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				if event != "values" {
+					t.Fatalf("got event %q, want values", event)
+				}
+				return values
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &values); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	if values := next(); values["key"] != "old" {
+		t.Fatalf("got first event %v, want the current values", values)
+	}
+	if _, err := client.Set("app", "prod", "key", "new"); err != nil {
+		t.Fatal(err)
+	}
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	if values := next(); values["key"] != "new" {
+		t.Errorf("got event %v after the change, want the new values", values)
+	}
+}
@@ -0,0 +1,46 @@
+package stogo
+
+import "sync"
+
+// maxIdempotencyRecords max number of idempotency keys remembered by a client, oldest are evicted first.
+const maxIdempotencyRecords = 1024
+
+// idempotencyRecord remembers results of writes made with an idempotency key. The zero value is ready to use.
+type idempotencyRecord struct {
+	mu      sync.Mutex
+	results map[string]string
+	order   []string
+}
+
+// lookup returns the recorded result of key used by tenant, if any.
+func (r *idempotencyRecord) lookup(tenant, key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	key = tenant + "\x00" + key
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res, ok := r.results[key]
+	return res, ok
+}
+
+// record remembers the result of a successful write made with key by tenant.
+func (r *idempotencyRecord) record(tenant, key, result string) {
+	if key == "" {
+		return
+	}
+	key = tenant + "\x00" + key
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results == nil {
+		r.results = make(map[string]string)
+	}
+	if _, ok := r.results[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.results[key] = result
+	if len(r.order) > maxIdempotencyRecords {
+		delete(r.results, r.order[0])
+		r.order = r.order[1:]
+	}
+}
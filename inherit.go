@@ -0,0 +1,108 @@
+package stogo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrProfileCycle thrown when profile parents loop back to a profile of the chain.
+var ErrProfileCycle = errors.New("profile inheritance cycle")
+
+// profileParentKey reserved key holding the parent of a profile.
+const profileParentKey = ReservedKeyPrefix + "parent"
+
+// SetProfileParent makes profile inherit the keys of parent within a namespace, so that it only
+// needs to store the keys it overrides, see GetMerged. An empty parent removes the inheritance.
+// It fails with ErrProfileCycle if parent already inherits, directly or not, from profile.
+//
+// Usage example:
+//
+//	if err := client.SetProfileParent("my-app", "prod-eu", "prod"); err != nil {
+//		log.Fatalf("Error in setting profile parent %v", err)
+//	}
+func (c *StooClient) SetProfileParent(namespace, profile, parent string, opts ...CallOption) error {
+	if parent == "" {
+		_, err := c.Delete(namespace, profile, profileParentKey, opts...)
+		return err
+	}
+	chain, err := c.profileChain(namespace, parent, opts)
+	if err != nil {
+		return err
+	}
+	for _, p := range chain {
+		if p.name == profile {
+			return fmt.Errorf("%w: %s/%s -> %s", ErrProfileCycle, namespace, profile, chainString(chain))
+		}
+	}
+	_, err = c.Set(namespace, profile, profileParentKey, parent, opts...)
+	return err
+}
+
+// GetProfileParent returns the profile a profile inherits from, empty if none.
+func (c *StooClient) GetProfileParent(namespace, profile string, opts ...CallOption) (string, error) {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return "", err
+	}
+	return values[profileParentKey], nil
+}
+
+// GetMerged gets all keys of a profile like GetAll, along with the keys it
+// inherits from its parents, see SetProfileParent. Keys of a profile take precedence over the
+// ones of its parents. It fails with ErrProfileCycle if the parents loop.
+//
+// Usage example:
+//
+//	all, err := client.GetMerged("my-app", "prod-eu")
+//	if err != nil {
+//		log.Fatalf("Error reading merged profile %v", err)
+//	}
+func (c *StooClient) GetMerged(namespace, profile string, opts ...CallOption) (map[string]string, error) {
+	chain, err := c.profileChain(namespace, profile, opts)
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[string]string)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].values {
+			merged[k] = v
+		}
+	}
+	delete(merged, profileParentKey)
+	return merged, nil
+}
+
+// inheritedProfile profile of an inheritance chain along with its own values.
+type inheritedProfile struct {
+	name   string
+	values map[string]string
+}
+
+// profileChain returns profile followed by its parents, nearest first.
+func (c *StooClient) profileChain(namespace, profile string, opts []CallOption) ([]inheritedProfile, error) {
+	var chain []inheritedProfile
+	seen := make(map[string]bool)
+	for name := profile; name != ""; {
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s/%s", ErrProfileCycle, namespace, chainString(append(chain, inheritedProfile{name: name})))
+		}
+		seen[name] = true
+		values, err := c.GetAll(namespace, name, opts...)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, inheritedProfile{name: name, values: values})
+		name = values[profileParentKey]
+	}
+	return chain, nil
+}
+
+// chainString formats the profile names of chain.
+func chainString(chain []inheritedProfile) string {
+	names := make([]string, len(chain))
+	for i, p := range chain {
+		names[i] = p.name
+	}
+	return strings.Join(names, " -> ")
+}
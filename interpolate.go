@@ -0,0 +1,179 @@
+package stogo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrUnresolvedPlaceholder thrown when a placeholder refers to a missing key or environment
+	// variable, or is not terminated.
+	ErrUnresolvedPlaceholder = errors.New("unresolved placeholder")
+	// ErrPlaceholderCycle thrown when placeholders refer back to the key being resolved.
+	ErrPlaceholderCycle = errors.New("placeholder cycle")
+)
+
+// envPlaceholderPrefix prefix of placeholders referring to an environment variable.
+const envPlaceholderPrefix = "env:"
+
+// GetInterpolated gets the value of a key like Get, with its placeholders resolved: ${other.key}
+// is replaced by the value of other.key in the same namespace and profile, itself resolved, and
+// ${env:VAR} by the environment variable VAR. $${ is kept as a literal ${. Values holding
+// placeholders are resolved against the whole profile, read with GetAll. It fails with
+// ErrUnresolvedPlaceholder if a placeholder can't be resolved and with ErrPlaceholderCycle if
+// placeholders loop.
+//
+// Usage example:
+//
+//	// With database.host=db.internal, database.port=5432 and
+//	// database.url=postgres://${database.host}:${database.port}/app
+//	url, err := client.GetInterpolated("my-app", "prod", "database.url")
+func (c *StooClient) GetInterpolated(namespace, profile, key string, opts ...CallOption) (string, error) {
+	value, err := c.Get(namespace, profile, key, opts...)
+	if err != nil || !strings.Contains(value, "$") {
+		return value, err
+	}
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return "", err
+	}
+	values = copyValues(values)
+	// The key may only be visible through Get, e.g. when overridden.
+	values[key] = value
+	resolved, err := newInterpolator(values, os.LookupEnv).resolve(key)
+	if err != nil {
+		return "", fmt.Errorf("stogo: interpolate %s/%s: %w", namespace, profile, err)
+	}
+	return resolved, nil
+}
+
+// GetAllInterpolated gets all keys of a profile like GetAll, with the placeholders of every value
+// resolved, see GetInterpolated.
+func (c *StooClient) GetAllInterpolated(namespace, profile string, opts ...CallOption) (map[string]string, error) {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := Interpolate(values, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("stogo: interpolate %s/%s: %w", namespace, profile, err)
+	}
+	return resolved, nil
+}
+
+// Interpolate returns a copy of values with the placeholders of every value resolved against
+// values and lookupEnv, see StooClient.GetInterpolated. lookupEnv is usually os.LookupEnv, nil
+// making ${env:VAR} placeholders unresolvable. The errors of all the values failing to resolve
+// are joined together, each one once.
+func Interpolate(values map[string]string, lookupEnv func(string) (string, bool)) (map[string]string, error) {
+	in := newInterpolator(values, lookupEnv)
+	resolved := make(map[string]string, len(values))
+	var errs []error
+	seen := make(map[error]bool)
+	for _, key := range sortedKeys(values) {
+		value, err := in.resolve(key)
+		if err != nil {
+			if !seen[err] {
+				seen[err] = true
+				errs = append(errs, err)
+			}
+			continue
+		}
+		resolved[key] = value
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return resolved, nil
+}
+
+// interpolator resolves the placeholders of values, remembering the keys already resolved.
+type interpolator struct {
+	values    map[string]string
+	lookupEnv func(string) (string, bool)
+	resolved  map[string]string
+	failed    map[string]error
+	// resolving keys being resolved, in order, to detect cycles.
+	resolving []string
+}
+
+// newInterpolator creates an interpolator of values.
+func newInterpolator(values map[string]string, lookupEnv func(string) (string, bool)) *interpolator {
+	if lookupEnv == nil {
+		lookupEnv = func(string) (string, bool) { return "", false }
+	}
+	return &interpolator{values: values, lookupEnv: lookupEnv, resolved: make(map[string]string), failed: make(map[string]error)}
+}
+
+// resolve returns the value of key with its placeholders resolved, or the error of the first
+// placeholder failing to resolve, remembered so that keys sharing it report it once.
+func (in *interpolator) resolve(key string) (string, error) {
+	if value, ok := in.resolved[key]; ok {
+		return value, nil
+	}
+	if err, ok := in.failed[key]; ok {
+		return "", err
+	}
+	for i, k := range in.resolving {
+		if k == key {
+			return "", fmt.Errorf("%w: %s -> %s", ErrPlaceholderCycle, strings.Join(in.resolving[i:], " -> "), key)
+		}
+	}
+	value, ok := in.values[key]
+	if !ok {
+		return "", fmt.Errorf("%w: key %s not found", ErrUnresolvedPlaceholder, key)
+	}
+	in.resolving = append(in.resolving, key)
+	defer func() { in.resolving = in.resolving[:len(in.resolving)-1] }()
+	value, err := in.expand(key, value)
+	if err != nil {
+		in.failed[key] = err
+		return "", err
+	}
+	in.resolved[key] = value
+	return value, nil
+}
+
+// expand replaces the placeholders of value, the value of key.
+func (in *interpolator) expand(key, value string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(value, "${")
+		if i < 0 {
+			b.WriteString(value)
+			break
+		}
+		if i > 0 && value[i-1] == '$' {
+			b.WriteString(value[:i-1])
+			b.WriteString("${")
+			value = value[i+2:]
+			continue
+		}
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unterminated placeholder in %s", ErrUnresolvedPlaceholder, key)
+		}
+		b.WriteString(value[:i])
+		name := value[i+2 : i+end]
+		if env, ok := strings.CutPrefix(name, envPlaceholderPrefix); ok {
+			v, ok := in.lookupEnv(env)
+			if !ok {
+				return "", fmt.Errorf("%w: environment variable %s referenced by %s is not set", ErrUnresolvedPlaceholder, env, key)
+			}
+			b.WriteString(v)
+		} else {
+			if _, ok := in.values[name]; !ok {
+				return "", fmt.Errorf("%w: key %s referenced by %s not found", ErrUnresolvedPlaceholder, name, key)
+			}
+			v, err := in.resolve(name)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+		}
+		value = value[i+end+1:]
+	}
+	return b.String(), nil
+}
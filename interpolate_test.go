@@ -0,0 +1,161 @@
+package stogo_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/stogotest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"DB_PASSWORD": "s3cr3t"}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    map[string]string
+		wantErr []error
+	}{
+		{
+			name: "nested keys",
+			values: map[string]string{
+				"db.host": "db.internal",
+				"db.port": "5432",
+				"db.addr": "${db.host}:${db.port}",
+				"db.url":  "postgres://${db.addr}/app",
+			},
+			want: map[string]string{
+				"db.host": "db.internal",
+				"db.port": "5432",
+				"db.addr": "db.internal:5432",
+				"db.url":  "postgres://db.internal:5432/app",
+			},
+		},
+		{
+			name:   "environment variable",
+			values: map[string]string{"db.url": "postgres://app:${env:DB_PASSWORD}@db"},
+			want:   map[string]string{"db.url": "postgres://app:s3cr3t@db"},
+		},
+		{
+			name:   "escaped placeholder",
+			values: map[string]string{"template": "$${db.host} costs $5", "db.host": "db.internal"},
+			want:   map[string]string{"template": "${db.host} costs $5", "db.host": "db.internal"},
+		},
+		{
+			name:    "missing key",
+			values:  map[string]string{"db.url": "postgres://${db.host}/app"},
+			wantErr: []error{stogo.ErrUnresolvedPlaceholder},
+		},
+		{
+			name:    "unset environment variable",
+			values:  map[string]string{"db.url": "postgres://app:${env:DB_USER}@db"},
+			wantErr: []error{stogo.ErrUnresolvedPlaceholder},
+		},
+		{
+			name:    "unterminated placeholder",
+			values:  map[string]string{"db.url": "postgres://${db.host/app", "db.host": "db.internal"},
+			wantErr: []error{stogo.ErrUnresolvedPlaceholder},
+		},
+		{
+			name:    "cycle",
+			values:  map[string]string{"a": "${b}", "b": "${c}", "c": "${a}"},
+			wantErr: []error{stogo.ErrPlaceholderCycle},
+		},
+		{
+			name:    "self reference",
+			values:  map[string]string{"a": "x${a}"},
+			wantErr: []error{stogo.ErrPlaceholderCycle},
+		},
+		{
+			name: "several problems",
+			values: map[string]string{
+				"a":       "${missing}",
+				"b":       "${a}",
+				"c":       "${d}",
+				"d":       "${c}",
+				"db.host": "db.internal",
+			},
+			wantErr: []error{stogo.ErrUnresolvedPlaceholder, stogo.ErrPlaceholderCycle},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stogo.Interpolate(tt.values, lookupEnv)
+			if len(tt.wantErr) > 0 {
+				for _, want := range tt.wantErr {
+					if !errors.Is(err, want) {
+						t.Errorf("got error %v, want %v", err, want)
+					}
+				}
+				if got != nil {
+					t.Errorf("got %v along with an error, want nil", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateReportsSharedErrorOnce(t *testing.T) {
+	values := map[string]string{"a": "${missing}", "b": "${a}", "c": "${a}"}
+	_, err := stogo.Interpolate(values, nil)
+	if n := strings.Count(err.Error(), "missing"); n != 1 {
+		t.Errorf("got error %q, want the missing key reported once", err)
+	}
+}
+
+func TestGetInterpolated(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	client := stogo.NewStoreClient(srv.Config())
+	for key, value := range map[string]string{
+		"db.host": "db.internal",
+		"db.url":  "postgres://${db.host}/app",
+		"broken":  "${missing}",
+	} {
+		if _, err := client.Set("app", "prod", key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if value, err := client.GetInterpolated("app", "prod", "db.url"); err != nil || value != "postgres://db.internal/app" {
+		t.Errorf("got %q, %v, want the resolved URL", value, err)
+	}
+	if value, err := client.GetInterpolated("app", "prod", "db.host"); err != nil || value != "db.internal" {
+		t.Errorf("got %q, %v, want the value without placeholders", value, err)
+	}
+	if _, err := client.GetInterpolated("app", "prod", "broken"); !errors.Is(err, stogo.ErrUnresolvedPlaceholder) {
+		t.Errorf("got error %v, want %v", err, stogo.ErrUnresolvedPlaceholder)
+	}
+	if _, err := client.GetInterpolated("app", "prod", "db.user"); !errors.Is(err, stogo.ErrKeyNotFound) {
+		t.Errorf("got error %v, want %v", err, stogo.ErrKeyNotFound)
+	}
+	if _, err := client.GetAllInterpolated("app", "prod"); !errors.Is(err, stogo.ErrUnresolvedPlaceholder) {
+		t.Errorf("got error %v from GetAllInterpolated, want %v", err, stogo.ErrUnresolvedPlaceholder)
+	}
+
+	if _, err := client.Delete("app", "prod", "broken"); err != nil {
+		t.Fatal(err)
+	}
+	all, err := client.GetAllInterpolated("app", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"db.host": "db.internal", "db.url": "postgres://db.internal/app"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("got %v, want %v", all, want)
+	}
+}
@@ -0,0 +1,103 @@
+package stogo
+
+// KeyValue entry of a namespace and profile returned by an Iterator.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Iterator walks the entries of a namespace and profile in key order, see StooClient.Iter. It
+// is not safe for concurrent use.
+type Iterator struct {
+	fetch   func() (map[string]string, error)
+	fetched bool
+	values  map[string]string
+	keys    []string
+	current KeyValue
+	err     error
+}
+
+// Iter returns an Iterator over the entries of a namespace and profile. Nothing is read until
+// the first call to Next, errors being reported by Err once Next returns false. StooKV returns
+// a profile in a single response, so the iterator holds it whole, but entries are handed out one
+// at a time without building another map, and callers stopping early skip the rest.
+//
+// Usage example:
+//
+//	it := client.Iter("my-app", "prod")
+//	for it.Next() {
+//		fmt.Printf("%s=%s\n", it.Key(), it.Value())
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatalf("Error reading profile %v", err)
+//	}
+func (c *StooClient) Iter(namespace, profile string, opts ...CallOption) *Iterator {
+	return &Iterator{fetch: func() (map[string]string, error) {
+		return c.GetAll(namespace, profile, opts...)
+	}}
+}
+
+// Next moves to the next entry, reading the profile on the first call, and tells if there is
+// one.
+func (it *Iterator) Next() bool {
+	if !it.fetched {
+		it.fetched = true
+		it.values, it.err = it.fetch()
+		if it.err != nil {
+			return false
+		}
+		it.keys = sortedKeys(it.values)
+	}
+	if len(it.keys) == 0 {
+		it.current = KeyValue{}
+		return false
+	}
+	key := it.keys[0]
+	it.keys = it.keys[1:]
+	it.current = KeyValue{Key: key, Value: it.values[key]}
+	return true
+}
+
+// Key returns the key of the current entry.
+func (it *Iterator) Key() string {
+	return it.current.Key
+}
+
+// Value returns the value of the current entry.
+func (it *Iterator) Value() string {
+	return it.current.Value
+}
+
+// Entry returns the current entry.
+func (it *Iterator) Entry() KeyValue {
+	return it.current
+}
+
+// Err returns the error met reading the profile, nil if there was none.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// All returns a function yielding the remaining entries until yield returns false, with the
+// signature of iter.Seq so it can be ranged over from Go 1.23. Errors are reported by Err once
+// it returns.
+//
+// Usage example:
+//
+//	it := client.Iter("my-app", "prod")
+//	it.All()(func(kv stogo.KeyValue) bool {
+//		fmt.Printf("%s=%s\n", kv.Key, kv.Value)
+//		return true
+//	})
+//	if err := it.Err(); err != nil {
+//		log.Fatalf("Error reading profile %v", err)
+//	}
+func (it *Iterator) All() func(yield func(KeyValue) bool) {
+	return func(yield func(KeyValue) bool) {
+		for it.Next() {
+			if !yield(it.current) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package stogo
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/mwangox/stogo/config"
+	"io"
+	"sync"
+	"time"
+)
+
+// MutationRecord line of a mutation log, see config.StooConfig.WithMutationLog.
+type MutationRecord struct {
+	// Seq position of the write among the ones logged by the client, starting at 1.
+	Seq uint64 `json:"seq"`
+	// Time time the write completed at.
+	Time time.Time `json:"time"`
+	// Op kind of write.
+	Op config.AuditOp `json:"op"`
+	// Namespace namespace written to.
+	Namespace string `json:"namespace"`
+	// Profile profile written to.
+	Profile string `json:"profile"`
+	// Key key written.
+	Key string `json:"key"`
+	// Value value written, MaskedValue for secrets and empty for deletes.
+	Value string `json:"value,omitempty"`
+	// Secret tells the value is a secret.
+	Secret bool `json:"secret,omitempty"`
+	// Identity caller identity, see WithIdentity.
+	Identity string `json:"identity,omitempty"`
+	// DryRun tells the write was only logged, see config.StooConfig.WithDryRun.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Result response of StooKV to a successful write.
+	Result string `json:"result,omitempty"`
+	// Error error the write failed with, empty on success.
+	Error string `json:"error,omitempty"`
+}
+
+// ReadMutationLog reads the records of a mutation log, in order.
+//
+// Usage example:
+//
+//	records, err := stogo.ReadMutationLog(f)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, r := range records {
+//		fmt.Printf("%d %s %s %s/%s/%s\n", r.Seq, r.Time.Format(time.RFC3339), r.Op, r.Namespace, r.Profile, r.Key)
+//	}
+func ReadMutationLog(r io.Reader) ([]MutationRecord, error) {
+	var records []MutationRecord
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for decoder.More() {
+		var record MutationRecord
+		if err := decoder.Decode(&record); err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// journal numbers and serializes the records written to a mutation log. The zero value is ready to use.
+type journal struct {
+	mu  sync.Mutex
+	seq uint64
+}
+
+// write appends the record of event and its result to w.
+func (j *journal) write(w io.Writer, event config.AuditEvent, result string) error {
+	record := MutationRecord{
+		Time:      event.Time,
+		Op:        event.Op,
+		Namespace: event.Namespace,
+		Profile:   event.Profile,
+		Key:       event.Key,
+		Value:     event.Value,
+		Secret:    event.Secret,
+		Identity:  event.Identity,
+		DryRun:    event.DryRun,
+		Result:    result,
+	}
+	if event.Err != nil {
+		record.Error = event.Err.Error()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seq++
+	record.Seq = j.seq
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,55 @@
+package stogo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrValueTooLarge thrown when writing a value bigger than config.StooConfig.WithMaxValueSize.
+var ErrValueTooLarge = errors.New("value too large")
+
+// SetJSON marshals v to JSON and sets it like Set. Combine it with WithCodec to compress
+// documents and config.StooConfig.WithMaxValueSize to bound their size.
+//
+// Usage example:
+//
+//	limits := map[string]int{"daily": 500, "monthly": 10000}
+//	if _, err := client.SetJSON("my-app", "prod", "payments.limits", limits); err != nil {
+//		log.Fatalf("Error in setting value %v", err)
+//	}
+func (c *StooClient) SetJSON(namespace, profile, key string, v any, opts ...CallOption) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("stogo: marshalling %s/%s/%s: %w", namespace, profile, key, err)
+	}
+	return c.Set(namespace, profile, key, string(data), opts...)
+}
+
+// GetJSON gets a value like Get and unmarshals it from JSON into out. Values written with
+// WithCodec are decoded first.
+//
+// Usage example:
+//
+//	var limits map[string]int
+//	if err := client.GetJSON("my-app", "prod", "payments.limits", &limits); err != nil {
+//		log.Fatalf("Error reading value %v", err)
+//	}
+func (c *StooClient) GetJSON(namespace, profile, key string, out any, opts ...CallOption) error {
+	value, err := c.Get(namespace, profile, key, opts...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(value), out); err != nil {
+		return fmt.Errorf("stogo: unmarshalling %s/%s/%s: %w", namespace, profile, key, err)
+	}
+	return nil
+}
+
+// checkValueSize fails with ErrValueTooLarge if value exceeds the configured max value size.
+func (c *StooClient) checkValueSize(namespace, profile, key, value string) error {
+	if max := c.CurrentConfig().GetMaxValueSize(); max > 0 && len(value) > max {
+		return fmt.Errorf("%w: %s/%s/%s is %d bytes, max %d", ErrValueTooLarge, namespace, profile, key, len(value), max)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Package k8ssync keeps a Kubernetes ConfigMap or Secret in sync with a StooKV namespace and
+// profile, to be embedded in an operator or run as a sidecar. It doesn't depend on a
+// Kubernetes client library: objects are read and written through a Store, typically a few
+// lines wrapping client-go.
+//
+// Usage example:
+//
+//	syncer := k8ssync.New(client, "my-app", "prod", store, k8ssync.Target{
+//		Kind:      k8ssync.KindSecret,
+//		Namespace: "payments",
+//		Name:      "my-app-config",
+//	})
+//	go syncer.Run(ctx)
+package k8ssync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound thrown by Store.Get when the object doesn't exist.
+var ErrNotFound = errors.New("k8ssync: object not found")
+
+// Kind kind of Kubernetes object values are written to.
+type Kind string
+
+const (
+	// KindConfigMap values are written to the data of a ConfigMap.
+	KindConfigMap Kind = "ConfigMap"
+	// KindSecret values are written to the data of a Secret. Stores handle the base64 encoding
+	// of Secret data, values are exchanged in plain text.
+	KindSecret Kind = "Secret"
+)
+
+// Target Kubernetes object values are synced to.
+type Target struct {
+	Kind      Kind
+	Namespace string
+	Name      string
+}
+
+// String formats t as kind/namespace/name.
+func (t Target) String() string {
+	return fmt.Sprintf("%s/%s/%s", t.Kind, t.Namespace, t.Name)
+}
+
+// Store reads and writes Kubernetes objects. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the data of the target object, ErrNotFound if it doesn't exist.
+	Get(ctx context.Context, target Target) (map[string]string, error)
+	// Put creates or replaces the data of the target object.
+	Put(ctx context.Context, target Target, data map[string]string) error
+}
+
+// invalidKeyChars characters not allowed in ConfigMap and Secret keys.
+var invalidKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// DefaultKeyMapper maps StooKV keys to ConfigMap and Secret keys by replacing the characters
+// Kubernetes doesn't allow with '-'. Bookkeeping keys written by stogo are left out.
+func DefaultKeyMapper(key string) (string, bool) {
+	if stogo.IsReservedKey(key) {
+		return "", false
+	}
+	return invalidKeyChars.ReplaceAllString(key, "-"), true
+}
+
+// Syncer writes the values of a namespace and profile to a Kubernetes object and, in reverse
+// mode, writes changes made to the object back to StooKV.
+type Syncer struct {
+	client    *stogo.StooClient
+	namespace string
+	profile   string
+	store     Store
+	target    Target
+	keyMapper func(key string) (string, bool)
+	reverse   bool
+	interval  time.Duration
+
+	mu   sync.Mutex
+	last map[string]string
+}
+
+// New creates a Syncer writing a namespace and profile to target through store.
+func New(client *stogo.StooClient, namespace, profile string, store Store, target Target) *Syncer {
+	return &Syncer{
+		client:    client,
+		namespace: namespace,
+		profile:   profile,
+		store:     store,
+		target:    target,
+		keyMapper: DefaultKeyMapper,
+	}
+}
+
+// WithKeyMapper sets the function mapping StooKV keys to object keys, returning false for
+// keys which must not be synced. Mapped keys must be unique.
+func (s *Syncer) WithKeyMapper(keyMapper func(key string) (string, bool)) *Syncer {
+	s.keyMapper = keyMapper
+	return s
+}
+
+// WithReverse enables writing back to StooKV the object keys changed in Kubernetes since the
+// last sync, instead of overwriting them. Keys deleted from the object are restored. Object
+// keys unknown to StooKV are written as they are.
+func (s *Syncer) WithReverse(reverse bool) *Syncer {
+	s.reverse = reverse
+	return s
+}
+
+// WithInterval sets the interval between syncs of Run, the configured poll interval by default.
+func (s *Syncer) WithInterval(interval time.Duration) *Syncer {
+	s.interval = interval
+	return s
+}
+
+// SyncOnce syncs the object with StooKV, writing it only if its data differs.
+func (s *Syncer) SyncOnce(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, err := s.client.GetAll(s.namespace, s.profile, stogo.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	current, err := s.store.Get(ctx, s.target)
+	if errors.Is(err, ErrNotFound) {
+		current, err = nil, nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", s.target, err)
+	}
+
+	desired, sources, err := s.mapKeys(values)
+	if err != nil {
+		return err
+	}
+	if s.reverse && s.last != nil {
+		changed := false
+		for _, key := range sortedKeys(current) {
+			if value, ok := s.last[key]; ok && value == current[key] {
+				continue
+			}
+			if desired[key] == current[key] {
+				continue
+			}
+			source, ok := sources[key]
+			if !ok {
+				source = key
+			}
+			if _, err := s.client.Set(s.namespace, s.profile, source, current[key], stogo.WithContext(ctx)); err != nil {
+				return fmt.Errorf("writing back %s: %w", source, err)
+			}
+			desired[key] = current[key]
+			changed = true
+		}
+		if changed {
+			s.client.CurrentConfig().GetLogger().Infof("k8ssync %s: wrote back changes to %s/%s", s.target, s.namespace, s.profile)
+		}
+	}
+
+	if current == nil || !equal(current, desired) {
+		if err := s.store.Put(ctx, s.target, desired); err != nil {
+			return fmt.Errorf("writing %s: %w", s.target, err)
+		}
+	}
+	s.last = desired
+	return nil
+}
+
+// Run calls SyncOnce every interval until ctx is done and returns ctx.Err(). Failures are
+// logged and retried on the next run.
+func (s *Syncer) Run(ctx context.Context) error {
+	cfg := s.client.CurrentConfig()
+	interval := s.interval
+	if interval <= 0 {
+		interval = cfg.GetPollInterval()
+	}
+	for {
+		if err := s.SyncOnce(ctx); err != nil && ctx.Err() == nil {
+			cfg.GetLogger().Warnf("k8ssync %s: %v", s.target, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-cfg.GetClock().After(interval):
+		}
+	}
+}
+
+// mapKeys maps values to object keys, returning the StooKV key each object key comes from.
+func (s *Syncer) mapKeys(values map[string]string) (map[string]string, map[string]string, error) {
+	data := make(map[string]string, len(values))
+	sources := make(map[string]string, len(values))
+	for _, key := range sortedKeys(values) {
+		mapped, ok := s.keyMapper(key)
+		if !ok {
+			continue
+		}
+		if other, dup := sources[mapped]; dup {
+			return nil, nil, fmt.Errorf("keys %s and %s both map to %s", other, key, mapped)
+		}
+		data[mapped] = values[key]
+		sources[mapped] = key
+	}
+	return data, sources, nil
+}
+
+// equal reports whether a and b hold the same key value pairs.
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of values in ascending order.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,51 @@
+package stogo
+
+// KeyInfo describes a key without its value.
+type KeyInfo struct {
+	// Key name of the key.
+	Key string
+	// Size length of the value in bytes, as returned by StooKV.
+	Size int
+	// Secret tells if the key matches the configured secret key patterns.
+	Secret bool
+}
+
+// ListKeys returns the keys of a namespace and profile in ascending order, leaving out the
+// bookkeeping keys written by stogo, for inventories which must not hand values around. StooKV
+// has no call listing keys only, so values are still fetched but dropped right away.
+//
+// Usage example:
+//
+//	keys, err := client.ListKeys("my-app", "prod")
+//	if err != nil {
+//		log.Fatalf("Error listing keys %v", err)
+//	}
+func (c *StooClient) ListKeys(namespace, profile string, opts ...CallOption) ([]string, error) {
+	infos, err := c.ListKeyInfos(namespace, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(infos))
+	for i, info := range infos {
+		keys[i] = info.Key
+	}
+	return keys, nil
+}
+
+// ListKeyInfos returns the keys of a namespace and profile like ListKeys, along with the size
+// of their values and whether they are secret.
+func (c *StooClient) ListKeyInfos(namespace, profile string, opts ...CallOption) ([]KeyInfo, error) {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	cfg := c.CurrentConfig()
+	infos := make([]KeyInfo, 0, len(values))
+	for _, key := range sortedKeys(values) {
+		if IsReservedKey(key) {
+			continue
+		}
+		infos = append(infos, KeyInfo{Key: key, Size: len(values[key]), Secret: cfg.IsSecretKey(key)})
+	}
+	return infos, nil
+}
@@ -0,0 +1,110 @@
+package stogo
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// limits enforces the configured rate limit and max calls in flight. Limits are read from the
+// current configuration on every call, so they can be changed with UpdateConfig. The zero value
+// is ready to use.
+type limits struct {
+	mu       sync.Mutex
+	tokens   float64
+	filledAt time.Time
+	inFlight int
+	released chan struct{}
+}
+
+// interceptor makes every call wait for the limits of c before being sent.
+func (l *limits) interceptor(c *StooClient) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := l.waitRate(ctx, c); err != nil {
+			return err
+		}
+		acquired, err := l.acquire(ctx, c.CurrentConfig().GetMaxInFlight())
+		if err != nil {
+			return err
+		}
+		if acquired {
+			defer l.release()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// waitRate waits for a token of the rate limit token bucket.
+func (l *limits) waitRate(ctx context.Context, c *StooClient) error {
+	for {
+		cfg := c.CurrentConfig()
+		rps, burst := cfg.GetRateLimit()
+		if rps == 0 {
+			return nil
+		}
+		now := cfg.GetClock().Now()
+		l.mu.Lock()
+		if l.filledAt.IsZero() {
+			l.tokens = float64(burst)
+		} else {
+			l.tokens += now.Sub(l.filledAt).Seconds() * rps
+		}
+		if l.tokens > float64(burst) {
+			l.tokens = float64(burst)
+		}
+		l.filledAt = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / rps * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-cfg.GetClock().After(wait):
+		}
+	}
+}
+
+// acquire waits for a call slot, reporting whether one was taken, which is not the case when
+// max is 0 and calls are not limited.
+func (l *limits) acquire(ctx context.Context, max int) (bool, error) {
+	if max == 0 {
+		return false, nil
+	}
+	for {
+		l.mu.Lock()
+		if l.inFlight < max {
+			l.inFlight++
+			l.mu.Unlock()
+			return true, nil
+		}
+		if l.released == nil {
+			l.released = make(chan struct{})
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false, status.FromContextError(ctx.Err()).Err()
+		case <-released:
+		}
+	}
+}
+
+// release frees a call slot and wakes up the calls waiting for one.
+func (l *limits) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	if l.released != nil {
+		close(l.released)
+		l.released = nil
+	}
+}
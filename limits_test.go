@@ -0,0 +1,97 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingServer holds the first read until release is closed, telling it arrived on started.
+type blockingServer struct {
+	proto.UnimplementedKVServiceServer
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingServer) GetService(context.Context, *proto.GetRequest) (*proto.GetResponse, error) {
+	s.once.Do(func() {
+		close(s.started)
+		<-s.release
+	})
+	return &proto.GetResponse{Data: "value"}, nil
+}
+
+func TestLimitsDoNotOpenBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  func(*config.StooConfig) *config.StooConfig
+	}{
+		{"max in flight", func(cfg *config.StooConfig) *config.StooConfig {
+			return cfg.WithMaxInFlight(1)
+		}},
+		{"rate limit", func(cfg *config.StooConfig) *config.StooConfig {
+			return cfg.WithRateLimit(0.1, 1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			kv := &blockingServer{started: make(chan struct{}), release: make(chan struct{})}
+			server := grpc.NewServer()
+			proto.RegisterKVServiceServer(server, kv)
+			go server.Serve(lis)
+			defer server.Stop()
+
+			client := stogo.NewStoreClient(tt.cfg(config.NewStooConfig(lis.Addr().String(), 10*time.Second).
+				WithCircuitBreaker(config.Breaker{ErrorRate: 0.5, MinRequests: 2, Window: time.Minute, OpenDuration: time.Minute})))
+
+			// The first call takes the only slot or token and holds it until the others gave up.
+			first := make(chan error, 1)
+			go func() {
+				_, err := client.Get("app", "prod", "key")
+				first <- err
+			}()
+			<-kv.started
+			client.UpdateConfig(func(cfg *config.StooConfig) {
+				cfg.WithReadTimeout(100 * time.Millisecond)
+			})
+			var wg sync.WaitGroup
+			errs := make([]error, 4)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, errs[i] = client.Get("app", "prod", "key")
+				}(i)
+			}
+			wg.Wait()
+			close(kv.release)
+			if err := <-first; err != nil {
+				t.Fatal(err)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("got error %v, want the call to time out waiting for the limits", err)
+				}
+			}
+
+			client.UpdateConfig(func(cfg *config.StooConfig) {
+				cfg.WithMaxInFlight(-1).WithRateLimit(0, 0)
+			})
+			if _, err := client.Get("app", "prod", "key"); errors.Is(err, stogo.ErrCircuitOpen) {
+				t.Fatal("circuit opened after calls timed out waiting for the limits")
+			}
+		})
+	}
+}
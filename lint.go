@@ -0,0 +1,107 @@
+package stogo
+
+import (
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/envelope"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LintRule kind of suspicious entry reported by Lint.
+type LintRule string
+
+const (
+	// LintCaseDuplicate keys differing only by case, likely the same setting written twice.
+	LintCaseDuplicate LintRule = "case-duplicate"
+	// LintEmptyValue keys holding an empty or blank value.
+	LintEmptyValue LintRule = "empty-value"
+	// LintPlainSecret keys matching the secret key patterns whose value is stored in plain
+	// text, i.e. written with Set rather than SetSecret. Only reported when an encrypter is
+	// configured, as values are otherwise stored the same way.
+	LintPlainSecret LintRule = "plain-secret"
+	// LintKeyName keys not matching the key name pattern, see
+	// config.StooConfig.WithKeyNamePattern.
+	LintKeyName LintRule = "key-name"
+)
+
+// LintIssue suspicious entry found by Lint.
+type LintIssue struct {
+	// Rule rule the entry breaks.
+	Rule LintRule
+	// Key key of the entry.
+	Key string
+	// Message description of the issue.
+	Message string
+}
+
+// String formats the issue as key: message [rule].
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s [%s]", i.Key, i.Message, i.Rule)
+}
+
+// Lint checks the keys of a namespace and profile for suspicious entries: keys differing only
+// by case, empty values, secrets stored in plain text and keys not following the key name
+// convention. It returns the issues sorted by key, empty if there are none, so that CI can
+// check a profile before promoting it. Bookkeeping keys written by stogo are left out.
+//
+// Usage example:
+//
+//	issues, err := client.Lint("my-app", "prod")
+//	if err != nil {
+//		log.Fatalf("Error linting %v", err)
+//	}
+//	for _, issue := range issues {
+//		log.Println(issue)
+//	}
+func (c *StooClient) Lint(namespace, profile string, opts ...CallOption) ([]LintIssue, error) {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return LintValues(c.CurrentConfig(), values)
+}
+
+// LintValues checks values like Lint, with the secret key patterns, encrypter and key name
+// pattern of cfg.
+func LintValues(cfg *config.StooConfig, values map[string]string) ([]LintIssue, error) {
+	keyName, err := regexp.Compile(cfg.GetKeyNamePattern())
+	if err != nil {
+		return nil, fmt.Errorf("stogo: key name pattern: %w", err)
+	}
+	var issues []LintIssue
+	folded := make(map[string][]string)
+	for _, key := range sortedKeys(values) {
+		if IsReservedKey(key) {
+			continue
+		}
+		value := values[key]
+		lower := strings.ToLower(key)
+		folded[lower] = append(folded[lower], key)
+		if strings.TrimSpace(value) == "" {
+			issues = append(issues, LintIssue{Rule: LintEmptyValue, Key: key, Message: "value is empty"})
+		}
+		if cfg.GetEncrypter() != nil && cfg.IsSecretKey(key) && value != "" && !envelope.IsEncrypted(value) {
+			issues = append(issues, LintIssue{Rule: LintPlainSecret, Key: key, Message: "secret is stored in plain text, write it with SetSecret"})
+		}
+		if !keyName.MatchString(key) {
+			issues = append(issues, LintIssue{Rule: LintKeyName, Key: key, Message: fmt.Sprintf("key does not match %s", keyName)})
+		}
+	}
+	for _, keys := range folded {
+		if len(keys) < 2 {
+			continue
+		}
+		for _, key := range keys {
+			issues = append(issues, LintIssue{Rule: LintCaseDuplicate, Key: key, Message: fmt.Sprintf("keys %s differ only by case", strings.Join(keys, ", "))})
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Key != issues[j].Key {
+			return issues[i].Key < issues[j].Key
+		}
+		return issues[i].Rule < issues[j].Rule
+	})
+	return issues, nil
+}
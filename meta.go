@@ -0,0 +1,101 @@
+package stogo
+
+import (
+	"errors"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"time"
+)
+
+// Response metadata keys StooKV may report value metadata with.
+const (
+	metaModifiedAt = "stookv-modified-at"
+	metaVersion    = "stookv-version"
+	metaAuthor     = "stookv-author"
+)
+
+// ErrNoValueMeta thrown by GetWithMeta when StooKV reported no metadata for the value, e.g.
+// because it doesn't support it, so that unknown metadata isn't mistaken for a value which was
+// never modified.
+var ErrNoValueMeta = errors.New("stooKV reported no value metadata")
+
+// ValueMeta metadata of a value, as reported by StooKV. Fields the server doesn't report are
+// left empty.
+type ValueMeta struct {
+	// ModifiedAt time the value was last written, zero if unknown.
+	ModifiedAt time.Time
+	// Version version of the value, incremented on every write, 0 if unknown.
+	Version int64
+	// Author identity which last wrote the value, empty if unknown.
+	Author string
+}
+
+// Known tells if StooKV reported any metadata.
+func (m ValueMeta) Known() bool {
+	return !m.ModifiedAt.IsZero() || m.Version != 0 || m.Author != ""
+}
+
+// GetWithMeta gets a value like Get along with its metadata, which StooKV reports in the
+// stookv-modified-at (RFC 3339), stookv-version and stookv-author response headers or trailers
+// when it supports it. The KV protocol has no metadata of its own and servers which don't send
+// these headers make GetWithMeta return the value along with an error matching ErrNoValueMeta.
+// Metadata is only known from StooKV itself, so the call always reaches it, bypassing
+// overrides, caches and the fallback file.
+//
+// Usage example:
+//
+//	value, meta, err := client.GetWithMeta("my-app", "prod", "database.url")
+//	switch {
+//	case errors.Is(err, stogo.ErrNoValueMeta):
+//		log.Printf("no metadata for %s", value)
+//	case err == nil:
+//		log.Printf("last changed by %s at %s", meta.Author, meta.ModifiedAt)
+//	}
+func (c *StooClient) GetWithMeta(namespace, profile, key string, opts ...CallOption) (string, ValueMeta, error) {
+	o := newCallOptions(opts)
+	ctx, cancel := c.newContext(namespace, o)
+	defer cancel()
+
+	var header, trailer metadata.MD
+	res, err := c.kv(namespace).GetService(ctx, &proto.GetRequest{
+		Namespace: namespace,
+		Profile:   profile,
+		Key:       key,
+	}, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return "", ValueMeta{}, keyNotFound(namespace, profile, key, err)
+		}
+		return "", ValueMeta{}, callError("get", namespace, profile, key, err)
+	}
+	if res == nil {
+		return "", ValueMeta{}, callError("get", namespace, profile, key, ErrEmptyResponse)
+	}
+	if err := c.confirmEmpty(namespace, profile, key, res.GetData(), o); err != nil {
+		return "", ValueMeta{}, err
+	}
+	meta := parseValueMeta(metadata.Join(header, trailer))
+	if !meta.Known() {
+		return res.GetData(), meta, callError("get", namespace, profile, key, ErrNoValueMeta)
+	}
+	return res.GetData(), meta, nil
+}
+
+// parseValueMeta reads value metadata from md, ignoring malformed entries.
+func parseValueMeta(md metadata.MD) ValueMeta {
+	var meta ValueMeta
+	if v := md.Get(metaModifiedAt); len(v) > 0 {
+		meta.ModifiedAt, _ = time.Parse(time.RFC3339Nano, v[0])
+	}
+	if v := md.Get(metaVersion); len(v) > 0 {
+		meta.Version, _ = strconv.ParseInt(v[0], 10, 64)
+	}
+	if v := md.Get(metaAuthor); len(v) > 0 {
+		meta.Author = v[0]
+	}
+	return meta
+}
@@ -0,0 +1,75 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"net"
+	"testing"
+	"time"
+)
+
+// metaServer answers every read with value and header.
+type metaServer struct {
+	proto.UnimplementedKVServiceServer
+	header metadata.MD
+}
+
+func (s *metaServer) GetService(ctx context.Context, _ *proto.GetRequest) (*proto.GetResponse, error) {
+	grpc.SetHeader(ctx, s.header)
+	return &proto.GetResponse{Data: "value"}, nil
+}
+
+func TestGetWithMeta(t *testing.T) {
+	modifiedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		header  metadata.MD
+		want    stogo.ValueMeta
+		wantErr error
+	}{
+		{
+			name:    "no metadata",
+			header:  metadata.MD{},
+			wantErr: stogo.ErrNoValueMeta,
+		},
+		{
+			name:    "malformed metadata",
+			header:  metadata.Pairs("stookv-version", "v2", "stookv-modified-at", "yesterday"),
+			wantErr: stogo.ErrNoValueMeta,
+		},
+		{
+			name:   "every field",
+			header: metadata.Pairs("stookv-version", "7", "stookv-modified-at", modifiedAt.Format(time.RFC3339), "stookv-author", "ci"),
+			want:   stogo.ValueMeta{ModifiedAt: modifiedAt, Version: 7, Author: "ci"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := grpc.NewServer()
+			proto.RegisterKVServiceServer(server, &metaServer{header: tt.header})
+			go server.Serve(lis)
+			defer server.Stop()
+
+			client := stogo.NewStoreClient(config.NewStooConfig(lis.Addr().String(), time.Second))
+			value, meta, err := client.GetWithMeta("app", "prod", "key")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if value != "value" {
+				t.Errorf("got value %q, want %q", value, "value")
+			}
+			if meta != tt.want {
+				t.Errorf("got meta %+v, want %+v", meta, tt.want)
+			}
+		})
+	}
+}
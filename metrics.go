@@ -0,0 +1,36 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"google.golang.org/grpc"
+	"path"
+)
+
+// metricsInterceptor reports the outcome of every call to the configured metrics, with the
+// trace ID of slow calls as an exemplar when tracing is set up.
+func metricsInterceptor(c *StooClient) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		cfg := c.CurrentConfig()
+		metrics := cfg.GetMetrics()
+		if metrics == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		clock := cfg.GetClock()
+		start := clock.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		call := config.CallMetric{
+			Method:   path.Base(method),
+			Duration: clock.Now().Sub(start),
+			Err:      err,
+		}
+		if r, ok := req.(interface{ GetNamespace() string }); ok {
+			call.Namespace = r.GetNamespace()
+		}
+		if traceID := cfg.GetTracing(); traceID != nil && call.Duration >= cfg.GetExemplarThreshold() {
+			call.TraceID = traceID(ctx)
+		}
+		metrics.ObserveCall(call)
+		return err
+	}
+}
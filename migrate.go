@@ -0,0 +1,210 @@
+package stogo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/export"
+	"os"
+	"strings"
+)
+
+// ErrNoProfiles thrown by MigrateTo when no profile to migrate is given.
+var ErrNoProfiles = errors.New("no profiles to migrate, StooKV can't list them")
+
+// MigrateOption configures MigrateTo.
+type MigrateOption func(*migrateOptions)
+
+// migrateOptions holds settings of a migration.
+type migrateOptions struct {
+	profiles   []string
+	policy     ConflictPolicy
+	checkpoint string
+	progress   func(MigrateProgress)
+}
+
+// MigrateProgress progress of a migration, reported after each namespace and profile.
+type MigrateProgress struct {
+	// Namespace and Profile just migrated or skipped as already done.
+	Namespace, Profile string
+	// Keys keys written for this namespace and profile.
+	Keys int
+	// Done namespaces and profiles migrated so far, including the ones of a previous run.
+	Done int
+	// Total namespaces and profiles to migrate.
+	Total int
+}
+
+// WithProfiles sets the profiles migrated in every namespace. StooKV has no call listing the
+// profiles of a namespace, so they must be given.
+func WithProfiles(profiles ...string) MigrateOption {
+	return func(o *migrateOptions) {
+		o.profiles = profiles
+	}
+}
+
+// WithMigrateConflictPolicy sets what happens to keys which already exist in the target,
+// Overwrite by default. With Fail, the conflicting keys of a profile are reported and none of
+// its keys are written.
+func WithMigrateConflictPolicy(policy ConflictPolicy) MigrateOption {
+	return func(o *migrateOptions) {
+		o.policy = policy
+	}
+}
+
+// WithCheckpoint records the namespaces and profiles migrated in file path, so that a
+// migration run again with the same checkpoint resumes where it stopped.
+func WithCheckpoint(path string) MigrateOption {
+	return func(o *migrateOptions) {
+		o.checkpoint = path
+	}
+}
+
+// WithMigrateProgress sets a function called after each namespace and profile.
+func WithMigrateProgress(fn func(MigrateProgress)) MigrateOption {
+	return func(o *migrateOptions) {
+		o.progress = fn
+	}
+}
+
+// migrateCheckpoint content of a checkpoint file.
+type migrateCheckpoint struct {
+	Done []string `json:"done"`
+}
+
+// MigrateTo copies every key of the given namespaces and profiles, see WithProfiles, to the
+// StooKV instance target is connected to, e.g. for a datacenter move. Keys matching the secret
+// key patterns and values encrypted by the envelope package are written as secrets, as they
+// are, so client-side encrypted values stay readable with the same keys. Bookkeeping keys are
+// copied too. Every key is attempted, failures are reported per namespace/profile/key in the
+// result. The returned error reports a failure to read a profile or to save the checkpoint,
+// which stops the migration; run it again with the same checkpoint to resume.
+//
+// Usage example:
+//
+//	res, err := oldClient.MigrateTo(newClient, []string{"my-app", "billing"},
+//		stogo.WithProfiles("dev", "staging", "prod"),
+//		stogo.WithCheckpoint("migration.json"),
+//		stogo.WithMigrateProgress(func(p stogo.MigrateProgress) {
+//			log.Printf("%d/%d %s/%s: %d keys", p.Done, p.Total, p.Namespace, p.Profile, p.Keys)
+//		}))
+func (c *StooClient) MigrateTo(target *StooClient, namespaces []string, opts ...MigrateOption) (*BulkResult, error) {
+	o := &migrateOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if len(o.profiles) == 0 {
+		return nil, ErrNoProfiles
+	}
+	checkpoint, err := loadCheckpoint(o.checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(checkpoint.Done))
+	for _, scope := range checkpoint.Done {
+		done[scope] = true
+	}
+
+	result := &BulkResult{}
+	progress := MigrateProgress{Total: len(namespaces) * len(o.profiles)}
+	for _, namespace := range namespaces {
+		for _, profile := range o.profiles {
+			scope := namespace + "/" + profile
+			progress.Namespace, progress.Profile, progress.Keys = namespace, profile, 0
+			if !done[scope] {
+				keys, err := c.migrateProfile(target, namespace, profile, o.policy, result)
+				if err != nil {
+					return result, err
+				}
+				progress.Keys = keys
+				checkpoint.Done = append(checkpoint.Done, scope)
+				if err := saveCheckpoint(o.checkpoint, checkpoint); err != nil {
+					return result, err
+				}
+			}
+			progress.Done++
+			if o.progress != nil {
+				o.progress(progress)
+			}
+		}
+	}
+	return result, nil
+}
+
+// migrateProfile copies a namespace and profile to target, returning the number of keys written.
+func (c *StooClient) migrateProfile(target *StooClient, namespace, profile string, policy ConflictPolicy, result *BulkResult) (int, error) {
+	values, err := c.GetAll(namespace, profile)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := target.GetAll(namespace, profile)
+	if err != nil {
+		return 0, err
+	}
+	keys := sortedKeys(values)
+	if policy == Fail {
+		conflicts := false
+		for _, key := range keys {
+			if _, ok := existing[key]; ok {
+				result.add("migrate", migrateKey(namespace, profile, key), ErrKeyExists)
+				conflicts = true
+			}
+		}
+		if conflicts {
+			return 0, nil
+		}
+	}
+
+	written := 0
+	for _, key := range keys {
+		if _, ok := existing[key]; ok && policy == Skip {
+			result.Skipped = append(result.Skipped, migrateKey(namespace, profile, key))
+			continue
+		}
+		value := values[key]
+		secret := c.CurrentConfig().IsSecretKey(key) || strings.HasPrefix(value, envelope.Prefix)
+		err := target.mutate(namespace, profile, mutation{key: key, value: value, secret: secret, raw: true})
+		result.add("migrate", migrateKey(namespace, profile, key), err)
+		if err == nil {
+			written++
+		}
+	}
+	return written, nil
+}
+
+// migrateKey formats a key of a migration result.
+func migrateKey(namespace, profile, key string) string {
+	return namespace + "/" + profile + "/" + key
+}
+
+// loadCheckpoint reads the checkpoint file at path, empty if path is empty or doesn't exist.
+func loadCheckpoint(path string) (*migrateCheckpoint, error) {
+	checkpoint := &migrateCheckpoint{}
+	if path == "" {
+		return checkpoint, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("stogo: checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint writes checkpoint to path, if not empty.
+func saveCheckpoint(path string, checkpoint *migrateCheckpoint) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return export.WriteFileAtomic(path, data, 0o600)
+}
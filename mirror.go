@@ -0,0 +1,102 @@
+package stogo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Mirror live local copy of a namespace and profile, for consumers reading values without
+// reacting to changes. It is refreshed in the background until the context given to
+// StooClient.Mirror is done.
+type Mirror struct {
+	values sync.Map
+
+	mu       sync.Mutex
+	keys     map[string]bool
+	lastSync time.Time
+	err      error
+}
+
+// Mirror starts mirroring a namespace and profile, polling it every configured poll interval.
+// The first poll runs before Mirror returns, check LastSync or Err to tell if it succeeded.
+// Failed polls keep the previous values, LastSync tells how stale they may be.
+//
+// Usage example:
+//
+//	m := client.Mirror(ctx, "my-app", "prod")
+//	if time.Since(m.LastSync()) > 5*time.Minute {
+//		log.Printf("my-app/prod mirror is stale: %v", m.Err())
+//	}
+//	if url, ok := m.Load("database.url"); ok {
+//		connect(url)
+//	}
+func (c *StooClient) Mirror(ctx context.Context, namespace, profile string) *Mirror {
+	m := &Mirror{keys: make(map[string]bool)}
+	clock := c.CurrentConfig().GetClock()
+	m.sync(c, namespace, profile)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-clock.After(c.CurrentConfig().GetPollInterval()):
+			}
+			m.sync(c, namespace, profile)
+		}
+	}()
+	return m
+}
+
+// Load returns the value of key and whether it exists.
+func (m *Mirror) Load(key string) (string, bool) {
+	value, ok := m.values.Load(key)
+	if !ok {
+		return "", false
+	}
+	return value.(string), true
+}
+
+// Range calls fn for every key value pair until fn returns false, like sync.Map.Range.
+func (m *Mirror) Range(fn func(key, value string) bool) {
+	m.values.Range(func(key, value any) bool {
+		return fn(key.(string), value.(string))
+	})
+}
+
+// LastSync returns the time of the last successful poll, zero if none succeeded yet.
+func (m *Mirror) LastSync() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastSync
+}
+
+// Err returns the error of the last poll, nil if it succeeded.
+func (m *Mirror) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// sync polls the namespace and profile and updates the mirrored values.
+func (m *Mirror) sync(c *StooClient, namespace, profile string) {
+	data, err := c.GetAll(namespace, profile)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+	if err != nil {
+		c.CurrentConfig().GetLogger().Warnf("mirror %s/%s: %v", namespace, profile, err)
+		return
+	}
+	for key, value := range data {
+		m.values.Store(key, value)
+		m.keys[key] = true
+	}
+	for key := range m.keys {
+		if _, ok := data[key]; !ok {
+			m.values.Delete(key)
+			delete(m.keys, key)
+		}
+	}
+	m.lastSync = c.CurrentConfig().GetClock().Now()
+}
@@ -0,0 +1,90 @@
+package stogo
+
+import (
+	"github.com/mwangox/stogo/config"
+	"time"
+)
+
+// Option configures a client created with New.
+type Option func(*config.StooConfig)
+
+// New creates a client of endpoint configured by opts, as an alternative to building a
+// config.StooConfig and calling Dial. Settings without an option of their own are set with
+// WithConfig. Like Dial, it returns an error if the configuration is invalid or the client can't
+// be set up.
+//
+// Usage example:
+//
+//	client, err := stogo.New("stookv.internal:50051",
+//		stogo.WithTimeout(5*time.Second),
+//		stogo.WithTLS(&config.TLS{CaCertPath: "/etc/stookv/ca.pem"}),
+//		stogo.WithNamespace("my-app"),
+//		stogo.WithProfile("prod"),
+//	)
+//	if err != nil {
+//		return fmt.Errorf("connecting to stooKV: %w", err)
+//	}
+func New(endpoint string, opts ...Option) (*StooClient, error) {
+	cfg := config.NewStooConfig(endpoint, config.DefaultTimeout)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return Dial(cfg)
+}
+
+// WithTimeout sets the read timeout of every call, config.DefaultTimeout by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *config.StooConfig) {
+		cfg.WithReadTimeout(timeout)
+	}
+}
+
+// WithTLS connects to StooKV over TLS with the settings of t, the system root CAs being
+// trusted if t is nil.
+func WithTLS(t *config.TLS) Option {
+	return func(cfg *config.StooConfig) {
+		cfg.WithUseTls(true).WithTls(t)
+	}
+}
+
+// WithNamespace sets the default namespace used by the *Default methods.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config.StooConfig) {
+		cfg.WithDefaultNamespace(namespace)
+	}
+}
+
+// WithProfile sets the default profile used by the *Default methods.
+func WithProfile(profile string) Option {
+	return func(cfg *config.StooConfig) {
+		cfg.WithDefaultProfile(profile)
+	}
+}
+
+// WithClientName sets the name of the application using the client, sent with every call so
+// that StooKV operators can attribute traffic, see config.StooConfig.WithClientName.
+func WithClientName(name string) Option {
+	return func(cfg *config.StooConfig) {
+		cfg.WithClientName(name)
+	}
+}
+
+// WithLogger sets the logger the client logs to.
+func WithLogger(logger config.Logger) Option {
+	return func(cfg *config.StooConfig) {
+		cfg.WithLogger(logger)
+	}
+}
+
+// WithConfig applies fn to the configuration, to set anything else config.StooConfig offers.
+//
+// Usage example:
+//
+//	client, err := stogo.New("stookv.internal:50051", stogo.WithConfig(func(cfg *config.StooConfig) {
+//		cfg.WithCompression("gzip").WithCircuitBreaker(config.Breaker{})
+//	}))
+func WithConfig(fn func(cfg *config.StooConfig)) Option {
+	return func(cfg *config.StooConfig) {
+		fn(cfg)
+	}
+}
@@ -0,0 +1,136 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/codec"
+)
+
+// CallOption configures a single call made by StooClient.
+type CallOption func(*callOptions)
+
+// callOptions holds settings of a single call.
+type callOptions struct {
+	// idempotencyKey identifies a write so that retries of it are applied once.
+	idempotencyKey string
+	// ctx parent of the call context, context.Background if not set.
+	ctx context.Context
+	// codec encodes the value of a write, nil if not set.
+	codec codec.Codec
+	// identity caller identity reported to the audit hook, empty if not set.
+	identity string
+	// headers metadata sent with the call, as key value pairs.
+	headers []string
+	// tenant tenant the call is made for, empty if not set.
+	tenant string
+	// skipSchema skips the schema check of a write, for writes already validated as a whole.
+	skipSchema bool
+}
+
+// newCallOptions applies opts on top of the default call settings.
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithIdempotencyKey marks a Set, SetSecret or Delete with an idempotency key. The key is sent
+// to StooKV as idempotency-key metadata so servers supporting it can deduplicate retried writes,
+// and the client records the result of successful writes so that repeating a call with the same
+// key returns the recorded result without writing again. Reads ignore it.
+//
+// Usage example:
+//
+//	res, err := client.Set("my-app", "prod", "payments.limit", "500", stogo.WithIdempotencyKey(requestID))
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithContext makes a call run within ctx: the call is canceled along with ctx, still bounded
+// by the read timeout, and reads see the overrides set on ctx with WithOverrides. Calls running
+// out of time fail with a *DeadlineError telling whether the deadline of ctx or the read timeout
+// was hit, and running out of a deadline of ctx shorter than the read timeout doesn't count
+// against the health of the endpoint.
+//
+// Usage example:
+//
+//	value, err := client.Get("my-app", "prod", "checkout.theme", stogo.WithContext(r.Context()))
+func WithContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		if ctx != nil {
+			o.ctx = ctx
+		}
+	}
+}
+
+// WithCodec makes a Set or SetJSON encode the value with c, e.g. codec.Gzip to compress it,
+// before the namespace codec, if any, applies. c must be registered with codec.Register or
+// configured with config.StooConfig.WithNamespaceCodec for readers to decode the value.
+// Other calls ignore it.
+//
+// Usage example:
+//
+//	err := client.SetJSON("my-app", "prod", "routing.table", table, stogo.WithCodec(codec.Gzip))
+func WithCodec(c codec.Codec) CallOption {
+	return func(o *callOptions) {
+		o.codec = c
+	}
+}
+
+// WithIdentity sets the caller identity reported to the audit hook for a write, e.g. the user
+// behind an admin request, instead of the one set with config.StooConfig.WithAuditIdentity.
+//
+// Usage example:
+//
+//	_, err := client.Set("my-app", "prod", "payments.limit", "500", stogo.WithIdentity(user.Email))
+func WithIdentity(identity string) CallOption {
+	return func(o *callOptions) {
+		o.identity = identity
+	}
+}
+
+// WithHeader sends a metadata header with a call, e.g. a request ID or the user a call is made
+// on behalf of, in addition to the ones set with config.StooConfig.WithHeader. It can be given
+// several times.
+//
+// Usage example:
+//
+//	value, err := client.Get("my-app", "prod", "checkout.theme", stogo.WithHeader("x-request-id", requestID))
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		o.headers = append(o.headers, key, value)
+	}
+}
+
+// WithTenant makes a call on behalf of a tenant of a StooKV deployment serving several
+// tenants: the tenant is sent in the header set with config.StooConfig.WithTenantHeader, and
+// the values cached or shared between concurrent reads are kept apart from the ones of other
+// tenants while the connections are shared. Reads made for a tenant are not saved to nor served
+// from the fallback file. See also StooClient.Tenant.
+//
+// Usage example:
+//
+//	value, err := client.Get("my-app", "prod", "checkout.theme", stogo.WithTenant(tenantID))
+func WithTenant(id string) CallOption {
+	return func(o *callOptions) {
+		o.tenant = id
+	}
+}
+
+// bookkeepingOptions returns opts for the write of a reserved key made along with a write made
+// with opts, without the idempotency key which identifies the latter.
+func bookkeepingOptions(opts []CallOption) []CallOption {
+	return append(opts[:len(opts):len(opts)], func(o *callOptions) {
+		o.idempotencyKey = ""
+	})
+}
+
+// withoutSchemaCheck skips the schema check of a write, see config.StooConfig.WithValidateWrites.
+func withoutSchemaCheck() CallOption {
+	return func(o *callOptions) {
+		o.skipSchema = true
+	}
+}
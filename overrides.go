@@ -0,0 +1,42 @@
+package stogo
+
+import "context"
+
+// overridesKey context key of the overrides set with WithOverrides.
+type overridesKey struct{}
+
+// WithOverrides returns a copy of ctx in which reads made with WithContext see values on top
+// of the values stored in StooKV, for any namespace and profile, without touching them. Overrides
+// set on a parent context are kept unless overridden again. Get returns an overridden key
+// without calling StooKV and GetAll merges the overrides into its result.
+//
+// Usage example:
+//
+//	ctx := stogo.WithOverrides(context.Background(), map[string]string{"checkout.theme": "dark"})
+//	theme, err := client.Get("my-app", "prod", "checkout.theme", stogo.WithContext(ctx))
+func WithOverrides(ctx context.Context, values map[string]string) context.Context {
+	merged := copyValues(overridesFrom(ctx))
+	for key, value := range values {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, overridesKey{}, merged)
+}
+
+// overridesFrom returns the overrides set on ctx, nil if there are none.
+func overridesFrom(ctx context.Context) map[string]string {
+	values, _ := ctx.Value(overridesKey{}).(map[string]string)
+	return values
+}
+
+// applyOverrides returns values with the overrides of ctx applied, values itself if there are none.
+func applyOverrides(ctx context.Context, values map[string]string) map[string]string {
+	overrides := overridesFrom(ctx)
+	if len(overrides) == 0 {
+		return values
+	}
+	merged := copyValues(values)
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
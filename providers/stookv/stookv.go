@@ -0,0 +1,145 @@
+// Package stookv implements a koanf (github.com/knadh/koanf) provider reading key value pairs
+// from a StooKV namespace and profile, so StooKV can be merged with file and env sources.
+//
+// Usage example:
+//
+//	k := koanf.New(".")
+//	provider := stookv.Provider(client, "my-app", "prod", ".")
+//	if err := k.Load(provider, nil); err != nil {
+//		log.Fatalf("Error loading config %v", err)
+//	}
+//	provider.Watch(func(event interface{}, err error) {
+//		if err != nil {
+//			return
+//		}
+//		k.Load(provider, nil)
+//	})
+package stookv
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrReadBytesNotSupported thrown by ReadBytes, StooKV values are read as a map with Read.
+var ErrReadBytesNotSupported = errors.New("stookv provider does not support ReadBytes")
+
+// ErrAlreadyWatching thrown by Watch when the provider is already being watched.
+var ErrAlreadyWatching = errors.New("stookv provider is already being watched")
+
+// StooKV koanf provider for a StooKV namespace and profile.
+type StooKV struct {
+	client    *stogo.StooClient
+	namespace string
+	profile   string
+	delim     string
+	interval  time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// Provider creates a StooKV provider. Keys are split on delim into nested maps, an empty delim
+// keeps them flat.
+func Provider(client *stogo.StooClient, namespace, profile, delim string) *StooKV {
+	return &StooKV{
+		client:    client,
+		namespace: namespace,
+		profile:   profile,
+		delim:     delim,
+	}
+}
+
+// WithInterval sets the interval between polls made by Watch, the client poll interval is used if not set.
+func (s *StooKV) WithInterval(interval time.Duration) *StooKV {
+	s.interval = interval
+	return s
+}
+
+// ReadBytes is not supported and returns ErrReadBytesNotSupported.
+func (s *StooKV) ReadBytes() ([]byte, error) {
+	return nil, ErrReadBytesNotSupported
+}
+
+// Read returns all key value pairs of the namespace and profile as a nested map.
+func (s *StooKV) Read() (map[string]interface{}, error) {
+	data, err := s.client.GetAll(s.namespace, s.profile)
+	if err != nil {
+		return nil, err
+	}
+	return unflatten(data, s.delim), nil
+}
+
+// Watch calls cb every time the namespace and profile change, until Unwatch is called.
+// Changes are detected by polling, see stogo.StooClient.Watch.
+func (s *StooKV) Watch(cb func(event interface{}, err error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return ErrAlreadyWatching
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	go func() {
+		first := true
+		s.client.Watch(ctx, s.namespace, s.profile, s.interval, func(map[string]string) {
+			// The first snapshot is the one already loaded by Read.
+			if first {
+				first = false
+				return
+			}
+			cb(nil, nil)
+		})
+	}()
+	return nil
+}
+
+// Unwatch stops watching for changes.
+func (s *StooKV) Unwatch() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	return nil
+}
+
+// unflatten turns delim separated keys into nested maps. When a key is both a value and a
+// parent of other keys, the nested keys win.
+func unflatten(data map[string]string, delim string) map[string]interface{} {
+	out := make(map[string]interface{}, len(data))
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if delim == "" {
+			out[key] = data[key]
+			continue
+		}
+		parts := strings.Split(key, delim)
+		node := out
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[part] = child
+			}
+			node = child
+		}
+		last := parts[len(parts)-1]
+		if _, ok := node[last].(map[string]interface{}); !ok {
+			node[last] = data[key]
+		}
+	}
+	return out
+}
@@ -0,0 +1,203 @@
+package stogo_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/stogotest"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// tunnel copies data both ways between client and upstream until either side is done.
+func tunnel(client, upstream net.Conn) {
+	defer client.Close()
+	defer upstream.Close()
+	go io.Copy(upstream, client)
+	io.Copy(client, upstream)
+}
+
+// connectProxy HTTP proxy serving CONNECT requests, requiring the Proxy-Authorization header
+// auth if not empty, counting the tunnels opened.
+type connectProxy struct {
+	auth    string
+	tunnels atomic.Int32
+}
+
+func (p *connectProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+		return
+	}
+	if p.auth != "" && r.Header.Get("Proxy-Authorization") != p.auth {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+	upstream, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	p.tunnels.Add(1)
+	conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	tunnel(conn, upstream)
+}
+
+func TestHTTPProxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    string
+		user    string
+		wantErr bool
+	}{
+		{"no credentials", "", "", false},
+		{"credentials", "Basic " + base64.StdEncoding.EncodeToString([]byte("stogo:s3cr3t")), "stogo:s3cr3t@", false},
+		{"wrong credentials", "Basic " + base64.StdEncoding.EncodeToString([]byte("stogo:s3cr3t")), "stogo:guess@", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			proxy := &connectProxy{auth: tt.auth}
+			px := httptest.NewServer(proxy)
+			defer px.Close()
+
+			client, err := stogo.Dial(srv.Config().WithProxy("http://" + tt.user + px.Listener.Addr().String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = client.Set("app", "prod", "key", "value")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error through a proxy refusing the credentials")
+				}
+				if len(srv.Data("app", "prod")) != 0 {
+					t.Fatal("got the write through a proxy refusing the credentials")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if srv.Data("app", "prod")["key"] != "value" {
+				t.Fatalf("got %v, want the write through the proxy", srv.Data("app", "prod"))
+			}
+			if tunnels := proxy.tunnels.Load(); tunnels == 0 {
+				t.Error("got no tunnel through the proxy")
+			}
+		})
+	}
+}
+
+// serveSOCKS5 serves a SOCKS5 proxy without authentication on lis, counting the connections
+// opened in tunnels.
+func serveSOCKS5(lis net.Listener, tunnels *atomic.Int32) {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			upstream, err := socks5Connect(conn)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			tunnels.Add(1)
+			tunnel(conn, upstream)
+		}()
+	}
+}
+
+// socks5Connect reads the greeting and CONNECT request of a SOCKS5 client from conn, then
+// connects to the requested address.
+func socks5Connect(conn net.Conn) (net.Conn, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return nil, err
+	}
+	conn.Write([]byte{5, 0})
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return nil, err
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return nil, err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return nil, err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return nil, err
+		}
+		host = string(name)
+	default:
+		conn.Write([]byte{5, 8, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, io.ErrUnexpectedEOF
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return nil, err
+	}
+	upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, err
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	return upstream, nil
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	var tunnels atomic.Int32
+	go serveSOCKS5(lis, &tunnels)
+
+	client, err := stogo.Dial(srv.Config().WithProxy("socks5://" + lis.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Set("app", "prod", "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := client.Get("app", "prod", "key"); err != nil || value != "value" {
+		t.Fatalf("got %q, %v, want the value through the proxy", value, err)
+	}
+	if tunnels.Load() == 0 {
+		t.Error("got no tunnel through the proxy")
+	}
+}
@@ -0,0 +1,85 @@
+package stogo
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotConfirmed thrown when a bulk delete is called with neither Confirmed nor DryRun.
+var ErrNotConfirmed = errors.New("bulk delete requires Confirmed or DryRun")
+
+// DeleteOption configures DeleteAllByNamespaceAndProfile and DeleteByPrefix.
+type DeleteOption func(*deleteOptions)
+
+// deleteOptions holds settings of a bulk delete.
+type deleteOptions struct {
+	confirmed bool
+	dryRun    bool
+}
+
+// Confirmed confirms that a bulk delete must actually remove the keys.
+func Confirmed() DeleteOption {
+	return func(o *deleteOptions) {
+		o.confirmed = true
+	}
+}
+
+// DryRun makes a bulk delete only report the keys it would remove, as Skipped keys of the
+// result. It takes precedence over Confirmed.
+func DryRun() DeleteOption {
+	return func(o *deleteOptions) {
+		o.dryRun = true
+	}
+}
+
+// DeleteAllByNamespaceAndProfile removes every key of a namespace and profile, including the
+// bookkeeping keys written by stogo, e.g. to tear down an ephemeral environment. It must be
+// given Confirmed or DryRun, failing with ErrNotConfirmed otherwise. Every key is attempted,
+// failures are reported per key in the result, the returned error only reports a failure to
+// list the keys.
+//
+// Usage example:
+//
+//	res, err := client.DeleteAllByNamespaceAndProfile("my-app", "pr-1234", stogo.Confirmed())
+//	if err != nil {
+//		log.Fatalf("Error in listing keys %v", err)
+//	}
+//	if err := res.Err(); err != nil {
+//		log.Printf("Failed keys %v: %v", res.FailedKeys(), err)
+//	}
+func (c *StooClient) DeleteAllByNamespaceAndProfile(namespace, profile string, opts ...DeleteOption) (*BulkResult, error) {
+	return c.DeleteByPrefix(namespace, profile, "", opts...)
+}
+
+// DeleteByPrefix removes every key of a namespace and profile starting with prefix, like
+// DeleteAllByNamespaceAndProfile.
+//
+// Usage example:
+//
+//	res, err := client.DeleteByPrefix("my-app", "prod", "legacy.", stogo.DryRun())
+//	if err == nil {
+//		log.Printf("Would delete %v", res.Skipped)
+//	}
+func (c *StooClient) DeleteByPrefix(namespace, profile, prefix string, opts ...DeleteOption) (*BulkResult, error) {
+	o := &deleteOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if !o.confirmed && !o.dryRun {
+		return nil, ErrNotConfirmed
+	}
+	values, err := c.GetAll(namespace, profile)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, key := range sortedKeys(values) {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	if o.dryRun {
+		return &BulkResult{Skipped: keys}, nil
+	}
+	return c.DeleteMany(namespace, profile, keys), nil
+}
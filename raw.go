@@ -0,0 +1,63 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+)
+
+// Raw returns the generated gRPC client, as an escape hatch for features this package doesn't
+// wrap yet. Calls are routed across endpoints like the ones made by StooClient, reads are hedged
+// if configured, and they go through the configured interceptors and value codecs, but they bypass everything else: read
+// timeouts, headers, namespace tokens, client-side encryption, caches, the fallback file and idempotency
+// records. Prefer the StooClient methods whenever they cover the need.
+//
+// Usage example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	res, err := client.Raw().GetService(ctx, &proto.GetRequest{Namespace: "my-app", Profile: "prod", Key: "database.url"})
+func (c *StooClient) Raw() proto.KVServiceClient {
+	return rawClient{c: c}
+}
+
+// Conn returns the connection to the first configured endpoint, nil if the client could not
+// be set up or reaches StooKV over config.TransportHTTP, to call RPCs the generated client
+// doesn't know yet or other services of the endpoint. The connection is shared with the client
+// and must not be closed.
+func (c *StooClient) Conn() *grpc.ClientConn {
+	if len(c.endpoints) == 0 {
+		return nil
+	}
+	return c.endpoints[0].conn
+}
+
+// rawClient KVServiceClient routing every call to the endpoint picked for its namespace.
+type rawClient struct {
+	c *StooClient
+}
+
+// GetService calls GetService on the endpoint picked for the request namespace.
+func (r rawClient) GetService(ctx context.Context, in *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+	return r.c.kv(in.GetNamespace()).GetService(ctx, in, opts...)
+}
+
+// GetServiceByNamespaceAndProfile calls GetServiceByNamespaceAndProfile on the endpoint picked for the request namespace.
+func (r rawClient) GetServiceByNamespaceAndProfile(ctx context.Context, in *proto.GetByNamespaceAndProfileRequest, opts ...grpc.CallOption) (*proto.GetByNamespaceAndProfileResponse, error) {
+	return r.c.kv(in.GetNamespace()).GetServiceByNamespaceAndProfile(ctx, in, opts...)
+}
+
+// SetKeyService calls SetKeyService on the endpoint picked for the request namespace.
+func (r rawClient) SetKeyService(ctx context.Context, in *proto.SetKeyRequest, opts ...grpc.CallOption) (*proto.SetKeyResponse, error) {
+	return r.c.kv(in.GetNamespace()).SetKeyService(ctx, in, opts...)
+}
+
+// SetSecretKeyService calls SetSecretKeyService on the endpoint picked for the request namespace.
+func (r rawClient) SetSecretKeyService(ctx context.Context, in *proto.SetKeyRequest, opts ...grpc.CallOption) (*proto.SetKeyResponse, error) {
+	return r.c.kv(in.GetNamespace()).SetSecretKeyService(ctx, in, opts...)
+}
+
+// DeleteKeyService calls DeleteKeyService on the endpoint picked for the request namespace.
+func (r rawClient) DeleteKeyService(ctx context.Context, in *proto.DeleteKeyRequest, opts ...grpc.CallOption) (*proto.DeleteKeyResponse, error) {
+	return r.c.kv(in.GetNamespace()).DeleteKeyService(ctx, in, opts...)
+}
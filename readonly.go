@@ -0,0 +1,74 @@
+package stogo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"path"
+)
+
+// ErrReadOnly thrown when a write is refused because the client is read-only, see
+// config.StooConfig.WithReadOnly. The error returned is a *ReadOnlyError matching it with errors.Is.
+var ErrReadOnly = errors.New("stogo: client is read-only")
+
+// ReadOnlyError reports a write refused because the client is read-only.
+type ReadOnlyError struct {
+	// Method name of the refused call, e.g. SetKeyService.
+	Method string
+	// Namespace namespace the write was made on.
+	Namespace string
+	// Profile profile the write was made on.
+	Profile string
+	// Key key the write was made on.
+	Key string
+}
+
+// Error implements error.
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%v: refused %s of %s/%s %s", ErrReadOnly, e.Method, e.Namespace, e.Profile, e.Key)
+}
+
+// Is tells if target is ErrReadOnly.
+func (e *ReadOnlyError) Is(target error) bool {
+	return target == ErrReadOnly
+}
+
+// GRPCStatus returns the FailedPrecondition status, so that the write is not retried.
+func (e *ReadOnlyError) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, e.Error())
+}
+
+// readOnlyInterceptor refuses writes when the client is read-only, and logs them instead of
+// sending them in dry run mode.
+func readOnlyInterceptor(c *StooClient) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		cfg := c.CurrentConfig()
+		if !cfg.GetReadOnly() && !cfg.GetDryRun() {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		name := path.Base(method)
+		switch r := req.(type) {
+		case *proto.SetKeyRequest:
+			if cfg.GetDryRun() {
+				value := r.GetValue()
+				if name == "SetSecretKeyService" || cfg.IsSecretKey(r.GetKey()) {
+					value = MaskedValue
+				}
+				cfg.GetLogger().Infof("dry run: %s %s/%s %s=%s", name, r.GetNamespace(), r.GetProfile(), r.GetKey(), value)
+				return nil
+			}
+			return &ReadOnlyError{Method: name, Namespace: r.GetNamespace(), Profile: r.GetProfile(), Key: r.GetKey()}
+		case *proto.DeleteKeyRequest:
+			if cfg.GetDryRun() {
+				cfg.GetLogger().Infof("dry run: %s %s/%s %s", name, r.GetNamespace(), r.GetProfile(), r.GetKey())
+				return nil
+			}
+			return &ReadOnlyError{Method: name, Namespace: r.GetNamespace(), Profile: r.GetProfile(), Key: r.GetKey()}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
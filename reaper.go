@@ -0,0 +1,128 @@
+package stogo
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Reaper deletes keys of a namespace and profile whose TTL, set with SetWithTTL, has elapsed,
+// giving TTL semantics on StooKV servers without native support. It also reverts the expired
+// overrides set with OverrideTemporarily. Only one reaper per namespace and profile is needed,
+// e.g. run by a single replica or as a cron job with "stogo reap".
+type Reaper struct {
+	client    *StooClient
+	namespace string
+	profile   string
+	tenant    string
+	interval  time.Duration
+}
+
+// NewReaper creates a Reaper for the given namespace and profile. Run interval defaults to
+// the configured poll interval.
+//
+// Usage example:
+//
+//	reaper := stogo.NewReaper(client, "my-app", "prod").WithInterval(time.Minute)
+//	go reaper.Run(ctx)
+func NewReaper(client *StooClient, namespace, profile string) *Reaper {
+	return &Reaper{
+		client:    client,
+		namespace: namespace,
+		profile:   profile,
+	}
+}
+
+// WithInterval sets the interval between runs.
+func (r *Reaper) WithInterval(interval time.Duration) *Reaper {
+	r.interval = interval
+	return r
+}
+
+// WithTenant makes the reaper read and delete keys on behalf of tenant id, see WithTenant.
+func (r *Reaper) WithTenant(id string) *Reaper {
+	r.tenant = id
+	return r
+}
+
+// ReapOnce deletes every expired key along with its TTL record and reverts every expired
+// temporary override. The result lists the deleted and reverted keys and the ones which
+// failed, the returned error only reports a failure to read the profile. TTL and override
+// records which can't be parsed are left untouched.
+func (r *Reaper) ReapOnce() (*BulkResult, error) {
+	values, err := r.client.GetAll(r.namespace, r.profile, WithTenant(r.tenant))
+	if err != nil {
+		return nil, err
+	}
+	now := r.client.CurrentConfig().GetClock().Now()
+	prefix := ttlKey("")
+	result := &BulkResult{}
+	for _, reserved := range sortedKeys(values) {
+		if key, ok := strings.CutPrefix(reserved, overrideKey("")); ok {
+			r.revertExpired(result, key, values[reserved], now)
+			continue
+		}
+		key, ok := strings.CutPrefix(reserved, prefix)
+		if !ok {
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339Nano, values[reserved])
+		if err != nil {
+			result.Skipped = append(result.Skipped, key)
+			continue
+		}
+		if now.Before(expiresAt) {
+			continue
+		}
+		if _, exists := values[key]; exists {
+			if _, err := r.client.Delete(r.namespace, r.profile, key, WithTenant(r.tenant)); err != nil {
+				result.add("delete", key, err)
+				continue
+			}
+		}
+		_, err = r.client.Delete(r.namespace, r.profile, reserved, WithTenant(r.tenant))
+		result.add("delete", key, err)
+	}
+	return result, nil
+}
+
+// revertExpired reverts the temporary override of key recorded in raw if it expired.
+func (r *Reaper) revertExpired(result *BulkResult, key, raw string, now time.Time) {
+	record, err := parseOverrideRecord(r.client.CurrentConfig(), r.namespace, r.profile, key, raw)
+	if err != nil {
+		result.Skipped = append(result.Skipped, key)
+		return
+	}
+	if now.Before(record.ExpiresAt) {
+		return
+	}
+	result.add("revert", key, r.client.revertOverride(r.namespace, r.profile, key, WithTenant(r.tenant)))
+}
+
+// Run calls ReapOnce every interval until ctx is done and returns ctx.Err(). Failures are
+// logged and retried on the next run.
+func (r *Reaper) Run(ctx context.Context) error {
+	interval := r.interval
+	if interval <= 0 {
+		interval = r.client.CurrentConfig().GetPollInterval()
+	}
+	clock := r.client.CurrentConfig().GetClock()
+	logger := r.client.CurrentConfig().GetLogger()
+	for {
+		result, err := r.ReapOnce()
+		if err == nil {
+			err = result.Err()
+		}
+		if err != nil {
+			logger.Warnf("reaper %s/%s: %v", r.namespace, r.profile, err)
+		}
+		if result != nil && len(result.Succeeded) > 0 {
+			logger.Infof("reaper %s/%s: deleted or reverted expired keys %v", r.namespace, r.profile, result.Succeeded)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clock.After(interval):
+		}
+	}
+}
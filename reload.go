@@ -0,0 +1,101 @@
+package stogo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"time"
+)
+
+// Keys recognized by WatchConfig in a bootstrap namespace and profile.
+const (
+	// ConfigKeyReadTimeout read timeout, as a time.Duration string.
+	ConfigKeyReadTimeout = "stogo.read-timeout"
+	// ConfigKeyPollInterval poll interval, as a time.Duration string.
+	ConfigKeyPollInterval = "stogo.poll-interval"
+	// ConfigKeyDefaultNamespace default namespace.
+	ConfigKeyDefaultNamespace = "stogo.default-namespace"
+	// ConfigKeyDefaultProfile default profile.
+	ConfigKeyDefaultProfile = "stogo.default-profile"
+	// ConfigKeyLogLevel log level, one of debug, info, warn, error or off.
+	ConfigKeyLogLevel = "stogo.log-level"
+)
+
+// CurrentConfig returns the configuration in effect, which differs from Config once
+// UpdateConfig has been called. The returned configuration must not be modified.
+func (c *StooClient) CurrentConfig() *config.StooConfig {
+	if cfg := c.current.Load(); cfg != nil {
+		return cfg
+	}
+	return c.Config
+}
+
+// UpdateConfig changes the configuration of a running client. fn receives a copy of the
+// current configuration to modify, which replaces it once fn returns. Only settings read
+// per call are affected, e.g. timeouts, log level and default namespace and profile; connection settings
+// such as the endpoint, TLS, interceptors or logger keep the values the client was created with.
+//
+// Usage example:
+//
+//	client.UpdateConfig(func(cfg *config.StooConfig) {
+//		cfg.WithReadTimeout(30 * time.Second)
+//	})
+func (c *StooClient) UpdateConfig(fn func(cfg *config.StooConfig)) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	next := c.CurrentConfig().Clone()
+	fn(next)
+	c.current.Store(next)
+}
+
+// WatchConfig keeps the client configuration in sync with the stogo.* keys of a bootstrap
+// namespace and profile, see the ConfigKey constants. Invalid values are ignored. WatchConfig
+// blocks until ctx is done, like Watch.
+//
+// Usage example:
+//
+//	go client.WatchConfig(ctx, "stogo", "prod", time.Minute)
+func (c *StooClient) WatchConfig(ctx context.Context, namespace, profile string, interval time.Duration) error {
+	return c.Watch(ctx, namespace, profile, interval, func(values map[string]string) {
+		c.UpdateConfig(func(cfg *config.StooConfig) {
+			if err := applyConfigValues(cfg, values); err != nil {
+				cfg.GetLogger().Warnf("watch config %s/%s: %v", namespace, profile, err)
+			}
+		})
+	})
+}
+
+// applyConfigValues sets the settings found in values to cfg, returning the invalid ones.
+func applyConfigValues(cfg *config.StooConfig, values map[string]string) error {
+	var errs []error
+	duration := func(key string, set func(time.Duration) *config.StooConfig) {
+		value, ok := values[key]
+		if !ok {
+			return
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("stogo: invalid %s %q", key, value))
+			return
+		}
+		set(d)
+	}
+	duration(ConfigKeyReadTimeout, cfg.WithReadTimeout)
+	duration(ConfigKeyPollInterval, cfg.WithPollInterval)
+	if value, ok := values[ConfigKeyDefaultNamespace]; ok {
+		cfg.WithDefaultNamespace(value)
+	}
+	if value, ok := values[ConfigKeyDefaultProfile]; ok {
+		cfg.WithDefaultProfile(value)
+	}
+	if value, ok := values[ConfigKeyLogLevel]; ok {
+		level, err := config.ParseLogLevel(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stogo: invalid %s: %w", ConfigKeyLogLevel, err))
+		} else {
+			cfg.WithLogLevel(level)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,177 @@
+// Package render renders text/template templates, such as nginx or HAProxy configurations,
+// with the values of a StooKV namespace and profile, and keeps the output up to date as values
+// change.
+//
+// Templates are executed with the map of all values as data, so values are read with
+// {{ index . "database.url" }}, along with these functions:
+//
+//	get "key"              value of key, failing the render if it is missing
+//	getOr "key" "default"  value of key, default if it is missing
+//	subtree "prefix."      values whose key starts with prefix, keyed by the rest of the key
+//	split "a,b" ","        strings.Split
+//
+// Usage example:
+//
+//	r, err := render.ParseFile(client, "edge", "prod", "/etc/stogo/nginx.conf.tmpl")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	r.WithOutput("/etc/nginx/nginx.conf", 0o644).OnChange(func() error {
+//		return exec.Command("nginx", "-s", "reload").Run()
+//	})
+//	log.Fatal(r.Run(ctx))
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/export"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Renderer renders a template with the values of a namespace and profile.
+type Renderer struct {
+	client    *stogo.StooClient
+	namespace string
+	profile   string
+	tmpl      *template.Template
+	output    string
+	perm      os.FileMode
+	onChange  func() error
+	last      []byte
+}
+
+// Funcs returns the functions available to templates, to be added to templates given to New.
+// values is the map the functions read from.
+func Funcs(values map[string]string) template.FuncMap {
+	return template.FuncMap{
+		"get": func(key string) (string, error) {
+			value, ok := values[key]
+			if !ok {
+				return "", fmt.Errorf("%w: %s", stogo.ErrKeyNotFound, key)
+			}
+			return value, nil
+		},
+		"getOr": func(key, def string) string {
+			if value, ok := values[key]; ok {
+				return value
+			}
+			return def
+		},
+		"subtree": func(prefix string) map[string]string {
+			sub := make(map[string]string)
+			for key, value := range values {
+				if rest, ok := strings.CutPrefix(key, prefix); ok {
+					sub[rest] = value
+				}
+			}
+			return sub
+		},
+		"split": strings.Split,
+	}
+}
+
+// New creates a Renderer of tmpl. Templates using the render functions must be parsed with
+// Funcs(nil) added, the functions are bound to the values at every render.
+func New(client *stogo.StooClient, namespace, profile string, tmpl *template.Template) *Renderer {
+	return &Renderer{
+		client:    client,
+		namespace: namespace,
+		profile:   profile,
+		tmpl:      tmpl,
+		perm:      0o644,
+	}
+}
+
+// Parse creates a Renderer of the template text.
+func Parse(client *stogo.StooClient, namespace, profile, text string) (*Renderer, error) {
+	tmpl, err := template.New(namespace + "/" + profile).Funcs(Funcs(nil)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, namespace, profile, tmpl), nil
+}
+
+// ParseFile creates a Renderer of the template stored in file path.
+func ParseFile(client *stogo.StooClient, namespace, profile, path string) (*Renderer, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(Funcs(nil)).Option("missingkey=error").ParseFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, namespace, profile, tmpl), nil
+}
+
+// WithOutput sets the file Run writes to with permissions perm. It is replaced atomically, so
+// readers never see a partial render.
+func (r *Renderer) WithOutput(path string, perm os.FileMode) *Renderer {
+	r.output = path
+	r.perm = perm
+	return r
+}
+
+// OnChange sets a function Run calls after writing a render which differs from the previous
+// one, e.g. to reload the process reading the output.
+func (r *Renderer) OnChange(fn func() error) *Renderer {
+	r.onChange = fn
+	return r
+}
+
+// Render renders the template with the current values to w.
+func (r *Renderer) Render(w io.Writer) error {
+	values, err := r.client.GetAll(r.namespace, r.profile)
+	if err != nil {
+		return err
+	}
+	return r.execute(w, values)
+}
+
+// execute renders the template with values to w.
+func (r *Renderer) execute(w io.Writer, values map[string]string) error {
+	tmpl, err := r.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	return tmpl.Funcs(Funcs(values)).Execute(w, values)
+}
+
+// Run renders to the output file, or standard output if none is set, every time values change,
+// skipping renders identical to the previous one. Values are watched like with
+// stogo.StooClient.Watch. Failed renders are logged and the previous output kept. Run blocks
+// until ctx is done and returns ctx.Err().
+func (r *Renderer) Run(ctx context.Context) error {
+	logger := r.client.CurrentConfig().GetLogger()
+	return r.client.Watch(ctx, r.namespace, r.profile, 0, func(values map[string]string) {
+		if err := r.update(values); err != nil {
+			logger.Warnf("render %s/%s: %v", r.namespace, r.profile, err)
+		}
+	})
+}
+
+// update renders values and writes the output if it changed.
+func (r *Renderer) update(values map[string]string) error {
+	var buf bytes.Buffer
+	if err := r.execute(&buf, values); err != nil {
+		return err
+	}
+	if r.last != nil && bytes.Equal(buf.Bytes(), r.last) {
+		return nil
+	}
+	if r.output == "" {
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	} else if err := export.WriteFileAtomic(r.output, buf.Bytes(), r.perm); err != nil {
+		return err
+	}
+	r.last = buf.Bytes()
+	if r.onChange != nil {
+		return r.onChange()
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package stogo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueType type a required value must parse as.
+type ValueType string
+
+const (
+	// TypeString any value.
+	TypeString ValueType = ""
+	// TypeInt integer, as understood by strconv.ParseInt.
+	TypeInt ValueType = "int"
+	// TypeFloat number, as understood by strconv.ParseFloat.
+	TypeFloat ValueType = "float"
+	// TypeBool boolean, as understood by strconv.ParseBool.
+	TypeBool ValueType = "bool"
+	// TypeDuration duration, as understood by time.ParseDuration.
+	TypeDuration ValueType = "duration"
+	// TypeURL absolute URL.
+	TypeURL ValueType = "url"
+)
+
+// Requirement expectation on a key checked by ValidateRequired.
+type Requirement struct {
+	// Key key the requirement applies to.
+	Key string
+	// Optional tells the key may be missing, the other constraints apply if it is present.
+	Optional bool
+	// Type type the value must parse as, TypeString if empty.
+	Type ValueType
+	// NonEmpty tells the value must not be empty or blank.
+	NonEmpty bool
+	// Pattern regular expression the whole value must match, if not empty.
+	Pattern string
+	// Min and Max inclusive bounds of TypeInt and TypeFloat values, and of TypeDuration values
+	// in seconds, if not nil.
+	Min, Max *float64
+}
+
+// RequirementError lists every requirement not met found by ValidateRequired.
+type RequirementError struct {
+	// Namespace and Profile checked.
+	Namespace, Profile string
+	// Problems one error per requirement not met, each naming its key.
+	Problems []error
+}
+
+// Error implements error.
+func (e *RequirementError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.Error()
+	}
+	return fmt.Sprintf("%s/%s does not meet requirements: %s", e.Namespace, e.Profile, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the problems, so that errors.Is matches ErrKeyNotFound for missing keys.
+func (e *RequirementError) Unwrap() []error {
+	return e.Problems
+}
+
+// ValidateRequired checks every requirement of spec against the values of a namespace and
+// profile, returning a *RequirementError listing all the requirements not met, or nil if they
+// all are, so that services fail fast at startup with the complete list of problems.
+//
+// Usage example:
+//
+//	one := 1.0
+//	err := client.ValidateRequired("my-app", "prod", []stogo.Requirement{
+//		{Key: "database.url", Type: stogo.TypeURL},
+//		{Key: "database.pool.size", Type: stogo.TypeInt, Min: &one},
+//		{Key: "log.level", Pattern: "debug|info|warn|error"},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+func (c *StooClient) ValidateRequired(namespace, profile string, spec []Requirement, opts ...CallOption) error {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return err
+	}
+	var problems []error
+	for _, req := range spec {
+		if err := req.check(values); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return &RequirementError{Namespace: namespace, Profile: profile, Problems: problems}
+}
+
+// check checks the requirement against values.
+func (r Requirement) check(values map[string]string) error {
+	value, ok := values[r.Key]
+	if !ok {
+		if r.Optional {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", r.Key, ErrKeyNotFound)
+	}
+	if r.NonEmpty && strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s: must not be empty", r.Key)
+	}
+	if r.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + r.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %w", r.Key, r.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s: must match %q", r.Key, r.Pattern)
+		}
+	}
+	number, err := r.parse(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.Key, err)
+	}
+	if r.Min != nil && number < *r.Min {
+		return fmt.Errorf("%s: must be at least %v", r.Key, *r.Min)
+	}
+	if r.Max != nil && number > *r.Max {
+		return fmt.Errorf("%s: must be at most %v", r.Key, *r.Max)
+	}
+	return nil
+}
+
+// parse checks value is of the required type and returns its numeric value, 0 for
+// non-numeric types.
+func (r Requirement) parse(value string) (float64, error) {
+	switch r.Type {
+	case TypeString:
+		if r.Min != nil || r.Max != nil {
+			return 0, errors.New("bounds need a numeric or duration type")
+		}
+		return 0, nil
+	case TypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("must be an integer")
+		}
+		return float64(n), nil
+	case TypeFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("must be a number")
+		}
+		return f, nil
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return 0, fmt.Errorf("must be a boolean")
+		}
+		return 0, nil
+	case TypeDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("must be a duration")
+		}
+		return d.Seconds(), nil
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+			return 0, fmt.Errorf("must be an absolute URL")
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unknown type %q", r.Type)
+}
@@ -0,0 +1,12 @@
+package stogo
+
+import "strings"
+
+// ReservedKeyPrefix prefixes keys written by stogo for its own bookkeeping, such as file
+// chunks. Such keys are managed by the features which write them and should not be set directly.
+const ReservedKeyPrefix = "__stogo."
+
+// IsReservedKey tells if key is a bookkeeping key written by stogo.
+func IsReservedKey(key string) bool {
+	return strings.HasPrefix(key, ReservedKeyPrefix)
+}
@@ -0,0 +1,311 @@
+// Package rollout deploys configuration changes in two phases: a Plan records the changes along
+// with the values they replace, then Apply writes them and Revert puts the previous values back.
+// Plans serialize to JSON, so that a CI pipeline can plan and review a change, apply it, verify
+// the service and promote or revert it in separate jobs.
+//
+// Usage example:
+//
+//	r := rollout.New(client)
+//	plan, err := r.Plan(ctx, []rollout.Change{
+//		{Namespace: "my-app", Profile: "prod", Key: "payments.limit", Value: "500"},
+//		{Namespace: "my-app", Profile: "prod", Key: "legacy.flag", Delete: true},
+//	})
+//	if err != nil {
+//		log.Fatalf("Error planning rollout %v", err)
+//	}
+//	err = r.ApplyAndVerify(ctx, plan, func(ctx context.Context) error {
+//		return checkHealth(ctx)
+//	})
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/envelope"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPlan thrown when a Plan or one of its changes is malformed.
+	ErrInvalidPlan = errors.New("rollout: invalid plan")
+	// ErrDrift thrown when the values a Plan is applied or reverted over are not the ones it
+	// expects, because they were changed by someone else in between. The error returned is a
+	// *DriftError matching it with errors.Is.
+	ErrDrift = errors.New("rollout: values drifted")
+	// ErrVerifyFailed thrown by ApplyAndVerify when the verification of applied changes fails.
+	ErrVerifyFailed = errors.New("rollout: verification failed")
+)
+
+// Change single write of a rollout.
+type Change struct {
+	Namespace string `json:"namespace"`
+	Profile   string `json:"profile"`
+	Key       string `json:"key"`
+	Value     string `json:"value,omitempty"`
+	// Secret writes Value with SetSecret.
+	Secret bool `json:"secret,omitempty"`
+	// Delete deletes Key, Value is ignored.
+	Delete bool `json:"delete,omitempty"`
+}
+
+// Step change of a Plan along with the value it replaces.
+type Step struct {
+	Change
+	// Previous value of the key before the change, in plain text for secrets.
+	Previous string `json:"previous,omitempty"`
+	// Existed tells if the key existed before the change.
+	Existed bool `json:"existed"`
+	// PreviousSecret tells if the previous value was a secret.
+	PreviousSecret bool `json:"previousSecret,omitempty"`
+}
+
+// Plan changes to roll out, each one with the value it replaces so that it can be reverted.
+// Values of secret steps are stored in plain text, the JSON document should be handled like
+// the secrets it holds.
+type Plan struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Steps     []Step    `json:"steps"`
+}
+
+// ReadPlan decodes a JSON Plan from r and validates it.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	var plan Plan
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&plan); err != nil {
+		return nil, fmt.Errorf("rollout: decode plan: %w", err)
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Validate checks every step of the plan targets a namespace, profile and key, and that no key
+// is changed twice.
+func (p *Plan) Validate() error {
+	seen := make(map[string]bool, len(p.Steps))
+	for i, s := range p.Steps {
+		if err := s.Change.validate(); err != nil {
+			return fmt.Errorf("%w: step %d: %w", ErrInvalidPlan, i, err)
+		}
+		id := s.id()
+		if seen[id] {
+			return fmt.Errorf("%w: step %d: %s/%s %s is changed twice", ErrInvalidPlan, i, s.Namespace, s.Profile, s.Key)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// String renders the steps one per line for review, prefixed with +, - or ~ like
+// stogo.ProfileDiff, with secret values masked.
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, s := range p.Steps {
+		previous, value := s.Previous, s.Value
+		if s.PreviousSecret {
+			previous = stogo.MaskedValue
+		}
+		if s.Secret {
+			value = stogo.MaskedValue
+		}
+		switch {
+		case s.Delete:
+			fmt.Fprintf(&b, "- %s/%s %s=%s\n", s.Namespace, s.Profile, s.Key, previous)
+		case s.Existed:
+			fmt.Fprintf(&b, "~ %s/%s %s: %s -> %s\n", s.Namespace, s.Profile, s.Key, previous, value)
+		default:
+			fmt.Fprintf(&b, "+ %s/%s %s=%s\n", s.Namespace, s.Profile, s.Key, value)
+		}
+	}
+	return b.String()
+}
+
+// DriftError lists the keys whose value is not the one a Plan expects.
+type DriftError struct {
+	// Keys keys which drifted, as namespace/profile key, sorted.
+	Keys []string
+}
+
+// Error implements error.
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrDrift, strings.Join(e.Keys, ", "))
+}
+
+// Is tells if target is ErrDrift.
+func (e *DriftError) Is(target error) bool {
+	return target == ErrDrift
+}
+
+// Rollout plans, applies and reverts changes with a client.
+type Rollout struct {
+	client *stogo.StooClient
+}
+
+// New creates a Rollout writing with client.
+func New(client *stogo.StooClient) *Rollout {
+	return &Rollout{client: client}
+}
+
+// Plan reads the current value of every key changed and returns the plan of changes, leaving
+// out the ones which wouldn't change anything. Keys matching the configured secret key patterns
+// are planned as secrets.
+func (r *Rollout) Plan(ctx context.Context, changes []Change) (*Plan, error) {
+	cfg := r.client.CurrentConfig()
+	plan := &Plan{CreatedAt: cfg.GetClock().Now().UTC()}
+	for _, change := range changes {
+		if cfg.IsSecretKey(change.Key) && !change.Delete {
+			change.Secret = true
+		}
+		if change.Delete {
+			change.Value = ""
+		}
+		previous, existed, secret, err := r.current(ctx, change.Namespace, change.Profile, change.Key)
+		if err != nil {
+			return nil, err
+		}
+		if change.Delete && !existed || !change.Delete && existed && previous == change.Value && secret == change.Secret {
+			continue
+		}
+		plan.Steps = append(plan.Steps, Step{Change: change, Previous: previous, Existed: existed, PreviousSecret: secret})
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Apply writes the changes of plan in order, after checking the keys still hold the values the
+// plan was made against. If a write fails, the keys already written are restored, with the
+// best effort atomicity of stogo.StooClient.Txn.
+func (r *Rollout) Apply(ctx context.Context, plan *Plan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	if err := r.checkDrift(ctx, plan, false); err != nil {
+		return err
+	}
+	txn := r.client.Txn(ctx)
+	for _, s := range plan.Steps {
+		write(txn, s.Change)
+	}
+	return txn.Commit()
+}
+
+// Revert restores the values plan replaced, in reverse order, after checking the keys still
+// hold the values the plan wrote. Keys the plan created are deleted.
+func (r *Rollout) Revert(ctx context.Context, plan *Plan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	if err := r.checkDrift(ctx, plan, true); err != nil {
+		return err
+	}
+	txn := r.client.Txn(ctx)
+	for i := len(plan.Steps) - 1; i >= 0; i-- {
+		write(txn, plan.Steps[i].inverse())
+	}
+	return txn.Commit()
+}
+
+// ApplyAndVerify applies plan, then calls verify and reverts the plan if it fails, returning an
+// error matching ErrVerifyFailed joined with any revert failure.
+func (r *Rollout) ApplyAndVerify(ctx context.Context, plan *Plan, verify func(ctx context.Context) error) error {
+	if err := r.Apply(ctx, plan); err != nil {
+		return err
+	}
+	err := verify(ctx)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("%w: %w", ErrVerifyFailed, err)
+	if revertErr := r.Revert(ctx, plan); revertErr != nil {
+		return errors.Join(err, fmt.Errorf("rollout: revert: %w", revertErr))
+	}
+	return err
+}
+
+// checkDrift checks every key of plan holds its value before the plan, or after it if applied
+// is true.
+func (r *Rollout) checkDrift(ctx context.Context, plan *Plan, applied bool) error {
+	var drifted []string
+	for _, s := range plan.Steps {
+		expected := s.Change
+		if !applied {
+			expected = s.inverse()
+		}
+		value, existed, _, err := r.current(ctx, s.Namespace, s.Profile, s.Key)
+		if err != nil {
+			return err
+		}
+		if existed == expected.Delete || existed && value != expected.Value {
+			drifted = append(drifted, fmt.Sprintf("%s/%s %s", s.Namespace, s.Profile, s.Key))
+		}
+	}
+	if len(drifted) > 0 {
+		sort.Strings(drifted)
+		return &DriftError{Keys: drifted}
+	}
+	return nil
+}
+
+// current returns the value of a key, in plain text for secrets, whether it exists and whether
+// it is a secret, i.e. matches the secret key patterns or was encrypted on the client.
+func (r *Rollout) current(ctx context.Context, namespace, profile, key string) (string, bool, bool, error) {
+	values, err := r.client.GetAll(namespace, profile, stogo.WithContext(ctx))
+	if err != nil {
+		return "", false, false, err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", false, false, nil
+	}
+	if !r.client.CurrentConfig().IsSecretKey(key) && !envelope.IsEncrypted(value) {
+		return value, true, false, nil
+	}
+	secret, err := r.client.GetSecret(namespace, profile, key, stogo.WithContext(ctx))
+	if err != nil {
+		return "", false, false, err
+	}
+	return secret.Reveal(), true, true, nil
+}
+
+// validate checks the change targets a namespace, profile and key.
+func (c Change) validate() error {
+	if c.Namespace == "" || c.Profile == "" || c.Key == "" {
+		return errors.New("namespace, profile and key are required")
+	}
+	return nil
+}
+
+// id identifies the key a step changes.
+func (s Step) id() string {
+	return s.Namespace + "\x00" + s.Profile + "\x00" + s.Key
+}
+
+// inverse returns the change restoring the value the step replaced.
+func (s Step) inverse() Change {
+	if !s.Existed {
+		return Change{Namespace: s.Namespace, Profile: s.Profile, Key: s.Key, Delete: true}
+	}
+	return Change{Namespace: s.Namespace, Profile: s.Profile, Key: s.Key, Value: s.Previous, Secret: s.PreviousSecret}
+}
+
+// write adds change to txn.
+func write(txn *stogo.Txn, change Change) {
+	switch {
+	case change.Delete:
+		txn.Delete(change.Namespace, change.Profile, change.Key)
+	case change.Secret:
+		txn.SetSecret(change.Namespace, change.Profile, change.Key, change.Value)
+	default:
+		txn.Set(change.Namespace, change.Profile, change.Key, change.Value)
+	}
+}
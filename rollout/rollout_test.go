@@ -0,0 +1,243 @@
+package rollout_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/rollout"
+	"github.com/mwangox/stogo/stogotest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newClient returns a client of a stogotest.Server holding db.host, db.password as a secret and
+// legacy.flag in app/prod.
+func newClient(t *testing.T) (*stogo.StooClient, *stogotest.Server) {
+	t.Helper()
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := stogo.NewStoreClient(srv.Config().
+		WithEncrypter(envelope.NewChain(provider)).
+		WithSecretKeyPatterns("*.password"))
+	if _, err := client.Set("app", "prod", "db.host", "db.internal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SetSecret("app", "prod", "db.password", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Set("app", "prod", "legacy.flag", "true"); err != nil {
+		t.Fatal(err)
+	}
+	return client, srv
+}
+
+// changes used by the tests: one update, one secret update, one creation, one deletion and
+// one deletion without effect.
+var changes = []rollout.Change{
+	{Namespace: "app", Profile: "prod", Key: "db.host", Value: "db2.internal"},
+	{Namespace: "app", Profile: "prod", Key: "db.password", Value: "new"},
+	{Namespace: "app", Profile: "prod", Key: "db.pool", Value: "10"},
+	{Namespace: "app", Profile: "prod", Key: "legacy.flag", Delete: true},
+	{Namespace: "app", Profile: "prod", Key: "db.user", Delete: true},
+}
+
+// values returns the values of app/prod, secrets decrypted.
+func values(t *testing.T, client *stogo.StooClient) map[string]string {
+	t.Helper()
+	all, err := client.GetAll("app", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := all["db.password"]; ok {
+		secret, err := client.GetSecret("app", "prod", "db.password")
+		if err != nil {
+			t.Fatal(err)
+		}
+		all["db.password"] = secret.Reveal()
+	}
+	return all
+}
+
+func TestPlan(t *testing.T) {
+	client, _ := newClient(t)
+	r := rollout.New(client)
+	plan, err := r.Plan(context.Background(), changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rollout.Step{
+		{Change: changes[0], Previous: "db.internal", Existed: true},
+		{Change: rollout.Change{Namespace: "app", Profile: "prod", Key: "db.password", Value: "new", Secret: true}, Previous: "old", Existed: true, PreviousSecret: true},
+		{Change: changes[2]},
+		{Change: changes[3], Previous: "true", Existed: true},
+	}
+	if !reflect.DeepEqual(plan.Steps, want) {
+		t.Fatalf("got steps %+v, want %+v", plan.Steps, want)
+	}
+	wantString := "~ app/prod db.host: db.internal -> db2.internal\n" +
+		"~ app/prod db.password: " + stogo.MaskedValue + " -> " + stogo.MaskedValue + "\n" +
+		"+ app/prod db.pool=10\n" +
+		"- app/prod legacy.flag=true\n"
+	if got := plan.String(); got != wantString {
+		t.Errorf("got plan\n%s\nwant\n%s", got, wantString)
+	}
+
+	unchanged := []rollout.Change{{Namespace: "app", Profile: "prod", Key: "db.host", Value: "db.internal"}}
+	if plan, err := r.Plan(context.Background(), unchanged); err != nil || len(plan.Steps) != 0 {
+		t.Errorf("got %v, %v planning the current value, want no step", plan, err)
+	}
+
+	twice := []rollout.Change{changes[0], {Namespace: "app", Profile: "prod", Key: "db.host", Value: "db3.internal"}}
+	if _, err := r.Plan(context.Background(), twice); !errors.Is(err, rollout.ErrInvalidPlan) {
+		t.Errorf("got error %v planning a key changed twice, want %v", err, rollout.ErrInvalidPlan)
+	}
+}
+
+func TestApplyRevert(t *testing.T) {
+	client, _ := newClient(t)
+	r := rollout.New(client)
+	ctx := context.Background()
+	before := values(t, client)
+	plan, err := r.Plan(ctx, changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Apply(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+	applied := map[string]string{"db.host": "db2.internal", "db.password": "new", "db.pool": "10"}
+	if got := values(t, client); !reflect.DeepEqual(got, applied) {
+		t.Fatalf("got %v after Apply, want %v", got, applied)
+	}
+	if err := r.Apply(ctx, plan); !errors.Is(err, rollout.ErrDrift) {
+		t.Errorf("got error %v applying twice, want %v", err, rollout.ErrDrift)
+	}
+
+	if err := r.Revert(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+	if got := values(t, client); !reflect.DeepEqual(got, before) {
+		t.Errorf("got %v after Revert, want %v", got, before)
+	}
+	if err := r.Revert(ctx, plan); !errors.Is(err, rollout.ErrDrift) {
+		t.Errorf("got error %v reverting twice, want %v", err, rollout.ErrDrift)
+	}
+}
+
+func TestApplyDrift(t *testing.T) {
+	client, _ := newClient(t)
+	r := rollout.New(client)
+	ctx := context.Background()
+	plan, err := r.Plan(ctx, changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Set("app", "prod", "db.host", "db3.internal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Set("app", "prod", "db.pool", "20"); err != nil {
+		t.Fatal(err)
+	}
+	before := values(t, client)
+
+	err = r.Apply(ctx, plan)
+	var drift *rollout.DriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("got error %v, want a *DriftError", err)
+	}
+	if want := []string{"app/prod db.host", "app/prod db.pool"}; !reflect.DeepEqual(drift.Keys, want) {
+		t.Errorf("got drifted keys %v, want %v", drift.Keys, want)
+	}
+	if got := values(t, client); !reflect.DeepEqual(got, before) {
+		t.Errorf("got %v after a drifted Apply, want nothing written", got)
+	}
+}
+
+func TestApplyAndVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		verify  error
+		wantErr error
+	}{
+		{"verified", nil, nil},
+		{"verification failing", errors.New("health check failed"), rollout.ErrVerifyFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newClient(t)
+			r := rollout.New(client)
+			ctx := context.Background()
+			before := values(t, client)
+			plan, err := r.Plan(ctx, changes[:1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var verified map[string]string
+			err = r.ApplyAndVerify(ctx, plan, func(context.Context) error {
+				verified = values(t, client)
+				return tt.verify
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if verified["db.host"] != "db2.internal" {
+				t.Errorf("got %v while verifying, want the plan applied", verified)
+			}
+			want := before
+			if tt.wantErr == nil {
+				want = verified
+			}
+			if got := values(t, client); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v after ApplyAndVerify, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadPlan(t *testing.T) {
+	client, _ := newClient(t)
+	plan, err := rollout.New(client).Plan(context.Background(), changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rollout.ReadPlan(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Steps, plan.Steps) || !got.CreatedAt.Equal(plan.CreatedAt) {
+		t.Errorf("got %+v after a round trip, want %+v", got, plan)
+	}
+
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"unknown field", `{"steps": [], "approved": true}`},
+		{"missing key", `{"steps": [{"namespace": "app", "profile": "prod", "value": "x"}]}`},
+		{"key changed twice", `{"steps": [{"namespace": "app", "profile": "prod", "key": "k"}, {"namespace": "app", "profile": "prod", "key": "k", "delete": true}]}`},
+		{"malformed", `{"steps": `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rollout.ReadPlan(strings.NewReader(tt.json)); err == nil {
+				t.Error("got no error, want the plan rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package stogo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/envelope"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"reflect"
+)
+
+var (
+	// ErrSchemaViolation thrown when the values of a namespace and profile don't follow their
+	// schema, see config.StooConfig.WithSchema. The error returned is a *SchemaError matching it
+	// with errors.Is.
+	ErrSchemaViolation = errors.New("stogo: schema violation")
+	// ErrNoSchema thrown by Validate when no schema is registered for the namespace and profile.
+	ErrNoSchema = errors.New("stogo: no schema registered")
+)
+
+// SchemaError reports values of a namespace and profile not following their schema.
+type SchemaError struct {
+	// Namespace and Profile validated.
+	Namespace, Profile string
+	// Key key of the refused write, empty when reported by Validate or StooClient.Apply.
+	Key string
+	// Err error returned by the schema.
+	Err error
+}
+
+// Error implements error.
+func (e *SchemaError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("%v: refused write of %s/%s %s: %v", ErrSchemaViolation, e.Namespace, e.Profile, e.Key, e.Err)
+	}
+	return fmt.Sprintf("%v: %s/%s: %v", ErrSchemaViolation, e.Namespace, e.Profile, e.Err)
+}
+
+// Is tells if target is ErrSchemaViolation.
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrSchemaViolation
+}
+
+// Unwrap returns the error of the schema.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the InvalidArgument status, so that the write is not retried.
+func (e *SchemaError) GRPCStatus() *status.Status {
+	return status.New(codes.InvalidArgument, e.Error())
+}
+
+// SchemaFunc config.Schema calling the function.
+//
+// Usage example:
+//
+//	cfg.WithSchema("my-app", "prod", stogo.SchemaFunc(func(values map[string]string) error {
+//		if values["tls.enabled"] == "true" && values["tls.cert"] == "" {
+//			return errors.New("tls.cert is required when tls.enabled is true")
+//		}
+//		return nil
+//	}))
+type SchemaFunc func(values map[string]string) error
+
+// Validate implements config.Schema.
+func (f SchemaFunc) Validate(values map[string]string) error {
+	return f(values)
+}
+
+// RequirementsSchema returns a config.Schema checking the requirements of spec, as
+// ValidateRequired does, failing with a *RequirementError listing the ones not met.
+//
+// Usage example:
+//
+//	cfg.WithSchema("my-app", "", stogo.RequirementsSchema([]stogo.Requirement{
+//		{Key: "database.url", Type: stogo.TypeURL},
+//		{Key: "log.level", Pattern: "debug|info|warn|error"},
+//	}))
+func RequirementsSchema(spec []Requirement) config.Schema {
+	spec = append([]Requirement(nil), spec...)
+	return SchemaFunc(func(values map[string]string) error {
+		var problems []error
+		for _, req := range spec {
+			if err := req.check(values); err != nil {
+				problems = append(problems, err)
+			}
+		}
+		if len(problems) == 0 {
+			return nil
+		}
+		return &RequirementError{Problems: problems}
+	})
+}
+
+// StructSchema returns a config.Schema decoding the values into a new value of the type of v,
+// a struct or a pointer to one, with Unmarshal, or UnmarshalStrict if strict is true so that
+// keys without a field are refused. If the decoded value has a Validate() error method, it is
+// called too, for constraints between fields.
+//
+// Usage example:
+//
+//	type AppConfig struct {
+//		Port    int           `stoo:"server.port"`
+//		Timeout time.Duration `stoo:"server.timeout"`
+//	}
+//
+//	func (c *AppConfig) Validate() error {
+//		if c.Port <= 0 {
+//			return errors.New("server.port must be positive")
+//		}
+//		return nil
+//	}
+//
+//	cfg.WithSchema("my-app", "", stogo.StructSchema(AppConfig{}, true))
+func StructSchema(v any, strict bool) config.Schema {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return SchemaFunc(func(values map[string]string) error {
+		if t == nil || t.Kind() != reflect.Struct {
+			return fmt.Errorf("struct schema of %T: not a struct", v)
+		}
+		target := reflect.New(t).Interface()
+		decode := Unmarshal
+		if strict {
+			decode = UnmarshalStrict
+		}
+		if err := decode(values, target); err != nil {
+			return err
+		}
+		if validator, ok := target.(interface{ Validate() error }); ok {
+			return validator.Validate()
+		}
+		return nil
+	})
+}
+
+// Validate checks the values of a namespace and profile, read with GetAll and without reserved
+// keys, against the schema registered with config.StooConfig.WithSchema, returning a
+// *SchemaError if they don't follow it, or ErrNoSchema if there is no schema.
+//
+// Usage example:
+//
+//	if err := client.Validate("my-app", "prod"); err != nil {
+//		log.Fatalf("Invalid configuration %v", err)
+//	}
+func (c *StooClient) Validate(namespace, profile string, opts ...CallOption) error {
+	schema, ok := c.CurrentConfig().GetSchema(namespace, profile)
+	if !ok {
+		return fmt.Errorf("%w for %s/%s", ErrNoSchema, namespace, profile)
+	}
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return err
+	}
+	if values, err = schemaValues(c.CurrentConfig(), namespace, profile, values); err != nil {
+		return err
+	}
+	return validateSchema(schema, namespace, profile, "", values)
+}
+
+// checkSchema checks that writing value to key, or deleting key, keeps the values of the
+// namespace and profile following their schema, when config.StooConfig.WithValidateWrites is
+// set.
+func (c *StooClient) checkSchema(namespace, profile string, m mutation, o *callOptions) error {
+	cfg := c.CurrentConfig()
+	if !cfg.GetValidateWrites() || o.skipSchema || IsReservedKey(m.key) {
+		return nil
+	}
+	schema, ok := cfg.GetSchema(namespace, profile)
+	if !ok {
+		return nil
+	}
+	values, err := c.GetAll(namespace, profile, WithContext(o.ctx), WithTenant(o.tenant))
+	if err != nil {
+		return err
+	}
+	if values, err = schemaValues(cfg, namespace, profile, applyMutations(values, []mutation{m})); err != nil {
+		return err
+	}
+	return validateSchema(schema, namespace, profile, m.key, values)
+}
+
+// schemaValues returns values with the secrets encrypted on the client decrypted, so that
+// schemas see the values callers wrote rather than their ciphertext.
+func schemaValues(cfg *config.StooConfig, namespace, profile string, values map[string]string) (map[string]string, error) {
+	if cfg.GetEncrypter() == nil {
+		return values, nil
+	}
+	plain := make(map[string]string, len(values))
+	for key, value := range values {
+		if envelope.IsEncrypted(value) {
+			plaintext, err := decryptSecret(cfg, namespace, profile, key, value)
+			if err != nil {
+				return nil, err
+			}
+			value = plaintext
+		}
+		plain[key] = value
+	}
+	return plain, nil
+}
+
+// applyMutations returns a copy of values with mutations applied in order.
+func applyMutations(values map[string]string, mutations []mutation) map[string]string {
+	values = copyValues(values)
+	for _, m := range mutations {
+		if m.delete {
+			delete(values, m.key)
+		} else {
+			values[m.key] = m.value
+		}
+	}
+	return values
+}
+
+// validateSchema validates values, without reserved keys, against schema.
+func validateSchema(schema config.Schema, namespace, profile, key string, values map[string]string) error {
+	visible := make(map[string]string, len(values))
+	for k, value := range values {
+		if !IsReservedKey(k) {
+			visible[k] = value
+		}
+	}
+	if err := schema.Validate(visible); err != nil {
+		var reqErr *RequirementError
+		if errors.As(err, &reqErr) && reqErr.Namespace == "" {
+			reqErr.Namespace, reqErr.Profile = namespace, profile
+		}
+		return &SchemaError{Namespace: namespace, Profile: profile, Key: key, Err: err}
+	}
+	return nil
+}
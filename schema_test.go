@@ -0,0 +1,70 @@
+package stogo_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/stogotest"
+	"testing"
+)
+
+func TestSchemaWithEncryption(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := stogo.RequirementsSchema([]stogo.Requirement{
+		{Key: "db.password", Optional: true, Pattern: "^[a-z]{8,}$"},
+		{Key: "db.host", Optional: true, NonEmpty: true},
+	})
+	cfg := srv.Config().
+		WithEncrypter(envelope.NewChain(provider)).
+		WithSchema("app", "", schema).
+		WithValidateWrites(true)
+	client := stogo.NewStoreClient(cfg)
+
+	tests := []struct {
+		name    string
+		write   func() error
+		wantErr bool
+	}{
+		{"valid secret", func() error {
+			_, err := client.SetSecret("app", "prod", "db.password", "abcdefgh")
+			return err
+		}, false},
+		{"invalid secret", func() error {
+			_, err := client.SetSecret("app", "prod", "db.password", "short")
+			return err
+		}, true},
+		{"plain write next to an encrypted secret", func() error {
+			_, err := client.Set("app", "prod", "db.host", "db.internal")
+			return err
+		}, false},
+		{"invalid plain write", func() error {
+			_, err := client.Set("app", "prod", "db.host", " ")
+			return err
+		}, true},
+		{"validate stored values", func() error {
+			return client.Validate("app", "prod")
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.write()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, stogo.ErrSchemaViolation) {
+				t.Fatalf("got error %v, want %v", err, stogo.ErrSchemaViolation)
+			}
+		})
+	}
+	if !envelope.IsEncrypted(srv.Data("app", "prod")["db.password"]) {
+		t.Fatal("secret stored in plain text")
+	}
+}
@@ -0,0 +1,85 @@
+package stogo
+
+import (
+	"context"
+	"time"
+)
+
+// ScopedClient client bound to a namespace and profile, whose methods take only a key. Scopes
+// share the connection, cache and limits of the client they were created from, so a process can
+// read several namespaces through one connection.
+type ScopedClient struct {
+	client    *StooClient
+	namespace string
+	profile   string
+}
+
+// Scope returns a ScopedClient bound to namespace and profile.
+//
+// Usage example:
+//
+//	shared := client.Scope("shared", "prod")
+//	app := client.Scope("my-app", "prod")
+//	region, err := shared.Get("region")
+//	if err != nil {
+//		log.Fatalf("Error reading region %v", err)
+//	}
+//	_, err = app.Set("last-boot", time.Now().Format(time.RFC3339))
+func (c *StooClient) Scope(namespace, profile string) *ScopedClient {
+	return &ScopedClient{client: c, namespace: namespace, profile: profile}
+}
+
+// Client returns the client the scope was created from.
+func (s *ScopedClient) Client() *StooClient {
+	return s.client
+}
+
+// Namespace returns the namespace of the scope.
+func (s *ScopedClient) Namespace() string {
+	return s.namespace
+}
+
+// Profile returns the profile of the scope.
+func (s *ScopedClient) Profile() string {
+	return s.profile
+}
+
+// Get gets the value of key, see StooClient.Get.
+func (s *ScopedClient) Get(key string, opts ...CallOption) (string, error) {
+	return s.client.Get(s.namespace, s.profile, key, opts...)
+}
+
+// GetSecret gets the value of key as a secret, see StooClient.GetSecret.
+func (s *ScopedClient) GetSecret(key string, opts ...CallOption) (SecretValue, error) {
+	return s.client.GetSecret(s.namespace, s.profile, key, opts...)
+}
+
+// GetAll gets all key value pairs of the scope, see StooClient.GetAll.
+func (s *ScopedClient) GetAll(opts ...CallOption) (map[string]string, error) {
+	return s.client.GetAll(s.namespace, s.profile, opts...)
+}
+
+// Range calls fn with every key value pair of the scope in key order, see StooClient.Range.
+func (s *ScopedClient) Range(fn func(key, value string) bool, opts ...CallOption) error {
+	return s.client.Range(s.namespace, s.profile, fn, opts...)
+}
+
+// Set sets key to value, see StooClient.Set.
+func (s *ScopedClient) Set(key, value string, opts ...CallOption) (string, error) {
+	return s.client.Set(s.namespace, s.profile, key, value, opts...)
+}
+
+// SetSecret sets key to value as a secret, see StooClient.SetSecret.
+func (s *ScopedClient) SetSecret(key, value string, opts ...CallOption) (string, error) {
+	return s.client.SetSecret(s.namespace, s.profile, key, value, opts...)
+}
+
+// Delete removes key, see StooClient.Delete.
+func (s *ScopedClient) Delete(key string, opts ...CallOption) (string, error) {
+	return s.client.Delete(s.namespace, s.profile, key, opts...)
+}
+
+// Watch calls fn with all key value pairs of the scope whenever they change, see StooClient.Watch.
+func (s *ScopedClient) Watch(ctx context.Context, interval time.Duration, fn func(map[string]string)) error {
+	return s.client.Watch(ctx, s.namespace, s.profile, interval, fn)
+}
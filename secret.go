@@ -0,0 +1,194 @@
+package stogo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/envelope"
+	"io"
+	"strings"
+)
+
+// SecretValue value of a secret. It prints as MaskedValue whatever the formatting verb and
+// marshals to MaskedValue as JSON or text, so it never lands in logs by accident; use Reveal
+// to get the actual value.
+type SecretValue string
+
+// Reveal returns the actual value.
+func (s SecretValue) Reveal() string {
+	return string(s)
+}
+
+// String returns MaskedValue.
+func (s SecretValue) String() string {
+	return MaskedValue
+}
+
+// GoString returns MaskedValue quoted.
+func (s SecretValue) GoString() string {
+	return `"` + MaskedValue + `"`
+}
+
+// Format writes MaskedValue for every verb.
+func (s SecretValue) Format(f fmt.State, _ rune) {
+	io.WriteString(f, MaskedValue)
+}
+
+// MarshalText returns MaskedValue.
+func (s SecretValue) MarshalText() ([]byte, error) {
+	return []byte(MaskedValue), nil
+}
+
+// MarshalJSON returns MaskedValue as a JSON string.
+func (s SecretValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MaskedValue)
+}
+
+// RedactedMap key value pairs whose secret values are masked when printed or marshaled.
+// Secret keys are the ones matching the secret key patterns of the client which built it.
+type RedactedMap struct {
+	values   map[string]string
+	isSecret func(key string) bool
+}
+
+// Get returns the actual value of key.
+func (m *RedactedMap) Get(key string) (string, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// Keys returns all keys in ascending order.
+func (m *RedactedMap) Keys() []string {
+	return sortedKeys(m.values)
+}
+
+// Len returns the number of key value pairs.
+func (m *RedactedMap) Len() int {
+	return len(m.values)
+}
+
+// Unredacted returns a copy of the key value pairs with actual values.
+func (m *RedactedMap) Unredacted() map[string]string {
+	values := make(map[string]string, len(m.values))
+	for k, v := range m.values {
+		values[k] = v
+	}
+	return values
+}
+
+// Redacted returns a copy of the key value pairs with secret values replaced by MaskedValue.
+func (m *RedactedMap) Redacted() map[string]string {
+	values := make(map[string]string, len(m.values))
+	for k, v := range m.values {
+		if m.isSecret(k) {
+			v = MaskedValue
+		}
+		values[k] = v
+	}
+	return values
+}
+
+// String renders the redacted key value pairs like a map.
+func (m *RedactedMap) String() string {
+	redacted := m.Redacted()
+	keys := sortedKeys(redacted)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + ":" + redacted[k]
+	}
+	return "map[" + strings.Join(pairs, " ") + "]"
+}
+
+// Format writes String for every verb.
+func (m *RedactedMap) Format(f fmt.State, _ rune) {
+	io.WriteString(f, m.String())
+}
+
+// MarshalJSON marshals the redacted key value pairs as a JSON object.
+func (m *RedactedMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Redacted())
+}
+
+// GetSecret gets a value like Get, wrapped in a SecretValue which is masked when printed.
+// If an encrypter is configured, values encrypted on the client by SetSecret are decrypted,
+// while values which were not encrypted by the envelope package are returned as they are.
+//
+// Usage example:
+//
+//	password, err := client.GetSecret("my-app", "prod", "database.password")
+//	if err != nil {
+//		log.Fatalf("Error reading secret %v", err)
+//	}
+//	log.Printf("password: %v", password) // password: ******
+//	db.Connect(password.Reveal())
+func (c *StooClient) GetSecret(namespace, profile, key string, opts ...CallOption) (SecretValue, error) {
+	value, err := c.Get(namespace, profile, key, opts...)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decryptSecret(c.CurrentConfig(), namespace, profile, key, value)
+	if err != nil {
+		return "", err
+	}
+	return SecretValue(plaintext), nil
+}
+
+// encryptSecret encrypts value with the configured encrypter, if any, bound to namespace,
+// profile and key when the encryption context is enabled.
+func encryptSecret(cfg *config.StooConfig, namespace, profile, key, value string) (string, error) {
+	encrypter := cfg.GetEncrypter()
+	if encrypter == nil {
+		return value, nil
+	}
+	if ce, ok := encrypter.(config.ContextEncrypter); ok && cfg.GetEncryptionContext() {
+		return ce.EncryptWithContext([]byte(value), secretAAD(namespace, profile, key))
+	}
+	return encrypter.Encrypt([]byte(value))
+}
+
+// decryptSecret decrypts value with the configured encrypter, if any, returning values which
+// were not encrypted by the envelope package as they are.
+func decryptSecret(cfg *config.StooConfig, namespace, profile, key, value string) (string, error) {
+	encrypter := cfg.GetEncrypter()
+	if encrypter == nil {
+		return value, nil
+	}
+	var plaintext []byte
+	var err error
+	if ce, ok := encrypter.(config.ContextEncrypter); ok && cfg.GetEncryptionContext() {
+		plaintext, err = ce.DecryptWithContext(value, secretAAD(namespace, profile, key))
+	} else {
+		plaintext, err = encrypter.Decrypt(value)
+	}
+	switch {
+	case errors.Is(err, envelope.ErrNotEncrypted):
+		return value, nil
+	case err != nil:
+		return "", fmt.Errorf("stogo: decrypt %s/%s/%s: %w", namespace, profile, key, err)
+	}
+	return string(plaintext), nil
+}
+
+// secretAAD encryption context of a value stored under namespace, profile and key, each
+// length prefixed so that no two locations share a context.
+func secretAAD(namespace, profile, key string) []byte {
+	aad := []byte("stogo:aad:v1")
+	for _, part := range []string{namespace, profile, key} {
+		aad = binary.AppendUvarint(aad, uint64(len(part)))
+		aad = append(aad, part...)
+	}
+	return aad
+}
+
+// GetAllRedacted gets all key value pairs of a namespace and profile like
+// GetAll, masking values of secret keys when printed or marshaled.
+// Secret keys are the ones matching the configured secret key patterns.
+func (c *StooClient) GetAllRedacted(namespace, profile string, opts ...CallOption) (*RedactedMap, error) {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &RedactedMap{values: values, isSecret: c.CurrentConfig().IsSecretKey}, nil
+}
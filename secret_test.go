@@ -0,0 +1,115 @@
+package stogo_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/stogotest"
+	"testing"
+)
+
+func TestSecretEncryption(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherProvider, err := envelope.NewLocalProvider("other", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := stogo.NewStoreClient(srv.Config().WithEncrypter(envelope.NewChain(provider)))
+	other := stogo.NewStoreClient(srv.Config().WithEncrypter(envelope.NewChain(otherProvider)))
+	plain := stogo.NewStoreClient(srv.Config())
+
+	tests := []struct {
+		name    string
+		writer  *stogo.StooClient
+		reader  *stogo.StooClient
+		want    string
+		wantErr error
+	}{
+		{"encrypted secret", client, client, "s3cr3t", nil},
+		{"secret written before encryption", plain, client, "s3cr3t", nil},
+		{"secret encrypted with another key", other, client, "", envelope.ErrNoProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.writer.SetSecret("app", "prod", "db.password", "s3cr3t"); err != nil {
+				t.Fatal(err)
+			}
+			stored := srv.Data("app", "prod")["db.password"]
+			if encrypted := envelope.IsEncrypted(stored); encrypted != (tt.writer.CurrentConfig().GetEncrypter() != nil) {
+				t.Fatalf("got stored value %q encrypted %v", stored, encrypted)
+			}
+
+			secret, err := tt.reader.GetSecret("app", "prod", "db.password")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if secret.Reveal() != tt.want {
+				t.Errorf("got secret %q, want %q", secret.Reveal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretEncryptionContext(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := stogo.NewStoreClient(srv.Config().WithEncrypter(envelope.NewChain(provider)).WithEncryptionContext(true))
+	unbound := stogo.NewStoreClient(srv.Config().WithEncrypter(envelope.NewChain(provider)))
+	raw := stogo.NewStoreClient(srv.Config())
+	if _, err := client.SetSecret("app", "prod", "db.password", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unbound.SetSecret("app", "prod", "api.token", "t0ken"); err != nil {
+		t.Fatal(err)
+	}
+	stored := srv.Data("app", "prod")
+	// Copy the ciphertext elsewhere, as someone with write access to StooKV could.
+	if _, err := raw.Set("app", "prod", "db.user", stored["db.password"]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Set("app", "staging", "db.password", stored["db.password"]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		reader  *stogo.StooClient
+		profile string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"bound secret", client, "prod", "db.password", "s3cr3t", nil},
+		{"moved to another key", client, "prod", "db.user", "", envelope.ErrContextMismatch},
+		{"moved to another profile", client, "staging", "db.password", "", envelope.ErrContextMismatch},
+		{"bound secret read without context", unbound, "prod", "db.password", "", envelope.ErrContextMismatch},
+		{"secret written before the context was enabled", client, "prod", "api.token", "t0ken", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := tt.reader.GetSecret("app", tt.profile, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if secret.Reveal() != tt.want {
+				t.Errorf("got secret %q, want %q", secret.Reveal(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strings"
+)
+
+// Capability feature a StooKV server reports it supports natively.
+type Capability string
+
+const (
+	// CapabilityWatch server pushes changes. Watch polls whatever the server reports.
+	CapabilityWatch Capability = "watch"
+	// CapabilityTransactions server applies several writes atomically. Txn applies them one by one
+	// and rolls back on failure whatever the server reports.
+	CapabilityTransactions Capability = "transactions"
+	// CapabilityTTL server expires keys. SetWithTTL records expiries in bookkeeping keys reaped by
+	// the client whatever the server reports.
+	CapabilityTTL Capability = "ttl"
+	// CapabilityHealth server implements the gRPC health protocol, which HealthCheck uses when
+	// available.
+	CapabilityHealth Capability = "health"
+)
+
+const (
+	// ServerVersionHeader response header StooKV reports its version in. It differs from the
+	// stookv-version header carrying the version of a value, see GetWithMeta.
+	ServerVersionHeader = "stookv-server-version"
+	// ServerCapabilitiesHeader response header StooKV lists its capabilities in, comma separated.
+	ServerCapabilitiesHeader = "stookv-capabilities"
+)
+
+// emulatedCapabilities capabilities stogo provides on the client, the KV protocol having no
+// calls for them.
+var emulatedCapabilities = []Capability{CapabilityWatch, CapabilityTransactions, CapabilityTTL}
+
+// ServerInfo version and capabilities of a StooKV server.
+type ServerInfo struct {
+	// Endpoint address of the endpoint which answered.
+	Endpoint string
+	// Version version reported by the server, empty if it doesn't report one.
+	Version string
+	// Capabilities capabilities the server supports, sorted.
+	Capabilities []Capability
+	// Emulated capabilities the server doesn't report which stogo provides on the client, sorted.
+	Emulated []Capability
+}
+
+// Supports tells if the server supports capability natively.
+func (i *ServerInfo) Supports(capability Capability) bool {
+	for _, c := range i.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerInfo asks an endpoint of StooKV for its version and capabilities, reported in the
+// ServerVersionHeader and ServerCapabilitiesHeader response headers of a read, and checks
+// whether it implements the gRPC health protocol. The result is informational: the KV protocol
+// has no watch, transaction or TTL calls, so Watch, Txn and SetWithTTL always run on the client,
+// even against a server reporting these capabilities. Emulated lists the capabilities the server
+// doesn't report, e.g. to explain the cost of these features in diagnostics.
+//
+// Usage example:
+//
+//	info, err := client.ServerInfo(ctx)
+//	if err != nil {
+//		log.Fatalf("Error reaching StooKV %v", err)
+//	}
+//	log.Printf("StooKV %s at %s, emulating %v", info.Version, info.Endpoint, info.Emulated)
+func (c *StooClient) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	e := c.pick(healthCheckKey)
+	callCtx, cancel := c.newContext(healthCheckKey, newCallOptions([]CallOption{WithContext(ctx)}))
+	defer cancel()
+	var header metadata.MD
+	_, err := e.kv.GetService(callCtx, &proto.GetRequest{Namespace: healthCheckKey, Profile: healthCheckKey, Key: healthCheckKey}, grpc.Header(&header))
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, err
+	}
+
+	info := &ServerInfo{Endpoint: e.addr}
+	if values := header.Get(ServerVersionHeader); len(values) > 0 {
+		info.Version = values[0]
+	}
+	supported := make(map[Capability]bool)
+	for _, value := range header.Get(ServerCapabilitiesHeader) {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				supported[Capability(name)] = true
+			}
+		}
+	}
+	if e.conn != nil {
+		if _, err := grpc_health_v1.NewHealthClient(e.conn).Check(callCtx, &grpc_health_v1.HealthCheckRequest{}); err == nil {
+			supported[CapabilityHealth] = true
+		}
+	}
+	for capability := range supported {
+		info.Capabilities = append(info.Capabilities, capability)
+	}
+	sort.Slice(info.Capabilities, func(i, j int) bool { return info.Capabilities[i] < info.Capabilities[j] })
+	for _, capability := range emulatedCapabilities {
+		if !supported[capability] {
+			info.Emulated = append(info.Emulated, capability)
+		}
+	}
+	sort.Slice(info.Emulated, func(i, j int) bool { return info.Emulated[i] < info.Emulated[j] })
+	return info, nil
+}
@@ -0,0 +1,89 @@
+package stogo_test
+
+import (
+	"context"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// headerServer answers every read with a NotFound error carrying header.
+type headerServer struct {
+	proto.UnimplementedKVServiceServer
+	header metadata.MD
+}
+
+func (s *headerServer) GetService(ctx context.Context, _ *proto.GetRequest) (*proto.GetResponse, error) {
+	grpc.SetHeader(ctx, s.header)
+	return nil, status.Error(codes.NotFound, "key not found")
+}
+
+func TestServerInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       metadata.MD
+		health       bool
+		version      string
+		capabilities []stogo.Capability
+		emulated     []stogo.Capability
+	}{
+		{
+			name:     "nothing reported",
+			header:   metadata.MD{},
+			emulated: []stogo.Capability{stogo.CapabilityTransactions, stogo.CapabilityTTL, stogo.CapabilityWatch},
+		},
+		{
+			name:     "value version header is not the server version",
+			header:   metadata.Pairs("stookv-version", "42"),
+			emulated: []stogo.Capability{stogo.CapabilityTransactions, stogo.CapabilityTTL, stogo.CapabilityWatch},
+		},
+		{
+			name:         "version and capabilities",
+			header:       metadata.Pairs(stogo.ServerVersionHeader, "2.3.1", stogo.ServerCapabilitiesHeader, "Watch, ttl"),
+			health:       true,
+			version:      "2.3.1",
+			capabilities: []stogo.Capability{stogo.CapabilityHealth, stogo.CapabilityTTL, stogo.CapabilityWatch},
+			emulated:     []stogo.Capability{stogo.CapabilityTransactions},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := grpc.NewServer()
+			proto.RegisterKVServiceServer(server, &headerServer{header: tt.header})
+			if tt.health {
+				grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+			}
+			go server.Serve(lis)
+			defer server.Stop()
+
+			client := stogo.NewStoreClient(config.NewStooConfig(lis.Addr().String(), time.Second))
+			info, err := client.ServerInfo(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Version != tt.version {
+				t.Errorf("got version %q, want %q", info.Version, tt.version)
+			}
+			if !reflect.DeepEqual(info.Capabilities, tt.capabilities) {
+				t.Errorf("got capabilities %v, want %v", info.Capabilities, tt.capabilities)
+			}
+			if !reflect.DeepEqual(info.Emulated, tt.emulated) {
+				t.Errorf("got emulated %v, want %v", info.Emulated, tt.emulated)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"sync/atomic"
+)
+
+// maxShadowReads max shadow reads pending at once, further ones are dropped.
+const maxShadowReads = 64
+
+// ShadowStats counters of the shadow reads, see config.StooConfig.WithShadowEndpoint.
+type ShadowStats struct {
+	// Reads shadow reads completed.
+	Reads uint64
+	// Mismatches shadow reads which returned a different result than the main endpoints.
+	Mismatches uint64
+	// Errors shadow reads which failed.
+	Errors uint64
+	// Dropped shadow reads skipped because too many were pending.
+	Dropped uint64
+}
+
+// shadow connection reads are mirrored to.
+type shadow struct {
+	addr string
+	kv   proto.KVServiceClient
+
+	pending    atomic.Int64
+	reads      atomic.Uint64
+	mismatches atomic.Uint64
+	errors     atomic.Uint64
+	dropped    atomic.Uint64
+}
+
+// dialShadow connects to the shadow endpoint, nil if none is configured. Values read are decoded
+// like the ones of the main endpoints so that results compare.
+func dialShadow(c *StooClient) (*shadow, error) {
+	cfg := c.CurrentConfig()
+	addr := cfg.GetShadowEndpoint()
+	if addr == "" {
+		return nil, nil
+	}
+	var conn grpc.ClientConnInterface
+	var err error
+	if cfg.GetTransport() == config.TransportHTTP {
+		conn, err = newGRPCWebConn(cfg, addr, cfg.GetTls(), codecInterceptor(c))
+	} else {
+		conn, err = dial(cfg, addr, cfg.GetUseTls(), cfg.GetTls(), codecInterceptor(c))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shadow{addr: addr, kv: proto.NewKVServiceClient(conn)}, nil
+}
+
+// ShadowStats returns the counters of the shadow reads, zero if they are disabled.
+func (c *StooClient) ShadowStats() ShadowStats {
+	s := c.shadow
+	if s == nil {
+		return ShadowStats{}
+	}
+	return ShadowStats{
+		Reads:      s.reads.Load(),
+		Mismatches: s.mismatches.Load(),
+		Errors:     s.errors.Load(),
+		Dropped:    s.dropped.Load(),
+	}
+}
+
+// shadowGet mirrors a Get which returned value to the shadow endpoint.
+func (c *StooClient) shadowGet(namespace, profile, key, value string) {
+	c.shadowRead(namespace, func(ctx context.Context, kv proto.KVServiceClient) (bool, error) {
+		res, err := kv.GetService(ctx, &proto.GetRequest{Namespace: namespace, Profile: profile, Key: key})
+		return res.GetData() == value, err
+	}, func() {
+		c.CurrentConfig().GetLogger().Warnf("shadow read mismatch on %s for %s/%s/%s", c.shadow.addr, namespace, profile, key)
+	})
+}
+
+// shadowGetAll mirrors a GetAll which returned values to the shadow endpoint.
+func (c *StooClient) shadowGetAll(namespace, profile string, values map[string]string) {
+	if c.shadow == nil {
+		return
+	}
+	// Callers own the returned map, compare against a copy.
+	values = copyValues(values)
+	c.shadowRead(namespace, func(ctx context.Context, kv proto.KVServiceClient) (bool, error) {
+		res, err := kv.GetServiceByNamespaceAndProfile(ctx, &proto.GetByNamespaceAndProfileRequest{Namespace: namespace, Profile: profile})
+		return equalValues(res.GetData(), values), err
+	}, func() {
+		c.CurrentConfig().GetLogger().Warnf("shadow read mismatch on %s for %s/%s", c.shadow.addr, namespace, profile)
+	})
+}
+
+// shadowRead runs read against the shadow endpoint in the background, counting its outcome and
+// calling mismatch if the results differ. Values are never logged as they may be secret.
+func (c *StooClient) shadowRead(namespace string, read func(ctx context.Context, kv proto.KVServiceClient) (bool, error), mismatch func()) {
+	s := c.shadow
+	if s == nil {
+		return
+	}
+	if s.pending.Add(1) > maxShadowReads {
+		s.pending.Add(-1)
+		s.dropped.Add(1)
+		return
+	}
+	go func() {
+		defer s.pending.Add(-1)
+		ctx, cancel := c.newContext(namespace, newCallOptions(nil))
+		defer cancel()
+		equal, err := read(ctx, s.kv)
+		switch {
+		case err != nil:
+			s.errors.Add(1)
+			c.CurrentConfig().GetLogger().Debugf("shadow read on %s: %v", s.addr, err)
+		case !equal:
+			s.mismatches.Add(1)
+			mismatch()
+		}
+		s.reads.Add(1)
+	}()
+}
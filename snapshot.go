@@ -0,0 +1,128 @@
+package stogo
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// snapshotJitter fraction of the refresh interval refreshes are randomly moved by, so that a
+// fleet of processes started together doesn't poll StooKV in lockstep.
+const snapshotJitter = 0.1
+
+// Snapshot immutable copy of the values of a namespace and profile at a point in time.
+type Snapshot struct {
+	values    map[string]string
+	fetchedAt time.Time
+}
+
+// Get returns the value of key and whether it exists.
+func (s *Snapshot) Get(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Values returns a copy of all key value pairs.
+func (s *Snapshot) Values() map[string]string {
+	return copyValues(s.values)
+}
+
+// Len returns the number of keys.
+func (s *Snapshot) Len() int {
+	return len(s.values)
+}
+
+// FetchedAt returns the time the values were read from StooKV.
+func (s *Snapshot) FetchedAt() time.Time {
+	return s.fetchedAt
+}
+
+// SnapshotRefresher keeps an in-memory Snapshot of a namespace and profile fresh by reading it
+// again on an interval, so that reads cost no call to StooKV. It is safe for concurrent use.
+type SnapshotRefresher struct {
+	client    *StooClient
+	namespace string
+	profile   string
+	every     time.Duration
+	current   atomic.Pointer[Snapshot]
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewSnapshot reads the values of a namespace and profile and keeps them refreshed in the
+// background every refreshEvery, randomized by up to 10% either way, until Close is called.
+// It fails if the first read fails. Failed refreshes are logged and the previous snapshot is
+// kept, its FetchedAt telling how stale it is. If refreshEvery is not positive the configured
+// poll interval is used.
+//
+// Usage example:
+//
+//	flags, err := client.NewSnapshot("my-app", "prod", 30*time.Second)
+//	if err != nil {
+//		log.Fatalf("Error reading feature flags %v", err)
+//	}
+//	defer flags.Close()
+//	if v, _ := flags.Snapshot().Get("checkout.new-flow"); v == "true" {
+//		newCheckout(w, r)
+//	}
+func (c *StooClient) NewSnapshot(namespace, profile string, refreshEvery time.Duration) (*SnapshotRefresher, error) {
+	if refreshEvery <= 0 {
+		refreshEvery = c.CurrentConfig().GetPollInterval()
+	}
+	r := &SnapshotRefresher{
+		client:    c,
+		namespace: namespace,
+		profile:   profile,
+		every:     refreshEvery,
+		done:      make(chan struct{}),
+	}
+	if err := r.refresh(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.run(ctx)
+	return r, nil
+}
+
+// Snapshot returns the latest snapshot.
+func (r *SnapshotRefresher) Snapshot() *Snapshot {
+	return r.current.Load()
+}
+
+// Close stops refreshing and waits for a refresh in progress, if any. The last snapshot stays available.
+func (r *SnapshotRefresher) Close() {
+	r.cancel()
+	<-r.done
+}
+
+// run refreshes the snapshot until ctx is done.
+func (r *SnapshotRefresher) run(ctx context.Context) {
+	defer close(r.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.client.CurrentConfig().GetClock().After(jittered(r.every)):
+		}
+		if err := r.refresh(); err != nil {
+			r.client.CurrentConfig().GetLogger().Warnf("snapshot %s/%s: %v", r.namespace, r.profile, err)
+		}
+	}
+}
+
+// refresh reads the values and replaces the snapshot.
+func (r *SnapshotRefresher) refresh() error {
+	values, err := r.client.GetAll(r.namespace, r.profile)
+	if err != nil {
+		return err
+	}
+	r.current.Store(&Snapshot{values: copyValues(values), fetchedAt: r.client.CurrentConfig().GetClock().Now()})
+	return nil
+}
+
+// jittered returns d moved randomly by up to snapshotJitter of it either way.
+func jittered(d time.Duration) time.Duration {
+	return d + time.Duration((rand.Float64()*2-1)*snapshotJitter*float64(d))
+}
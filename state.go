@@ -0,0 +1,89 @@
+package stogo
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// errOffline reported to the fallback when every endpoint is failing to connect.
+var errOffline = status.Error(codes.Unavailable, "all endpoints are in transient failure")
+
+// StateChange change of the connection state of an endpoint.
+type StateChange struct {
+	// Endpoint address of the endpoint.
+	Endpoint string
+	// From state before the change.
+	From connectivity.State
+	// To state after the change, e.g. connectivity.TransientFailure when StooKV can't be reached.
+	To connectivity.State
+	// Time time the change was observed at.
+	Time time.Time
+}
+
+// WatchState calls fn for every connection state change of every endpoint, e.g. to log or
+// alert when StooKV becomes unreachable. Calls to fn are serialized. Connections are lazy, so
+// endpoints stay idle until the first call is made. WatchState blocks until ctx is done and
+// returns ctx.Err().
+//
+// Usage example:
+//
+//	go client.WatchState(ctx, func(change stogo.StateChange) {
+//		log.Printf("stooKV %s: %s -> %s", change.Endpoint, change.From, change.To)
+//	})
+func (c *StooClient) WatchState(ctx context.Context, fn func(StateChange)) error {
+	changes := make(chan StateChange)
+	var wg sync.WaitGroup
+	for _, e := range c.allEndpoints() {
+		if e.conn == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			state := e.conn.GetState()
+			for e.conn.WaitForStateChange(ctx, state) {
+				next := e.conn.GetState()
+				change := StateChange{Endpoint: e.addr, From: state, To: next, Time: c.CurrentConfig().GetClock().Now()}
+				state = next
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(e)
+	}
+	go func() {
+		wg.Wait()
+		close(changes)
+	}()
+
+	for change := range changes {
+		fn(change)
+	}
+	return ctx.Err()
+}
+
+// offline tells if every endpoint is failing to connect, in which case reads are served from
+// the fallback file, if any, without waiting for the call to time out.
+func (c *StooClient) offline(namespace string) bool {
+	for _, e := range c.endpointsFor(namespace) {
+		if e.state() != connectivity.TransientFailure {
+			return false
+		}
+	}
+	return true
+}
+
+// allEndpoints returns the endpoints of the client followed by the namespace specific ones.
+func (c *StooClient) allEndpoints() []*endpoint {
+	endpoints := append([]*endpoint(nil), c.endpoints...)
+	for _, namespaced := range c.namespaceEndpoints {
+		endpoints = append(endpoints, namespaced...)
+	}
+	return endpoints
+}
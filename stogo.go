@@ -4,27 +4,65 @@ package stogo
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/codec"
 	"github.com/mwangox/stogo/config"
 	"github.com/mwangox/stogo/proto"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
-	"log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"sync/atomic"
 )
 
 // StooClient holds stoo client and the associated configurations.
 type StooClient struct {
+	// Config configuration the client was created with, see CurrentConfig for the one in effect.
 	Config *config.StooConfig
-	client proto.KVServiceClient
+	// current configuration in effect once UpdateConfig has been called.
+	current atomic.Pointer[config.StooConfig]
+	// configMu serializes configuration updates.
+	configMu sync.Mutex
+	// endpoints connections to every configured endpoint.
+	endpoints []*endpoint
+	// namespaceEndpoints connections of namespaces having their own TLS credentials.
+	namespaceEndpoints map[string][]*endpoint
+	// idempotency results of writes made with an idempotency key.
+	idempotency idempotencyRecord
+	// cache values of prefetched profiles, see config.StooConfig.WithPrefetch.
+	cache profileCache
+	// pollers poll loops shared by watchers of the same profile.
+	pollers pollers
+	// flights GetAll calls in progress, shared by concurrent callers.
+	flights flights
+	// limits rate and concurrency limits applied to calls.
+	limits limits
+	// async concurrency limit of the calls started with the Async methods.
+	async limits
+	// shadow connection reads are mirrored to, nil if disabled.
+	shadow *shadow
+	// breaker circuit breaker failing calls fast while StooKV is failing.
+	breaker breaker
+	// fallback values served while StooKV is unreachable, see config.StooConfig.WithFallbackFile.
+	fallback fallbackStore
+	// journal mutation log writer, see config.StooConfig.WithMutationLog.
+	journal journal
+	// events topics of the profiles subscribed to with Subscribe.
+	events eventBus
 }
 
+// ErrEmptyResponse thrown when a call returns neither a response nor an error, which only
+// misbehaving interceptors or test doubles do.
+var ErrEmptyResponse = errors.New("empty response")
+
 // ErrDefaultNamespaceAndProfileMustBeDefined thrown by *default methods when called while default
 // namespace and profile are not defined.
 var ErrDefaultNamespaceAndProfileMustBeDefined = errors.New("default namespace and profile must be set to use this method")
 
-// NewStoreClient constructs stoo client from given configurations.
+// NewStoreClient constructs stoo client from given configurations. It never stops the
+// process: if the client can't be set up, the error is logged through the configured logger
+// and every call made with the returned client fails with it. Use Dial to get the error instead.
 //
 // Minimum configurations usage example:
 //
@@ -44,34 +82,67 @@ var ErrDefaultNamespaceAndProfileMustBeDefined = errors.New("default namespace a
 //
 //		client := stogo.NewStoreClient(stooConfig)
 func NewStoreClient(cfg *config.StooConfig) *StooClient {
-	var options []grpc.DialOption
-	if cfg.GetUseTls() {
-		if !cfg.GetTls().SkipTlsVerification {
-			creds, err := credentials.NewClientTLSFromFile(cfg.GetTls().CaCertPath, cfg.GetTls().ServerNameOverride)
-			if err != nil {
-				log.Fatalf("Failed to read CA cert: %v", err)
-			}
-			options = append(options, grpc.WithTransportCredentials(creds))
-		} else {
-			options = append(options, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
+	client, err := Dial(cfg)
+	if err != nil {
+		cfg.GetLogger().Errorf("failed to establish connection to stooKV: %v", err)
+		return &StooClient{
+			Config:    cfg,
+			endpoints: []*endpoint{{addr: cfg.GetEndpoint(), kv: failingKVClient{err: err}}},
 		}
-	} else {
-		options = append(options, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
+	return client
+}
 
-	conn, err := grpc.Dial(cfg.GetEndpoint(), options...)
+// Dial constructs stoo client from given configurations like NewStoreClient, returning an
+// error if the client can't be set up. The configuration is checked first with
+// config.StooConfig.Validate, which reports every problem at once.
+//
+// Usage example:
+//
+//	client, err := stogo.Dial(config.NewStooConfig("localhost:50051", 20*time.Second))
+//	if err != nil {
+//		return fmt.Errorf("connecting to stooKV: %w", err)
+//	}
+func Dial(cfg *config.StooConfig) (*StooClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	client := &StooClient{Config: cfg}
+	// The limits run before the breaker, so calls timing out while waiting for them are not
+	// counted as StooKV failures.
+	interceptors := []grpc.UnaryClientInterceptor{deadlineInterceptor(), auditInterceptor(client), readOnlyInterceptor(client), metricsInterceptor(client), client.limits.interceptor(client), client.breaker.interceptor(client), codecInterceptor(client)}
+	endpoints, err := dialEndpoints(cfg, cfg.GetUseTls(), cfg.GetTls(), interceptors...)
 	if err != nil {
-		log.Fatalf("Failed to establish connection to stooKV: %v", err)
+		return nil, err
+	}
+
+	namespaceEndpoints := make(map[string][]*endpoint)
+	for namespace, creds := range cfg.GetAllNamespaceCredentials() {
+		if creds.TLS == nil {
+			continue
+		}
+		namespaceEndpoints[namespace], err = dialEndpoints(cfg, true, creds.TLS, interceptors...)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", namespace, err)
+		}
 	}
 
-	client := proto.NewKVServiceClient(conn)
-	return &StooClient{
-		Config: cfg,
-		client: client,
+	client.shadow, err = dialShadow(client)
+	if err != nil {
+		return nil, fmt.Errorf("shadow endpoint: %w", err)
 	}
+
+	client.endpoints = endpoints
+	client.namespaceEndpoints = namespaceEndpoints
+	client.prefetch()
+	return client, nil
 }
 
-// Get gets a value stored using namespace, profile and key.
+// Get gets a value stored using namespace, profile and key. Missing keys fail with
+// ErrKeyNotFound, while keys holding an empty value return it with no error. Missing keys are
+// told apart by the NotFound status StooKV answers them with; as servers which answer them with
+// an empty value instead can't be told apart from empty values, Get confirms empty values exist
+// by reading the whole profile.
 //
 //	 Usage example:
 //		   data, err := client.Get("my-app", "prod", "database.username")
@@ -79,16 +150,69 @@ func NewStoreClient(cfg *config.StooConfig) *StooClient {
 //		     log.Fatalf("Error reading key from server %v", err)
 //		   }
 //		   log.Printf("Result: %v", data)
-func (c *StooClient) Get(namespace, profile, key string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.Config.GetReadTimeout())
+func (c *StooClient) Get(namespace, profile, key string, opts ...CallOption) (string, error) {
+	o := newCallOptions(opts)
+	if value, ok := overridesFrom(o.ctx)[key]; ok {
+		return value, nil
+	}
+	if c.CurrentConfig().IsPrefetched(namespace, profile) {
+		if values, err := c.getAll(namespace, profile, o); err == nil {
+			if value, ok := values[key]; ok {
+				return value, nil
+			}
+			return "", keyNotFound(namespace, profile, key, nil)
+		}
+	}
+	if c.offline(namespace) {
+		if values, ok := c.loadFallback(o.tenant, namespace, profile, errOffline); ok {
+			if value, ok := values[key]; ok {
+				return value, nil
+			}
+		}
+	}
+	ctx, cancel := c.newContext(namespace, o)
 	defer cancel()
 
-	res, err := c.client.GetService(ctx, &proto.GetRequest{
+	res, err := c.kv(namespace).GetService(ctx, &proto.GetRequest{
 		Namespace: namespace,
 		Profile:   profile,
 		Key:       key,
 	})
-	return res.GetData(), err
+	if err != nil {
+		if values, ok := c.loadFallback(o.tenant, namespace, profile, err); ok {
+			if value, ok := values[key]; ok {
+				return value, nil
+			}
+		}
+		if status.Code(err) == codes.NotFound {
+			return "", keyNotFound(namespace, profile, key, err)
+		}
+		return "", callError("get", namespace, profile, key, err)
+	}
+	if res == nil {
+		return "", callError("get", namespace, profile, key, ErrEmptyResponse)
+	}
+	if err := c.confirmEmpty(namespace, profile, key, res.GetData(), o); err != nil {
+		return "", err
+	}
+	c.shadowGet(namespace, profile, key, res.GetData())
+	return res.GetData(), nil
+}
+
+// confirmEmpty fails with ErrKeyNotFound if value, read from StooKV for key, is empty and the key
+// is missing from the profile. Value is trusted if the profile can't be read.
+func (c *StooClient) confirmEmpty(namespace, profile, key, value string, o *callOptions) error {
+	if value != "" {
+		return nil
+	}
+	values, err := c.getAll(namespace, profile, o)
+	if err != nil {
+		return nil
+	}
+	if _, ok := values[key]; !ok {
+		return keyNotFound(namespace, profile, key, nil)
+	}
+	return nil
 }
 
 // Set sets a key to a namespace and profile.
@@ -100,19 +224,47 @@ func (c *StooClient) Get(namespace, profile, key string) (string, error) {
 //		      log.Fatalf("Error in setting value %v", err)
 //		  }
 //		  log.Printf("Set result: %v", res)
-func (c *StooClient) Set(namespace, profile, key, value string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.Config.GetReadTimeout())
+func (c *StooClient) Set(namespace, profile, key, value string, opts ...CallOption) (string, error) {
+	o := newCallOptions(opts)
+	if res, ok := c.idempotency.lookup(o.tenant, o.idempotencyKey); ok {
+		return res, nil
+	}
+	if err := c.checkValueSize(namespace, profile, key, value); err != nil {
+		return "", err
+	}
+	if err := c.checkSchema(namespace, profile, mutation{key: key, value: value}, o); err != nil {
+		return "", err
+	}
+	if o.codec != nil {
+		encoded, err := codec.Encode(o.codec, value)
+		if err != nil {
+			return "", callError("set", namespace, profile, key, err)
+		}
+		value = encoded
+	}
+	defer c.invalidateCache(o.tenant, namespace, profile)
+	ctx, cancel := c.newContext(namespace, o)
 	defer cancel()
-	res, err := c.client.SetKeyService(ctx, &proto.SetKeyRequest{
+	res, err := c.kv(namespace).SetKeyService(ctx, &proto.SetKeyRequest{
 		Namespace: namespace,
 		Profile:   profile,
 		Key:       key,
 		Value:     value,
 	})
-	return res.GetData(), err
+	if err != nil {
+		return "", callError("set", namespace, profile, key, err)
+	}
+	if res == nil {
+		return "", callError("set", namespace, profile, key, ErrEmptyResponse)
+	}
+	c.idempotency.record(o.tenant, o.idempotencyKey, res.GetData())
+	return res.GetData(), nil
 }
 
-// SetSecret sets a key to a namespace and profile in an encrypted format.
+// SetSecret sets a key to a namespace and profile in an encrypted format. If an encrypter is
+// configured, the value is also encrypted on the client before being sent, see GetSecret, and
+// bound to namespace, profile and key if config.StooConfig.WithEncryptionContext is set.
+//
 // Usage example:
 //
 //	   res, err := client.SetSecret("my-app", "prod", "database.password", "the-scrore@1996")
@@ -120,16 +272,38 @@ func (c *StooClient) Set(namespace, profile, key, value string) (string, error)
 //		      log.Fatalf("Error in setting secret value %v", err)
 //		  }
 //		  log.Printf("SetSecret result: %v", res)
-func (c *StooClient) SetSecret(namespace, profile, key, value string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.Config.GetReadTimeout())
+func (c *StooClient) SetSecret(namespace, profile, key, value string, opts ...CallOption) (string, error) {
+	o := newCallOptions(opts)
+	if res, ok := c.idempotency.lookup(o.tenant, o.idempotencyKey); ok {
+		return res, nil
+	}
+	if err := c.checkValueSize(namespace, profile, key, value); err != nil {
+		return "", err
+	}
+	if err := c.checkSchema(namespace, profile, mutation{key: key, value: value, secret: true}, o); err != nil {
+		return "", err
+	}
+	value, err := encryptSecret(c.CurrentConfig(), namespace, profile, key, value)
+	if err != nil {
+		return "", callError("set secret", namespace, profile, key, err)
+	}
+	defer c.invalidateCache(o.tenant, namespace, profile)
+	ctx, cancel := c.newContext(namespace, o)
 	defer cancel()
-	res, err := c.client.SetSecretKeyService(ctx, &proto.SetKeyRequest{
+	res, err := c.kv(namespace).SetSecretKeyService(ctx, &proto.SetKeyRequest{
 		Namespace: namespace,
 		Profile:   profile,
 		Key:       key,
 		Value:     value,
 	})
-	return res.GetData(), err
+	if err != nil {
+		return "", callError("set secret", namespace, profile, key, err)
+	}
+	if res == nil {
+		return "", callError("set secret", namespace, profile, key, ErrEmptyResponse)
+	}
+	c.idempotency.record(o.tenant, o.idempotencyKey, res.GetData())
+	return res.GetData(), nil
 }
 
 // Delete removes a key from a given namespace and profile
@@ -141,85 +315,189 @@ func (c *StooClient) SetSecret(namespace, profile, key, value string) (string, e
 //		    log.Fatalf("Error deleting a key %v", err)
 //	   }
 //	   log.Printf("delete result: %v", res)
-func (c *StooClient) Delete(namespace, profile, key string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.Config.GetReadTimeout())
+func (c *StooClient) Delete(namespace, profile, key string, opts ...CallOption) (string, error) {
+	o := newCallOptions(opts)
+	if res, ok := c.idempotency.lookup(o.tenant, o.idempotencyKey); ok {
+		return res, nil
+	}
+	if err := c.checkSchema(namespace, profile, mutation{key: key, delete: true}, o); err != nil {
+		return "", err
+	}
+	defer c.invalidateCache(o.tenant, namespace, profile)
+	ctx, cancel := c.newContext(namespace, o)
 	defer cancel()
-	res, err := c.client.DeleteKeyService(ctx, &proto.DeleteKeyRequest{
+	res, err := c.kv(namespace).DeleteKeyService(ctx, &proto.DeleteKeyRequest{
 		Namespace: namespace,
 		Profile:   profile,
 		Key:       key,
 	})
-	return res.GetData(), err
+	if err != nil {
+		return "", callError("delete", namespace, profile, key, err)
+	}
+	if res == nil {
+		return "", callError("delete", namespace, profile, key, ErrEmptyResponse)
+	}
+	c.idempotency.record(o.tenant, o.idempotencyKey, res.GetData())
+	return res.GetData(), nil
 }
 
-// GetAllByNamespaceAndProfile gets all keys from a given namespace and profile.
+// GetAll gets all keys from a given namespace and profile.
+// The whole profile comes back in one response, so large profiles may need
+// config.StooConfig.WithMaxReceiveSize. With config.StooConfig.WithFallbackFile, results are
+// saved and served from the file while StooKV is unreachable, right away once every endpoint
+// is in transient failure.
 //
 // Usage example:
 //
-//	  all, err := client.GetAllByNamespaceAndProfile("my-app", "prod")
+//	  all, err := client.GetAll("my-app", "prod")
 //	  if err != nil {
 //		   log.Fatalf("Error reading all keys from server %v", err)
 //	  }
 //	  log.Printf("all keys values : %v", all)
-func (c *StooClient) GetAllByNamespaceAndProfile(namespace, profile string) (map[string]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.Config.GetReadTimeout())
+func (c *StooClient) GetAll(namespace, profile string, opts ...CallOption) (map[string]string, error) {
+	o := newCallOptions(opts)
+	values, err := c.getAll(namespace, profile, o)
+	if err != nil {
+		return nil, err
+	}
+	return applyOverrides(o.ctx, values), nil
+}
+
+// GetAllByNamespaceAndProfile gets all keys from a given namespace and profile.
+//
+// Deprecated: use GetAll.
+func (c *StooClient) GetAllByNamespaceAndProfile(namespace, profile string, opts ...CallOption) (map[string]string, error) {
+	return c.GetAll(namespace, profile, opts...)
+}
+
+// Range gets all keys from a given namespace and profile like GetAll and calls fn with every
+// key value pair in key order, stopping early if fn returns false.
+//
+// Usage example:
+//
+//	err := client.Range("my-app", "prod", func(key, value string) bool {
+//		fmt.Printf("%s=%s\n", key, value)
+//		return true
+//	})
+func (c *StooClient) Range(namespace, profile string, fn func(key, value string) bool, opts ...CallOption) error {
+	values, err := c.GetAll(namespace, profile, opts...)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(values) {
+		if !fn(key, values[key]) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// getAll gets all keys of a profile from the cache, StooKV or the fallback file. Concurrent
+// calls not bound to a caller context nor carrying headers of their own share a single call to StooKV.
+func (c *StooClient) getAll(namespace, profile string, o *callOptions) (map[string]string, error) {
+	if values, ok := c.cached(o.tenant, namespace, profile); ok {
+		return values, nil
+	}
+	if c.offline(namespace) {
+		if values, ok := c.loadFallback(o.tenant, namespace, profile, errOffline); ok {
+			return values, nil
+		}
+	}
+	if o.ctx == context.Background() && len(o.headers) == 0 {
+		return c.flights.do(newProfileKey(o.tenant, namespace, profile), func() (map[string]string, error) {
+			return c.fetchAll(namespace, profile, o)
+		})
+	}
+	return c.fetchAll(namespace, profile, o)
+}
+
+// fetchAll gets all keys of a profile from StooKV, or from the fallback file if it is unreachable.
+func (c *StooClient) fetchAll(namespace, profile string, o *callOptions) (map[string]string, error) {
+	ctx, cancel := c.newContext(namespace, o)
 	defer cancel()
-	res, err := c.client.GetServiceByNamespaceAndProfile(ctx, &proto.GetByNamespaceAndProfileRequest{
+	res, err := c.kv(namespace).GetServiceByNamespaceAndProfile(ctx, &proto.GetByNamespaceAndProfileRequest{
 		Namespace: namespace,
 		Profile:   profile,
 	})
-	return res.GetData(), err
+	if err != nil {
+		if values, ok := c.loadFallback(o.tenant, namespace, profile, err); ok {
+			return values, nil
+		}
+		return nil, callError("get all", namespace, profile, "", err)
+	}
+	if res == nil {
+		return nil, callError("get all", namespace, profile, "", ErrEmptyResponse)
+	}
+	c.storeCache(o.tenant, namespace, profile, res.GetData())
+	c.saveFallback(o.tenant, namespace, profile, res.GetData())
+	c.shadowGetAll(namespace, profile, res.GetData())
+	return res.GetData(), nil
 }
 
 // GetDefault gets a value for a key in a given default namespace and profile.
-func (c *StooClient) GetDefault(key string) (string, error) {
-	defaultNamespace := c.Config.GetDefaultNamespace()
-	defaultProfile := c.Config.GetDefaultProfile()
+func (c *StooClient) GetDefault(key string, opts ...CallOption) (string, error) {
+	defaultNamespace := c.CurrentConfig().GetDefaultNamespace()
+	defaultProfile := c.CurrentConfig().GetDefaultProfile()
 	if err := validateDefaultNamespaceAndProfile(defaultNamespace, defaultProfile); err != nil {
 		return "", err
 	}
-	return c.Get(defaultNamespace, defaultProfile, key)
+	return c.Get(defaultNamespace, defaultProfile, key, opts...)
 }
 
 // SetDefault sets value for a key in a given default namespace and profile.
-func (c *StooClient) SetDefault(key, value string) (string, error) {
-	defaultNamespace := c.Config.GetDefaultNamespace()
-	defaultProfile := c.Config.GetDefaultProfile()
+func (c *StooClient) SetDefault(key, value string, opts ...CallOption) (string, error) {
+	defaultNamespace := c.CurrentConfig().GetDefaultNamespace()
+	defaultProfile := c.CurrentConfig().GetDefaultProfile()
 	if err := validateDefaultNamespaceAndProfile(defaultNamespace, defaultProfile); err != nil {
 		return "", err
 	}
-	return c.Set(defaultNamespace, defaultProfile, key, value)
+	return c.Set(defaultNamespace, defaultProfile, key, value, opts...)
 }
 
 // SetSecretDefault sets secret value for a key in a given default namespace and profile.
-func (c *StooClient) SetSecretDefault(key, value string) (string, error) {
-	defaultNamespace := c.Config.GetDefaultNamespace()
-	defaultProfile := c.Config.GetDefaultProfile()
+func (c *StooClient) SetSecretDefault(key, value string, opts ...CallOption) (string, error) {
+	defaultNamespace := c.CurrentConfig().GetDefaultNamespace()
+	defaultProfile := c.CurrentConfig().GetDefaultProfile()
 	if err := validateDefaultNamespaceAndProfile(defaultNamespace, defaultProfile); err != nil {
 		return "", err
 	}
-	return c.SetSecret(defaultNamespace, defaultProfile, key, value)
+	return c.SetSecret(defaultNamespace, defaultProfile, key, value, opts...)
 }
 
 // DeleteDefault removes a key from a given default namespace and profile.
-func (c *StooClient) DeleteDefault(key string) (string, error) {
-	defaultNamespace := c.Config.GetDefaultNamespace()
-	defaultProfile := c.Config.GetDefaultProfile()
+func (c *StooClient) DeleteDefault(key string, opts ...CallOption) (string, error) {
+	defaultNamespace := c.CurrentConfig().GetDefaultNamespace()
+	defaultProfile := c.CurrentConfig().GetDefaultProfile()
 	if err := validateDefaultNamespaceAndProfile(defaultNamespace, defaultProfile); err != nil {
 		return "", err
 	}
-	return c.Delete(defaultNamespace, defaultProfile, key)
+	return c.Delete(defaultNamespace, defaultProfile, key, opts...)
 }
 
-// GetAllByDefaultNamespaceAndProfile gets all key value pairs from a given default namespace and profile.
-func (c *StooClient) GetAllByDefaultNamespaceAndProfile() (map[string]string, error) {
-	defaultNamespace := c.Config.GetDefaultNamespace()
-	defaultProfile := c.Config.GetDefaultProfile()
+// GetAllDefault gets all key value pairs from a given default namespace and profile.
+func (c *StooClient) GetAllDefault(opts ...CallOption) (map[string]string, error) {
+	defaultNamespace := c.CurrentConfig().GetDefaultNamespace()
+	defaultProfile := c.CurrentConfig().GetDefaultProfile()
 	if err := validateDefaultNamespaceAndProfile(defaultNamespace, defaultProfile); err != nil {
 		return nil, err
 	}
-	return c.GetAllByNamespaceAndProfile(defaultNamespace, defaultProfile)
+	return c.GetAll(defaultNamespace, defaultProfile, opts...)
+}
+
+// GetAllByDefaultNamespaceAndProfile gets all key value pairs from a given default namespace and profile.
+//
+// Deprecated: use GetAllDefault.
+func (c *StooClient) GetAllByDefaultNamespaceAndProfile(opts ...CallOption) (map[string]string, error) {
+	return c.GetAllDefault(opts...)
+}
 
+// callError wraps err, the error of op on namespace, profile and key, with that context. key is
+// omitted when empty, for calls on a whole profile.
+func callError(op, namespace, profile, key string, err error) error {
+	if key == "" {
+		return fmt.Errorf("stogo: %s %s/%s: %w", op, namespace, profile, err)
+	}
+	return fmt.Errorf("stogo: %s %s/%s/%s: %w", op, namespace, profile, key, err)
 }
 
 // validateDefaultNamespaceAndProfile checks if all defaultNamespace and defaultProfile are being set.
@@ -0,0 +1,74 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"net"
+	"testing"
+	"time"
+)
+
+// emptyServer answers reads of missing keys with an empty value rather than NotFound.
+type emptyServer struct {
+	*stogotest.Server
+}
+
+func (s emptyServer) GetService(_ context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	return &proto.GetResponse{Data: s.Data(req.GetNamespace(), req.GetProfile())[req.GetKey()]}, nil
+}
+
+func TestGetMissingKey(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	for key, value := range map[string]string{"db.host": "localhost", "db.password": ""} {
+		if _, err := stogo.NewStoreClient(srv.Config()).Set("app", "prod", key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	proto.RegisterKVServiceServer(server, emptyServer{Server: srv})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	tests := []struct {
+		name    string
+		config  *config.StooConfig
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"not found status", srv.Config(), "db.port", "", stogo.ErrKeyNotFound},
+		{"empty value", srv.Config(), "db.password", "", nil},
+		{"value", srv.Config(), "db.host", "localhost", nil},
+		{"missing key read as empty", config.NewStooConfig(lis.Addr().String(), time.Second), "db.port", "", stogo.ErrKeyNotFound},
+		{"empty value read as empty", config.NewStooConfig(lis.Addr().String(), time.Second), "db.password", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := stogo.NewStoreClient(tt.config)
+			got, err := client.Get("app", "prod", tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			_, _, err = client.GetWithMeta("app", "prod", tt.key)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v reading with metadata, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
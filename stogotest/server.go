@@ -0,0 +1,141 @@
+// Package stogotest provides an in-memory StooKV server and a fake clock for testing code
+// built on top of stogo without a running StooKV instance.
+package stogotest
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync"
+)
+
+// Server in-memory implementation of the StooKV gRPC service listening on a loopback port.
+//
+// Usage example:
+//
+//	srv, err := stogotest.NewServer()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer srv.Close()
+//	client := stogo.NewStoreClient(srv.Config())
+type Server struct {
+	proto.UnimplementedKVServiceServer
+
+	mu       sync.Mutex
+	data     map[string]map[string]string
+	listener net.Listener
+	server   *grpc.Server
+}
+
+// NewServer starts a Server on a random loopback port.
+func NewServer() (*Server, error) {
+	return listen("tcp", "127.0.0.1:0")
+}
+
+// NewUnixServer starts a Server listening on a Unix domain socket at path.
+func NewUnixServer(path string) (*Server, error) {
+	return listen("unix", path)
+}
+
+// listen starts a Server listening on address of network.
+func listen(network, address string) (*Server, error) {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		data:     make(map[string]map[string]string),
+		listener: listener,
+		server:   grpc.NewServer(),
+	}
+	proto.RegisterKVServiceServer(s.server, s)
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// Addr returns the address the server is listening on, as an endpoint usable by StooConfig.
+func (s *Server) Addr() string {
+	if s.listener.Addr().Network() == "unix" {
+		return "unix://" + s.listener.Addr().String()
+	}
+	return s.listener.Addr().String()
+}
+
+// Config returns a StooConfig pointing to the server.
+func (s *Server) Config() *config.StooConfig {
+	return config.NewStooConfig(s.Addr(), 0)
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.server.Stop()
+}
+
+// Data returns a copy of all key value pairs stored in a namespace and profile.
+func (s *Server) Data(namespace, profile string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := make(map[string]string, len(s.data[scope(namespace, profile)]))
+	for k, v := range s.data[scope(namespace, profile)] {
+		data[k] = v
+	}
+	return data
+}
+
+// GetService implements proto.KVServiceServer, failing with NotFound for missing keys. Clients
+// don't depend on it, reading the whole profile to tell missing keys from empty values.
+func (s *Server) GetService(_ context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.data[scope(req.GetNamespace(), req.GetProfile())][req.GetKey()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "key %s not found", req.GetKey())
+	}
+	return &proto.GetResponse{Data: value}, nil
+}
+
+// GetServiceByNamespaceAndProfile implements proto.KVServiceServer.
+func (s *Server) GetServiceByNamespaceAndProfile(_ context.Context, req *proto.GetByNamespaceAndProfileRequest) (*proto.GetByNamespaceAndProfileResponse, error) {
+	return &proto.GetByNamespaceAndProfileResponse{Data: s.Data(req.GetNamespace(), req.GetProfile())}, nil
+}
+
+// SetKeyService implements proto.KVServiceServer.
+func (s *Server) SetKeyService(_ context.Context, req *proto.SetKeyRequest) (*proto.SetKeyResponse, error) {
+	s.set(req)
+	return &proto.SetKeyResponse{Data: "OK"}, nil
+}
+
+// SetSecretKeyService implements proto.KVServiceServer. Secrets are kept in plain text.
+func (s *Server) SetSecretKeyService(_ context.Context, req *proto.SetKeyRequest) (*proto.SetKeyResponse, error) {
+	s.set(req)
+	return &proto.SetKeyResponse{Data: "OK"}, nil
+}
+
+// DeleteKeyService implements proto.KVServiceServer.
+func (s *Server) DeleteKeyService(_ context.Context, req *proto.DeleteKeyRequest) (*proto.DeleteKeyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data[scope(req.GetNamespace(), req.GetProfile())], req.GetKey())
+	return &proto.DeleteKeyResponse{Data: "OK"}, nil
+}
+
+// set stores the key value pair of req.
+func (s *Server) set(req *proto.SetKeyRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc := scope(req.GetNamespace(), req.GetProfile())
+	if s.data[sc] == nil {
+		s.data[sc] = make(map[string]string)
+	}
+	s.data[sc][req.GetKey()] = req.GetValue()
+}
+
+// scope builds the storage key of a namespace and profile.
+func scope(namespace, profile string) string {
+	return namespace + "/" + profile
+}
@@ -0,0 +1,64 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"strings"
+	"time"
+)
+
+// WatchSubtree watches the keys of a namespace and profile starting with prefix and calls fn
+// with the whole subtree, keyed by full key names, rather than with individual changes. The
+// first snapshot is delivered right away, later ones once the subtree stayed unchanged for
+// debounce, so a burst of changes results in a single call with the final state. Subtrees which
+// end up as they were last delivered are not delivered again. Polling is shared with Watch, at
+// the configured poll interval. WatchSubtree blocks until ctx is done and returns ctx.Err().
+//
+// Usage example:
+//
+//	go client.WatchSubtree(ctx, "my-app", "prod", "database.", 5*time.Second, func(db map[string]string) {
+//		pool.Reconfigure(db["database.url"], db["database.pool.size"])
+//	})
+func (c *StooClient) WatchSubtree(ctx context.Context, namespace, profile, prefix string, debounce time.Duration, fn func(map[string]string)) error {
+	updates, unsubscribe := c.pollers.subscribe(c, config.NamespaceProfile{Namespace: namespace, Profile: profile}, c.CurrentConfig().GetPollInterval())
+	defer unsubscribe()
+
+	var delivered, latest map[string]string
+	seen := false
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case data := <-updates:
+			sub := subtree(data, prefix)
+			if !seen {
+				seen = true
+				delivered, latest = sub, sub
+				fn(copyValues(sub))
+				continue
+			}
+			if !equalValues(sub, latest) {
+				latest = sub
+				settled = c.CurrentConfig().GetClock().After(debounce)
+			}
+		case <-settled:
+			settled = nil
+			if !equalValues(latest, delivered) {
+				delivered = latest
+				fn(copyValues(latest))
+			}
+		}
+	}
+}
+
+// subtree returns the values whose key starts with prefix, leaving out bookkeeping keys.
+func subtree(values map[string]string, prefix string) map[string]string {
+	sub := make(map[string]string)
+	for key, value := range values {
+		if strings.HasPrefix(key, prefix) && !IsReservedKey(key) {
+			sub[key] = value
+		}
+	}
+	return sub
+}
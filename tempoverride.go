@@ -0,0 +1,213 @@
+package stogo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/envelope"
+	"sync"
+	"time"
+)
+
+// TemporaryOverride override set by OverrideTemporarily.
+type TemporaryOverride struct {
+	client    *StooClient
+	namespace string
+	profile   string
+	key       string
+	expiresAt time.Time
+	// opts tenant and headers of the override, replayed to revert it.
+	opts []CallOption
+
+	once sync.Once
+	stop chan struct{}
+	err  error
+}
+
+// overrideRecord reserved record of a temporary override, kept next to the key.
+type overrideRecord struct {
+	Original  string    `json:"original"`
+	Existed   bool      `json:"existed"`
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Secret tells the key is overridden and restored as a secret, the record being written
+	// with SetSecret too.
+	Secret bool `json:"secret,omitempty"`
+	// Tenant and Headers of the overriding write, replayed to revert it.
+	Tenant  string   `json:"tenant,omitempty"`
+	Headers []string `json:"headers,omitempty"`
+}
+
+// options returns call options replaying the tenant and headers of the overriding write.
+func (r *overrideRecord) options() []CallOption {
+	opts := []CallOption{WithTenant(r.Tenant)}
+	for i := 0; i+1 < len(r.Headers); i += 2 {
+		opts = append(opts, WithHeader(r.Headers[i], r.Headers[i+1]))
+	}
+	return opts
+}
+
+// OverrideTemporarily sets a key to value for d and then restores the value it had before, or
+// deletes it if it didn't exist, e.g. to raise a timeout during an incident. The original value
+// is recorded in a reserved __stogo.override.* key, so the revert doesn't depend on this process:
+// it is done by the returned override once d elapsed while the process runs, and otherwise by a
+// Reaper. Overriding a key already overridden keeps the original value and moves the expiry.
+// The key isn't reverted if it was changed by someone else in the meantime. Keys matching the
+// secret key patterns or holding a value encrypted on the client are overridden and restored as
+// secrets, and their record, which holds the original value, is written with SetSecret so that
+// it is encrypted like the secret itself. The revert is made for the tenant and with the headers
+// of opts, a Reaper must be created with Reaper.WithTenant to revert overrides of a tenant.
+//
+// Usage example:
+//
+//	o, err := client.OverrideTemporarily("my-app", "prod", "http.timeout", "30s", time.Hour)
+//	if err != nil {
+//		log.Fatalf("Error overriding value %v", err)
+//	}
+//	// incident mitigated early
+//	err = o.Revert()
+func (c *StooClient) OverrideTemporarily(namespace, profile, key, value string, d time.Duration, opts ...CallOption) (*TemporaryOverride, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("stogo: override duration must be positive, got %s", d)
+	}
+	record, err := c.overrideRecord(namespace, profile, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		original, err := c.Get(namespace, profile, key, opts...)
+		switch {
+		case errors.Is(err, ErrKeyNotFound):
+			record = &overrideRecord{}
+		case err != nil:
+			return nil, err
+		default:
+			record = &overrideRecord{Original: original, Existed: true}
+		}
+	}
+	cfg := c.CurrentConfig()
+	record.Value = value
+	record.ExpiresAt = cfg.GetClock().Now().Add(d).UTC()
+	record.Secret = record.Secret || cfg.IsSecretKey(key) || envelope.IsEncrypted(record.Original)
+	o := newCallOptions(opts)
+	record.Tenant, record.Headers = o.tenant, o.headers
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	set := c.Set
+	if record.Secret {
+		set = c.SetSecret
+	}
+	if _, err := set(namespace, profile, overrideKey(key), string(data), bookkeepingOptions(opts)...); err != nil {
+		return nil, err
+	}
+	if _, err := set(namespace, profile, key, value, opts...); err != nil {
+		return nil, err
+	}
+
+	override := &TemporaryOverride{
+		client:    c,
+		namespace: namespace,
+		profile:   profile,
+		key:       key,
+		expiresAt: record.ExpiresAt,
+		opts:      record.options(),
+		stop:      make(chan struct{}),
+	}
+	go override.expire(d)
+	return override, nil
+}
+
+// ExpiresAt returns the time the override is reverted at.
+func (o *TemporaryOverride) ExpiresAt() time.Time {
+	return o.expiresAt
+}
+
+// Revert restores the original value now. Only the first call reverts, later ones return the
+// outcome of the first.
+func (o *TemporaryOverride) Revert() error {
+	o.once.Do(func() {
+		close(o.stop)
+		o.err = o.client.revertOverride(o.namespace, o.profile, o.key, o.opts...)
+	})
+	return o.err
+}
+
+// expire reverts the override once d elapsed, unless reverted before.
+func (o *TemporaryOverride) expire(d time.Duration) {
+	select {
+	case <-o.stop:
+	case <-o.client.CurrentConfig().GetClock().After(d):
+		if err := o.Revert(); err != nil {
+			o.client.CurrentConfig().GetLogger().Warnf("reverting override of %s/%s/%s: %v", o.namespace, o.profile, o.key, err)
+		}
+	}
+}
+
+// overrideRecord returns the override record of key, read with opts, nil if it isn't overridden.
+func (c *StooClient) overrideRecord(namespace, profile, key string, opts ...CallOption) (*overrideRecord, error) {
+	raw, err := c.Get(namespace, profile, overrideKey(key), opts...)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseOverrideRecord(c.CurrentConfig(), namespace, profile, key, raw)
+}
+
+// parseOverrideRecord decodes the override record of key stored as raw, decrypting it first
+// if it was written as a secret with client side encryption.
+func parseOverrideRecord(cfg *config.StooConfig, namespace, profile, key, raw string) (*overrideRecord, error) {
+	raw, err := decryptSecret(cfg, namespace, profile, overrideKey(key), raw)
+	if err != nil {
+		return nil, err
+	}
+	record := &overrideRecord{}
+	if err := json.Unmarshal([]byte(raw), record); err != nil {
+		return nil, fmt.Errorf("stogo: override record of %s/%s/%s: %w", namespace, profile, key, err)
+	}
+	return record, nil
+}
+
+// revertOverride restores the original value of an overridden key, unless it was changed
+// since, and removes the override record. The record is read with opts, the revert replays the
+// tenant and headers of the override.
+func (c *StooClient) revertOverride(namespace, profile, key string, opts ...CallOption) error {
+	record, err := c.overrideRecord(namespace, profile, key, opts...)
+	if err != nil || record == nil {
+		return err
+	}
+	opts = record.options()
+	current, err := c.Get(namespace, profile, key, opts...)
+	if err == nil && record.Secret {
+		current, err = decryptSecret(c.CurrentConfig(), namespace, profile, key, current)
+	}
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		c.CurrentConfig().GetLogger().Warnf("not reverting override of %s/%s/%s: key was deleted", namespace, profile, key)
+	case err != nil:
+		return err
+	case current != record.Value:
+		c.CurrentConfig().GetLogger().Warnf("not reverting override of %s/%s/%s: value was changed", namespace, profile, key)
+	case record.Existed:
+		// The original value is restored as it was read, already encrypted if it was.
+		restore := mutation{key: key, value: record.Original, secret: record.Secret, raw: record.Secret}
+		if err := c.mutate(namespace, profile, restore, opts...); err != nil {
+			return err
+		}
+	default:
+		if _, err := c.Delete(namespace, profile, key, opts...); err != nil {
+			return err
+		}
+	}
+	_, err = c.Delete(namespace, profile, overrideKey(key), opts...)
+	return err
+}
+
+// overrideKey returns the reserved key holding the override record of key.
+func overrideKey(key string) string {
+	return ReservedKeyPrefix + "override." + key
+}
@@ -0,0 +1,195 @@
+package stogo_test
+
+import (
+	"context"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/envelope"
+	"github.com/mwangox/stogo/stogotest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOverrideTemporarily(t *testing.T) {
+	provider, err := envelope.NewLocalProvider("test", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		key  string
+		// secret tells the original value is set with SetSecret.
+		secret bool
+		opts   []stogo.CallOption
+		// reap tells the override is reverted by a Reaper of another process.
+		reap bool
+	}{
+		{"plain value", "http.timeout", false, nil, false},
+		{"idempotency key", "http.timeout", false, []stogo.CallOption{stogo.WithIdempotencyKey("incident-42")}, false},
+		{"secret key pattern", "db.password", false, nil, false},
+		{"secret read encrypted", "api.token", true, nil, false},
+		{"secret reverted by a reaper", "api.token", true, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			recorder := &writeRecorder{methods: make(map[string]string)}
+			newClient := func(now time.Time) *stogo.StooClient {
+				return stogo.NewStoreClient(srv.Config().
+					WithClock(stogotest.NewFakeClock(now)).
+					WithEncrypter(envelope.NewChain(provider)).
+					WithSecretKeyPatterns("*.password").
+					WithUnaryInterceptors(recorder.interceptor))
+			}
+			client := newClient(time.Now())
+			set := client.Set
+			if tt.secret {
+				set = client.SetSecret
+			}
+			if _, err := set("app", "prod", tt.key, "original"); err != nil {
+				t.Fatal(err)
+			}
+			original := srv.Data("app", "prod")[tt.key]
+
+			o, err := client.OverrideTemporarily("app", "prod", tt.key, "override", time.Hour, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			overridden := srv.Data("app", "prod")
+			wantSecret := tt.secret || tt.key == "db.password"
+			if record := overridden[stogo.ReservedKeyPrefix+"override."+tt.key]; envelope.IsEncrypted(record) != wantSecret {
+				t.Fatalf("got override record %q, want it encrypted %v", record, wantSecret)
+			}
+			if value := overridden[tt.key]; value == original || envelope.IsEncrypted(value) != wantSecret {
+				t.Fatalf("got %q while overridden, want the override encrypted %v", value, wantSecret)
+			}
+
+			if tt.reap {
+				result, err := stogo.NewReaper(newClient(time.Now().Add(2*time.Hour)), "app", "prod").ReapOnce()
+				if err == nil {
+					err = result.Err()
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err := o.Revert(); err != nil {
+				t.Fatal(err)
+			}
+			data := srv.Data("app", "prod")
+			if len(data) != 1 || data[tt.key] != original {
+				t.Fatalf("got %v after revert, want only the original value", data)
+			}
+			wantMethod := "SetKeyService"
+			if wantSecret {
+				wantMethod = "SetSecretKeyService"
+			}
+			if method := recorder.methods[tt.key]; method != wantMethod {
+				t.Errorf("restored with %s, want %s", method, wantMethod)
+			}
+		})
+	}
+}
+
+// call method and metadata of a call, recorded by callRecorder.
+type call struct {
+	method    string
+	tenant    string
+	requestID string
+}
+
+// callRecorder records every call made through it.
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []call
+}
+
+func (r *callRecorder) interceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c := call{method: path.Base(method)}
+	if v := md.Get(config.DefaultTenantHeader); len(v) > 0 {
+		c.tenant = v[0]
+	}
+	if v := md.Get("x-request-id"); len(v) > 0 {
+		c.requestID = v[0]
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, c)
+	r.mu.Unlock()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// reset forgets the calls recorded so far.
+func (r *callRecorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func TestOverrideTemporarilyTenant(t *testing.T) {
+	tests := []struct {
+		name string
+		// reap tells the override is reverted by a Reaper of another process.
+		reap bool
+	}{
+		{"revert", false},
+		{"reaper", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			recorder := &callRecorder{}
+			newClient := func(now time.Time) *stogo.StooClient {
+				return stogo.NewStoreClient(srv.Config().
+					WithClock(stogotest.NewFakeClock(now)).
+					WithUnaryInterceptors(recorder.interceptor))
+			}
+			acme := newClient(time.Now()).Tenant("acme")
+			if _, err := acme.Set("app", "prod", "http.timeout", "5s"); err != nil {
+				t.Fatal(err)
+			}
+			o, err := acme.Client().OverrideTemporarily("app", "prod", "http.timeout", "30s", time.Hour,
+				stogo.WithTenant("acme"), stogo.WithHeader("x-request-id", "incident-42"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder.reset()
+			if tt.reap {
+				reaper := stogo.NewReaper(newClient(time.Now().Add(2*time.Hour)), "app", "prod").WithTenant("acme")
+				result, err := reaper.ReapOnce()
+				if err == nil {
+					err = result.Err()
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err := o.Revert(); err != nil {
+				t.Fatal(err)
+			}
+			if got := srv.Data("app", "prod")["http.timeout"]; got != "5s" {
+				t.Fatalf("got %q after revert, want %q", got, "5s")
+			}
+			for _, c := range recorder.calls {
+				if c.tenant != "acme" {
+					t.Errorf("%s made for tenant %q, want %q", c.method, c.tenant, "acme")
+				}
+				if strings.HasSuffix(c.method, "KeyService") && c.requestID != "incident-42" {
+					t.Errorf("%s made without the headers of the override", c.method)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package stogo
+
+// TenantClient client making every call on behalf of a tenant, see WithTenant. Tenant clients
+// share the connections and limits of the client they were created from, and keep cached
+// values apart, so a multi-tenant service needs a single client.
+type TenantClient struct {
+	client *StooClient
+	tenant string
+}
+
+// Tenant returns a TenantClient making its calls on behalf of tenant id.
+//
+// Usage example:
+//
+//	acme := client.Tenant("acme")
+//	theme, err := acme.Get("my-app", "prod", "checkout.theme")
+//	if err != nil {
+//		log.Fatalf("Error reading theme %v", err)
+//	}
+func (c *StooClient) Tenant(id string) *TenantClient {
+	return &TenantClient{client: c, tenant: id}
+}
+
+// Client returns the client the tenant client was created from.
+func (t *TenantClient) Client() *StooClient {
+	return t.client
+}
+
+// ID returns the tenant calls are made for.
+func (t *TenantClient) ID() string {
+	return t.tenant
+}
+
+// Get gets the value of key for the tenant, see StooClient.Get.
+func (t *TenantClient) Get(namespace, profile, key string, opts ...CallOption) (string, error) {
+	return t.client.Get(namespace, profile, key, t.options(opts)...)
+}
+
+// GetSecret gets the secret value of key for the tenant, see StooClient.GetSecret.
+func (t *TenantClient) GetSecret(namespace, profile, key string, opts ...CallOption) (SecretValue, error) {
+	return t.client.GetSecret(namespace, profile, key, t.options(opts)...)
+}
+
+// GetAll gets all keys of a namespace and profile for the tenant, see StooClient.GetAll.
+func (t *TenantClient) GetAll(namespace, profile string, opts ...CallOption) (map[string]string, error) {
+	return t.client.GetAll(namespace, profile, t.options(opts)...)
+}
+
+// Set sets key to value for the tenant, see StooClient.Set.
+func (t *TenantClient) Set(namespace, profile, key, value string, opts ...CallOption) (string, error) {
+	return t.client.Set(namespace, profile, key, value, t.options(opts)...)
+}
+
+// SetSecret sets key to value as a secret for the tenant, see StooClient.SetSecret.
+func (t *TenantClient) SetSecret(namespace, profile, key, value string, opts ...CallOption) (string, error) {
+	return t.client.SetSecret(namespace, profile, key, value, t.options(opts)...)
+}
+
+// Delete deletes key for the tenant, see StooClient.Delete.
+func (t *TenantClient) Delete(namespace, profile, key string, opts ...CallOption) (string, error) {
+	return t.client.Delete(namespace, profile, key, t.options(opts)...)
+}
+
+// options returns opts followed by WithTenant, which can't be overridden.
+func (t *TenantClient) options(opts []CallOption) []CallOption {
+	return append(opts[:len(opts):len(opts)], WithTenant(t.tenant))
+}
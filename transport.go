@@ -0,0 +1,224 @@
+package stogo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/mwangox/stogo/config"
+	"github.com/mwangox/stogo/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"os"
+	"strings"
+)
+
+// clientNameHeader metadata header carrying the configured client name.
+const clientNameHeader = "stookv-client-name"
+
+// errInvalidCaCert thrown when the CA certificate file or PEM holds no PEM certificate.
+var errInvalidCaCert = errors.New("failed to append CA certificate")
+
+// dial creates a connection to addr using the given TLS settings. interceptors run after the
+// configured ones, closest to the network.
+func dial(cfg *config.StooConfig, addr string, useTls bool, t *config.TLS, interceptors ...grpc.UnaryClientInterceptor) (*grpc.ClientConn, error) {
+	creds, err := transportCredentials(cfg, useTls, t)
+	if err != nil {
+		return nil, err
+	}
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.GetMaxReceiveSize())),
+		grpc.WithChainUnaryInterceptor(append(cfg.GetUnaryInterceptors(), interceptors...)...),
+		grpc.WithUserAgent(userAgent(cfg)),
+	}
+	if dialer := cfg.GetContextDialer(); dialer != nil {
+		options = append(options, grpc.WithContextDialer(dialer))
+	} else if cfg.GetProxy() != "" {
+		dialer, err := proxyDialer(cfg.GetProxy())
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.WithContextDialer(dialer), grpc.WithNoProxy())
+	} else if delay := cfg.GetHappyEyeballsDelay(); delay > 0 && !strings.HasPrefix(addr, "unix:") {
+		options = append(options, grpc.WithContextDialer(happyEyeballsDialer(delay)))
+	}
+	if compression := cfg.GetCompression(); compression != "" {
+		options = append(options, grpc.WithDefaultCallOptions(grpc.UseCompressor(compression)))
+	}
+	options = append(options, cfg.GetDialOptions()...)
+	return grpc.Dial(addr, options...)
+}
+
+// userAgent returns the user-agent calls are made with: the client name if set, then the stogo
+// version. gRPC appends its own.
+func userAgent(cfg *config.StooConfig) string {
+	if name := cfg.GetClientName(); name != "" {
+		return name + " stogo/" + Version
+	}
+	return "stogo/" + Version
+}
+
+// transportCredentials builds transport credentials from useTls and t.
+func transportCredentials(cfg *config.StooConfig, useTls bool, t *config.TLS) (credentials.TransportCredentials, error) {
+	if !useTls {
+		return insecure.NewCredentials(), nil
+	}
+	tlsConfig, err := clientTLSConfig(cfg, t)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// clientTLSConfig builds the TLS configuration StooKV is reached with from t.
+func clientTLSConfig(cfg *config.StooConfig, t *config.TLS) (*tls.Config, error) {
+	if t == nil {
+		t = &config.TLS{}
+	}
+	if t.Config != nil {
+		tlsConfig := t.Config.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = t.ServerNameOverride
+		}
+		return tlsConfig, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         t.ServerNameOverride,
+		InsecureSkipVerify: t.SkipTlsVerification,
+	}
+	pool, err := rootCAs(t)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.RootCAs = pool
+	cert, err := clientCertificate(t)
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+	}
+	if t.ReloadInterval > 0 {
+		newCertReloader(cfg, t, pool, cert).configure(tlsConfig)
+	}
+	return tlsConfig, nil
+}
+
+// rootCAs returns the CA certificates StooKV is verified with, nil to use the system ones.
+func rootCAs(t *config.TLS) (*x509.CertPool, error) {
+	if t.SkipTlsVerification || (t.CaCertPath == "" && len(t.CaCertPEM) == 0) {
+		return nil, nil
+	}
+	pool := x509.NewCertPool()
+	if t.UseSystemCertPool {
+		var err error
+		if pool, err = x509.SystemCertPool(); err != nil {
+			return nil, err
+		}
+	}
+	if t.CaCertPath != "" {
+		pem, err := os.ReadFile(t.CaCertPath)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errInvalidCaCert
+		}
+	}
+	if len(t.CaCertPEM) > 0 && !pool.AppendCertsFromPEM(t.CaCertPEM) {
+		return nil, errInvalidCaCert
+	}
+	return pool, nil
+}
+
+// clientCertificate returns the certificate presented to StooKV, nil if there is none.
+func clientCertificate(t *config.TLS) (*tls.Certificate, error) {
+	var cert tls.Certificate
+	var err error
+	switch {
+	case t.CertPath != "":
+		cert, err = tls.LoadX509KeyPair(t.CertPath, t.KeyPath)
+	case len(t.CertPEM) > 0:
+		cert, err = tls.X509KeyPair(t.CertPEM, t.KeyPEM)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// newContext creates a call context bounded by the read timeout, or by the deadline of the
+// context of the call options if it comes first, and carrying the configured headers and client
+// name, the credentials configured for namespace, the metadata and tenant of the call options
+// and the caller identity.
+func (c *StooClient) newContext(namespace string, o *callOptions) (context.Context, context.CancelFunc) {
+	cfg := c.CurrentConfig()
+	ctx, cancel := withBudget(o.ctx, cfg.GetClock(), cfg.GetReadTimeout())
+	for key, value := range cfg.GetHeaders() {
+		ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+	}
+	if name := cfg.GetClientName(); name != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, clientNameHeader, name)
+	}
+	if len(o.headers) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, o.headers...)
+	}
+	if o.tenant != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, cfg.GetTenantHeader(), o.tenant)
+	}
+	if creds, ok := cfg.GetNamespaceCredentials(namespace); ok && creds.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+creds.Token)
+	}
+	if o.idempotencyKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "idempotency-key", o.idempotencyKey)
+	}
+	if o.identity != "" {
+		ctx = context.WithValue(ctx, identityKey{}, o.identity)
+	}
+	return ctx, cancel
+}
+
+// kv returns the KVServiceClient to be used for calls made on namespace, hedging reads if
+// configured.
+func (c *StooClient) kv(namespace string) proto.KVServiceClient {
+	if _, attempts := c.CurrentConfig().GetHedging(); attempts > 1 {
+		return hedgedKVClient{c: c, namespace: namespace}
+	}
+	return c.pick(namespace).kv
+}
+
+// failingKVClient KVServiceClient of a client which couldn't be set up, failing every call with err.
+type failingKVClient struct {
+	err error
+}
+
+// GetService returns err.
+func (f failingKVClient) GetService(context.Context, *proto.GetRequest, ...grpc.CallOption) (*proto.GetResponse, error) {
+	return nil, f.err
+}
+
+// GetServiceByNamespaceAndProfile returns err.
+func (f failingKVClient) GetServiceByNamespaceAndProfile(context.Context, *proto.GetByNamespaceAndProfileRequest, ...grpc.CallOption) (*proto.GetByNamespaceAndProfileResponse, error) {
+	return nil, f.err
+}
+
+// SetKeyService returns err.
+func (f failingKVClient) SetKeyService(context.Context, *proto.SetKeyRequest, ...grpc.CallOption) (*proto.SetKeyResponse, error) {
+	return nil, f.err
+}
+
+// SetSecretKeyService returns err.
+func (f failingKVClient) SetSecretKeyService(context.Context, *proto.SetKeyRequest, ...grpc.CallOption) (*proto.SetKeyResponse, error) {
+	return nil, f.err
+}
+
+// DeleteKeyService returns err.
+func (f failingKVClient) DeleteKeyService(context.Context, *proto.DeleteKeyRequest, ...grpc.CallOption) (*proto.DeleteKeyResponse, error) {
+	return nil, f.err
+}
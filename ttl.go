@@ -0,0 +1,67 @@
+package stogo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrKeyExpired thrown when reading a key whose TTL has elapsed. It wraps ErrKeyNotFound.
+var ErrKeyExpired = fmt.Errorf("%w: ttl elapsed", ErrKeyNotFound)
+
+// SetWithTTL sets a key like Set and records that it expires after ttl. StooKV has no native
+// TTL, so the expiry is kept by the client in a reserved __stogo.ttl.* key, GetWithExpiry
+// treats expired keys as missing and a Reaper deletes them. The expiry is written with opts, so
+// for the same tenant as the key, a Reaper must be created with Reaper.WithTenant to delete
+// keys of a tenant. A ttl that is not positive removes the expiry of the key.
+//
+// Usage example:
+//
+//	_, err := client.SetWithTTL("my-app", "prod", "feature.checkout.override", "true", 2*time.Hour)
+//	if err != nil {
+//		log.Fatalf("Error in setting value %v", err)
+//	}
+func (c *StooClient) SetWithTTL(namespace, profile, key, value string, ttl time.Duration, opts ...CallOption) (string, error) {
+	res, err := c.Set(namespace, profile, key, value, opts...)
+	if err != nil {
+		return res, err
+	}
+	if ttl <= 0 {
+		_, err = c.Delete(namespace, profile, ttlKey(key), bookkeepingOptions(opts)...)
+		return res, err
+	}
+	expiresAt := c.CurrentConfig().GetClock().Now().Add(ttl)
+	_, err = c.Set(namespace, profile, ttlKey(key), expiresAt.UTC().Format(time.RFC3339Nano), bookkeepingOptions(opts)...)
+	return res, err
+}
+
+// GetWithExpiry gets a value like Get along with the time it expires at, which is zero for keys
+// set without a TTL. Expired keys fail with ErrKeyExpired.
+func (c *StooClient) GetWithExpiry(namespace, profile, key string, opts ...CallOption) (string, time.Time, error) {
+	expiresAt, err := c.expiry(namespace, profile, key, opts...)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if !expiresAt.IsZero() && !c.CurrentConfig().GetClock().Now().Before(expiresAt) {
+		return "", expiresAt, fmt.Errorf("%w: %s/%s/%s", ErrKeyExpired, namespace, profile, key)
+	}
+	value, err := c.Get(namespace, profile, key, opts...)
+	return value, expiresAt, err
+}
+
+// expiry returns the time key expires at, zero if it has no TTL, reading it with opts.
+func (c *StooClient) expiry(namespace, profile, key string, opts ...CallOption) (time.Time, error) {
+	raw, err := c.Get(namespace, profile, ttlKey(key), opts...)
+	if errors.Is(err, ErrKeyNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil || raw == "" {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, raw)
+}
+
+// ttlKey returns the reserved key holding the expiry of key.
+func ttlKey(key string) string {
+	return ReservedKeyPrefix + "ttl." + key
+}
@@ -0,0 +1,61 @@
+package stogo_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/stogotest"
+	"testing"
+	"time"
+)
+
+func TestSetWithTTL(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []stogo.CallOption
+		tenant string
+	}{
+		{"no options", nil, ""},
+		{"tenant", []stogo.CallOption{stogo.WithTenant("acme")}, "acme"},
+		{"idempotency key", []stogo.CallOption{stogo.WithIdempotencyKey("flag-42")}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			recorder := &callRecorder{}
+			clock := stogotest.NewFakeClock(time.Now())
+			client := stogo.NewStoreClient(srv.Config().WithClock(clock).WithUnaryInterceptors(recorder.interceptor))
+
+			if _, err := client.SetWithTTL("app", "prod", "feature.flag", "true", time.Hour, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			value, expiresAt, err := client.GetWithExpiry("app", "prod", "feature.flag", tt.opts...)
+			if err != nil || value != "true" {
+				t.Fatalf("got %q, %v, want %q", value, err, "true")
+			}
+			if want := clock.Now().Add(time.Hour); !expiresAt.Equal(want) {
+				t.Fatalf("got expiry %v, want %v", expiresAt, want)
+			}
+			for _, c := range recorder.calls {
+				if c.tenant != tt.tenant {
+					t.Errorf("%s made for tenant %q, want %q", c.method, c.tenant, tt.tenant)
+				}
+			}
+
+			clock.Advance(time.Hour)
+			if _, _, err := client.GetWithExpiry("app", "prod", "feature.flag", tt.opts...); !errors.Is(err, stogo.ErrKeyExpired) {
+				t.Fatalf("got error %v, want %v", err, stogo.ErrKeyExpired)
+			}
+			result, err := stogo.NewReaper(client, "app", "prod").WithTenant(tt.tenant).ReapOnce()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Succeeded) != 1 || len(srv.Data("app", "prod")) != 0 {
+				t.Fatalf("got reaped %v leaving %v, want the key and its expiry deleted", result.Succeeded, srv.Data("app", "prod"))
+			}
+		})
+	}
+}
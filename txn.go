@@ -0,0 +1,116 @@
+package stogo
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTxnDone thrown when committing a Txn which was already committed.
+var ErrTxnDone = errors.New("transaction already committed")
+
+// Txn group of writes, possibly spanning several namespaces and profiles, committed together
+// with the same best effort atomicity as Apply. Txn is not safe for concurrent use.
+type Txn struct {
+	client *StooClient
+	ctx    context.Context
+	sets   []*ChangeSet
+	done   bool
+}
+
+// Txn starts a transaction whose reads and writes run within ctx. StooKV has no transaction
+// RPC, so Commit emulates one: every write is checked against the current values before
+// anything is written, and if a write fails, every key already written, in any namespace and
+// profile, is restored to its previous value. Concurrent writers can still interleave with a
+// commit, and a restore can fail too, in which case the commit error says so.
+//
+// Usage example:
+//
+//	err := client.Txn(ctx).
+//		Set("my-app", "prod", "database.host", "db2.internal").
+//		Set("my-app", "prod", "database.port", "5433").
+//		Delete("my-app", "prod", "database.replica").
+//		Commit()
+func (c *StooClient) Txn(ctx context.Context) *Txn {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Txn{client: c, ctx: ctx}
+}
+
+// Set adds a write setting key to value.
+func (t *Txn) Set(namespace, profile, key, value string) *Txn {
+	t.changeSet(namespace, profile).Set(key, value)
+	return t
+}
+
+// SetSecret adds a write setting key to value as a secret.
+func (t *Txn) SetSecret(namespace, profile, key, value string) *Txn {
+	t.changeSet(namespace, profile).SetSecret(key, value)
+	return t
+}
+
+// Delete adds a write deleting key.
+func (t *Txn) Delete(namespace, profile, key string) *Txn {
+	t.changeSet(namespace, profile).Delete(key)
+	return t
+}
+
+// Commit applies the writes in the order they were added, grouped by namespace and profile.
+// Like Apply, the values each namespace and profile ends up with are checked against the
+// schema once, before anything is written.
+func (t *Txn) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.done = true
+
+	type pending struct {
+		cs        *ChangeSet
+		before    map[string]string
+		mutations []mutation
+	}
+	opts := []CallOption{WithContext(t.ctx)}
+	var all []pending
+	for _, cs := range t.sets {
+		if err := cs.Validate(); err != nil {
+			return err
+		}
+		// Read the stored values, the overrides of the context are not StooKV state.
+		before, err := t.client.getAll(cs.Namespace, cs.Profile, newCallOptions(opts))
+		if err != nil {
+			return err
+		}
+		mutations, err := t.client.resolveEdits(cs, before)
+		if err != nil {
+			return err
+		}
+		if err := t.client.checkFinalSchema(cs.Namespace, cs.Profile, before, mutations); err != nil {
+			return err
+		}
+		all = append(all, pending{cs: cs, before: before, mutations: mutations})
+	}
+
+	for i, p := range all {
+		if err := t.client.applyAtomically(p.cs.Namespace, p.cs.Profile, p.before, p.mutations, append(opts, withoutSchemaCheck())...); err != nil {
+			errs := []error{err}
+			for j := i - 1; j >= 0; j-- {
+				done := all[j]
+				errs = append(errs, t.client.rollback(done.cs.Namespace, done.cs.Profile, done.before, done.mutations))
+			}
+			return errors.Join(errs...)
+		}
+	}
+	return nil
+}
+
+// changeSet returns the change set of a namespace and profile, creating it on first use.
+func (t *Txn) changeSet(namespace, profile string) *ChangeSet {
+	for _, cs := range t.sets {
+		if cs.Namespace == namespace && cs.Profile == profile {
+			return cs
+		}
+	}
+	cs := NewChangeSet(namespace, profile)
+	t.sets = append(t.sets, cs)
+	return cs
+}
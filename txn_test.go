@@ -0,0 +1,101 @@
+package stogo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/stogotest"
+	"reflect"
+	"testing"
+)
+
+func TestTxnCommit(t *testing.T) {
+	schema := stogo.RequirementsSchema([]stogo.Requirement{
+		{Key: "db.host", NonEmpty: true},
+	})
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		txn     func(*stogo.Txn) *stogo.Txn
+		wantErr error
+		// want values of app/prod and app/staging after the commit.
+		want map[string]map[string]string
+	}{
+		{
+			name: "intermediate state breaks the schema",
+			txn: func(txn *stogo.Txn) *stogo.Txn {
+				return txn.Delete("app", "prod", "db.host").Set("app", "prod", "db.host", "db2.int")
+			},
+			want: map[string]map[string]string{
+				"prod":    {"db.host": "db2.int"},
+				"staging": {"db.host": "db.staging"},
+			},
+		},
+		{
+			name: "final state breaks the schema",
+			txn: func(txn *stogo.Txn) *stogo.Txn {
+				return txn.Set("app", "staging", "db.host", "db2.stg").Delete("app", "prod", "db.host")
+			},
+			wantErr: stogo.ErrSchemaViolation,
+			want: map[string]map[string]string{
+				"prod":    {"db.host": "db.internal"},
+				"staging": {"db.host": "db.staging"},
+			},
+		},
+		{
+			name: "overrides of the context are not stored",
+			ctx:  stogo.WithOverrides(context.Background(), map[string]string{"db.host": "local"}),
+			txn: func(txn *stogo.Txn) *stogo.Txn {
+				return txn.Set("app", "prod", "db.host", "db2.int").Set("app", "prod", "db.pool", "far too large")
+			},
+			wantErr: stogo.ErrValueTooLarge,
+			want: map[string]map[string]string{
+				"prod":    {"db.host": "db.internal"},
+				"staging": {"db.host": "db.staging"},
+			},
+		},
+		{
+			name: "write failure rolls back earlier profiles",
+			txn: func(txn *stogo.Txn) *stogo.Txn {
+				return txn.Set("app", "prod", "db.host", "db2.int").Set("app", "staging", "db.port", "far too large")
+			},
+			wantErr: stogo.ErrValueTooLarge,
+			want: map[string]map[string]string{
+				"prod":    {"db.host": "db.internal"},
+				"staging": {"db.host": "db.staging"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := stogotest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			client := stogo.NewStoreClient(srv.Config().
+				WithSchema("app", "", schema).
+				WithValidateWrites(true).
+				WithMaxValueSize(len("db.internal")))
+			if _, err := client.Set("app", "prod", "db.host", "db.internal"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Set("app", "staging", "db.host", "db.staging"); err != nil {
+				t.Fatal(err)
+			}
+
+			txn := tt.txn(client.Txn(tt.ctx))
+			if err := txn.Commit(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			for profile, want := range tt.want {
+				if got := srv.Data("app", profile); !reflect.DeepEqual(got, want) {
+					t.Errorf("got app/%s %v, want %v", profile, got, want)
+				}
+			}
+			if err := txn.Commit(); !errors.Is(err, stogo.ErrTxnDone) {
+				t.Errorf("got error %v committing again, want %v", err, stogo.ErrTxnDone)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package stogo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidUnmarshalTarget thrown by Unmarshal when the target is not a non-nil pointer to a struct.
+var ErrInvalidUnmarshalTarget = errors.New("unmarshal target must be a non-nil pointer to a struct")
+
+// UnmarshalError reports keys which don't match the target struct.
+type UnmarshalError struct {
+	// Missing keys of required fields not present in the data.
+	Missing []string
+	// Unknown keys present in the data but not mapped to any field, reserved keys aside, only
+	// reported by UnmarshalStrict.
+	Unknown []string
+}
+
+// Error implements error.
+func (e *UnmarshalError) Error() string {
+	var problems []string
+	if len(e.Missing) > 0 {
+		problems = append(problems, "missing required keys: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		problems = append(problems, "unknown keys: "+strings.Join(e.Unknown, ", "))
+	}
+	return "stogo: unmarshal: " + strings.Join(problems, "; ")
+}
+
+// decodeState tracks keys used while decoding a struct.
+type decodeState struct {
+	data    map[string]string
+	used    map[string]bool
+	missing []string
+}
+
+// durationType is used to tell time.Duration fields apart from plain int64 ones.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Unmarshal copies key value pairs into the struct pointed by v. Fields are mapped to keys
+// using the `stoo` tag, untagged fields are ignored. A tagged struct field uses its tag as a
+// prefix for the keys of its own fields while an untagged one is walked with the current prefix.
+// Supported field types are string, bool, ints, uints, floats and time.Duration. Fields whose
+// key is missing are left untouched, unless tagged as required, e.g. `stoo:"database.url,required"`,
+// in which case an *UnmarshalError lists all missing keys.
+//
+// Usage example:
+//
+//	type Config struct {
+//		Username string        `stoo:"database.username,required"`
+//		Port     int           `stoo:"database.port"`
+//		Timeout  time.Duration `stoo:"database.timeout"`
+//	}
+//
+//	all, _ := client.GetAll("my-app", "prod")
+//	var cfg Config
+//	if err := stogo.Unmarshal(all, &cfg); err != nil {
+//		log.Fatalf("Error decoding config %v", err)
+//	}
+func Unmarshal(data map[string]string, v any) error {
+	return unmarshal(data, v, false)
+}
+
+// UnmarshalStrict works like Unmarshal but also fails when data holds keys not mapped to
+// any field, catching typos such as databse.username early. Reserved keys are not reported.
+func UnmarshalStrict(data map[string]string, v any) error {
+	return unmarshal(data, v, true)
+}
+
+// unmarshal decodes data into v, reporting unknown keys if strict.
+func unmarshal(data map[string]string, v any, strict bool) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidUnmarshalTarget
+	}
+	return decodeInto(data, rv.Elem(), strict)
+}
+
+// decodeInto decodes data into the struct value rv, reporting unknown keys if strict.
+func decodeInto(data map[string]string, rv reflect.Value, strict bool) error {
+	state := &decodeState{data: data, used: make(map[string]bool)}
+	if err := state.decodeStruct(rv, ""); err != nil {
+		return err
+	}
+	uerr := &UnmarshalError{Missing: state.missing}
+	if strict {
+		for _, key := range sortedKeys(data) {
+			if !state.used[key] && !IsReservedKey(key) {
+				uerr.Unknown = append(uerr.Unknown, key)
+			}
+		}
+	}
+	if len(uerr.Missing) > 0 || len(uerr.Unknown) > 0 {
+		return uerr
+	}
+	return nil
+}
+
+// decodeStruct sets fields of the struct value rv, prefixing keys with prefix.
+func (d *decodeState) decodeStruct(rv reflect.Value, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("stoo")
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			nested := prefix
+			if tagged && name != "" {
+				nested = prefix + name + "."
+			}
+			if err := d.decodeStruct(fv, nested); err != nil {
+				return err
+			}
+			continue
+		}
+		if !tagged || name == "" {
+			continue
+		}
+		key := prefix + name
+		value, ok := d.data[key]
+		if !ok {
+			if options == "required" {
+				d.missing = append(d.missing, key)
+			}
+			continue
+		}
+		d.used[key] = true
+		if err := setField(fv, value); err != nil {
+			return fmt.Errorf("stogo: unmarshal key %s into %s: %w", key, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// setField parses value according to the kind of fv and stores it.
+func setField(fv reflect.Value, value string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package stogo_test
+
+import (
+	"errors"
+	"github.com/mwangox/stogo"
+	"github.com/mwangox/stogo/stogotest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type strictConfig struct {
+	Host    string `stoo:"db.host"`
+	Port    int    `stoo:"db.port"`
+	Session string `stoo:"session"`
+	Cert    string `stoo:"cert"`
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	srv, err := stogotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	client := stogo.NewStoreClient(srv.Config())
+	if _, err := client.Set("app", "prod", "db.host", "db.internal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Set("app", "prod", "db.port", "5432"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SetWithTTL("app", "prod", "session", "token", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PutFile("app", "prod", "cert", strings.NewReader("certificate")); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := client.GetAll("app", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        map[string]string
+		wantUnknown []string
+	}{
+		{"reserved keys written by stogo", stored, nil},
+		{"reserved key set by hand", map[string]string{"db.host": "db.internal", stogo.ReservedKeyPrefix + "parent": "base"}, nil},
+		{"unknown keys", map[string]string{"db.host": "db.internal", "databse.port": "5432", "__db.port": "5432"}, []string{"__db.port", "databse.port"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg strictConfig
+			err := stogo.UnmarshalStrict(tt.data, &cfg)
+			if tt.wantUnknown == nil {
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+				return
+			}
+			var uerr *stogo.UnmarshalError
+			if !errors.As(err, &uerr) {
+				t.Fatalf("got error %v, want an UnmarshalError", err)
+			}
+			if !reflect.DeepEqual(uerr.Unknown, tt.wantUnknown) {
+				t.Fatalf("got unknown keys %v, want %v", uerr.Unknown, tt.wantUnknown)
+			}
+		})
+	}
+}
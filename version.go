@@ -0,0 +1,4 @@
+package stogo
+
+// Version version of the stogo library, sent in the user-agent of every call.
+const Version = "0.1.0"
@@ -0,0 +1,186 @@
+package stogo
+
+import (
+	"context"
+	"github.com/mwangox/stogo/config"
+	"sync"
+	"time"
+)
+
+// Watch polls a namespace and profile and calls fn with all key value pairs whenever they
+// differ from the previous poll, starting with the first successful one. StooKV has no
+// server side watch, so changes are detected by comparing GetAll results.
+// If interval is not positive the configured poll interval is used. Failed polls are skipped
+// and retried on the next tick. Watch blocks until ctx is done and returns ctx.Err().
+//
+// Watchers of the same namespace and profile within a client share a single poll loop running
+// at the shortest interval among them, so adding watchers doesn't add calls to StooKV.
+//
+// Usage example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	go client.Watch(ctx, "my-app", "prod", 10*time.Second, func(all map[string]string) {
+//		log.Printf("my-app/prod changed: %v", all)
+//	})
+func (c *StooClient) Watch(ctx context.Context, namespace, profile string, interval time.Duration, fn func(map[string]string)) error {
+	if interval <= 0 {
+		interval = c.CurrentConfig().GetPollInterval()
+	}
+	updates, unsubscribe := c.pollers.subscribe(c, config.NamespaceProfile{Namespace: namespace, Profile: profile}, interval)
+	defer unsubscribe()
+
+	var last map[string]string
+	seen := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case data := <-updates:
+			if !seen || !equalValues(last, data) {
+				seen = true
+				last = data
+				fn(data)
+			}
+		}
+	}
+}
+
+// pollers shared poll loops of the watched profiles of a client. The zero value is ready to use.
+type pollers struct {
+	mu    sync.Mutex
+	loops map[config.NamespaceProfile]*poller
+}
+
+// poller poll loop of a profile, fanning results out to its subscribers.
+type poller struct {
+	mu   sync.Mutex
+	subs map[chan map[string]string]time.Duration
+	last map[string]string
+	seen bool
+	wake chan struct{}
+	stop chan struct{}
+}
+
+// subscribe registers a subscriber polling p every interval and returns the channel receiving
+// the latest values along with the function removing the subscription.
+func (ps *pollers) subscribe(c *StooClient, p config.NamespaceProfile, interval time.Duration) (<-chan map[string]string, func()) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.loops == nil {
+		ps.loops = make(map[config.NamespaceProfile]*poller)
+	}
+	loop, ok := ps.loops[p]
+	if !ok {
+		loop = &poller{
+			subs: make(map[chan map[string]string]time.Duration),
+			wake: make(chan struct{}, 1),
+			stop: make(chan struct{}),
+		}
+		ps.loops[p] = loop
+	}
+
+	updates := make(chan map[string]string, 1)
+	loop.mu.Lock()
+	loop.subs[updates] = interval
+	if loop.seen {
+		updates <- copyValues(loop.last)
+	}
+	loop.mu.Unlock()
+	if ok {
+		select {
+		case loop.wake <- struct{}{}:
+		default:
+		}
+	} else {
+		go loop.run(c, p)
+	}
+
+	return updates, func() {
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		loop.mu.Lock()
+		delete(loop.subs, updates)
+		empty := len(loop.subs) == 0
+		loop.mu.Unlock()
+		if empty {
+			close(loop.stop)
+			delete(ps.loops, p)
+		}
+	}
+}
+
+// run polls until the last subscriber leaves.
+func (p *poller) run(c *StooClient, np config.NamespaceProfile) {
+	for {
+		data, err := c.GetAll(np.Namespace, np.Profile)
+		if err != nil {
+			c.CurrentConfig().GetLogger().Warnf("watch %s/%s: %v", np.Namespace, np.Profile, err)
+		} else {
+			p.publish(data)
+		}
+		if !p.wait(c.CurrentConfig().GetClock()) {
+			return
+		}
+	}
+}
+
+// wait blocks for the shortest interval among the subscribers, starting over when one joins
+// since its interval may be shorter. It returns false once the last subscriber left.
+func (p *poller) wait(clock config.Clock) bool {
+	for {
+		interval := p.interval()
+		if interval == 0 {
+			<-p.stop
+			return false
+		}
+		select {
+		case <-p.stop:
+			return false
+		case <-clock.After(interval):
+			return true
+		case <-p.wake:
+		}
+	}
+}
+
+// publish hands a copy of data to every subscriber, replacing values they haven't received yet.
+func (p *poller) publish(data map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = data
+	p.seen = true
+	for updates := range p.subs {
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- copyValues(data)
+	}
+}
+
+// interval returns the shortest interval among the subscribers.
+func (p *poller) interval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var shortest time.Duration
+	for _, interval := range p.subs {
+		if shortest == 0 || interval < shortest {
+			shortest = interval
+		}
+	}
+	return shortest
+}
+
+// equalValues reports whether a and b hold the same key value pairs.
+func equalValues(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}